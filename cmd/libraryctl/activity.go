@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"pkg/service/pkg/models/request"
+	"strconv"
+	"time"
+)
+
+var runActivity = subcommands("activity", map[string]command{
+	"dump":  {usage: "print the activity events of a user, newest first", run: runActivityDump},
+	"clear": {usage: "delete the recorded activity of a user", run: runActivityClear},
+})
+
+func runActivityDump(args []string) error {
+	flags := flag.NewFlagSet("activity dump", flag.ContinueOnError)
+	username := flags.String("username", "", "username")
+	from := flags.String("from", "", "only events at or after this RFC 3339 time")
+	to := flags.String("to", "", "only events at or before this RFC 3339 time")
+	req := request.GetUserActivity{}
+	flags.IntVar(&req.Offset, "offset", 0, "number of events to skip")
+	flags.IntVar(&req.Limit, "limit", 0, "maximum number of events")
+	if err := parseWithUsername(flags, args, username); err != nil {
+		return err
+	}
+
+	var err error
+	if req.From, err = parseTimeFlag("from", *from); err != nil {
+		return err
+	}
+	if req.To, err = parseTimeFlag("to", *to); err != nil {
+		return err
+	}
+	if err = binding.Validator.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	res, err := newUsersHandler().GetUserActivity(*username, req)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(res.Events))
+	for _, event := range res.Events {
+		rows = append(rows, []string{
+			event.Timestamp.Format(time.RFC3339),
+			event.Method,
+			event.Path,
+			strconv.Itoa(event.Status),
+			event.ErrorCode,
+			fmt.Sprintf("%.1fms", event.LatencyMs),
+			event.ResourceId,
+			event.ClientIp,
+		})
+	}
+	return printResult(res, []string{"TIME", "METHOD", "PATH", "STATUS", "ERROR", "LATENCY", "RESOURCE", "CLIENT IP"}, rows)
+}
+
+func runActivityClear(args []string) error {
+	flags := flag.NewFlagSet("activity clear", flag.ContinueOnError)
+	username := flags.String("username", "", "username")
+	if err := parseWithUsername(flags, args, username); err != nil {
+		return err
+	}
+
+	if err := newUsersHandler().ClearUserActivity(*username); err != nil {
+		return err
+	}
+	return printResult(map[string]string{"username": *username}, []string{"CLEARED"}, [][]string{{*username}})
+}
+
+func parseWithUsername(flags *flag.FlagSet, args []string, username *string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+	return nil
+}
+
+func parseTimeFlag(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("-%s must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"os"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"time"
+)
+
+var runBooks = subcommands("books", map[string]command{
+	"create":  {usage: "create a book from a JSON document", run: runBooksCreate},
+	"get":     {usage: "get a book by id", run: runBooksGet},
+	"update":  {usage: "update the title of a book", run: runBooksUpdate},
+	"delete":  {usage: "delete a book", run: runBooksDelete},
+	"search":  {usage: "search books with the GET /books filters", run: runBooksSearch},
+	"trash":   {usage: "list the deleted books", run: runBooksTrash},
+	"restore": {usage: "restore a deleted book", run: runBooksRestore},
+	"purge":   {usage: "permanently delete a book from the trash", run: runBooksPurge},
+})
+
+func runBooksCreate(args []string) error {
+	flags := flag.NewFlagSet("books create", flag.ContinueOnError)
+	file := flags.String("file", "-", "JSON document shaped like the POST /books body, - for standard input")
+	allowDuplicate := flags.Bool("allow-duplicate", false, "create the book even if it looks like an existing one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	req := request.CreateBook{}
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return fmt.Errorf("invalid book document: %w", err)
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	id, err := newBooksHandler().CreateBook(req, *allowDuplicate, requestInfo())
+	if err != nil {
+		return err
+	}
+	return printResult(map[string]string{"id": id}, []string{"ID"}, [][]string{{id}})
+}
+
+func runBooksGet(args []string) error {
+	flags := flag.NewFlagSet("books get", flag.ContinueOnError)
+	id := flags.String("id", "", "book id")
+	if err := parseWithId(flags, args, id); err != nil {
+		return err
+	}
+
+	res, err := newBooksHandler().GetBookById(*id)
+	if err != nil {
+		return err
+	}
+	return printResult(res, bookHeaders, bookRows([]models.Book{res.Book}))
+}
+
+func runBooksUpdate(args []string) error {
+	flags := flag.NewFlagSet("books update", flag.ContinueOnError)
+	id := flags.String("id", "", "book id")
+	title := flags.String("title", "", "new title")
+	version := addVersionFlag(flags)
+	if err := parseWithId(flags, args, id); err != nil {
+		return err
+	}
+
+	req := request.UpdateBookTitle{Title: *title}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return err
+	}
+	expected, err := version()
+	if err != nil {
+		return err
+	}
+	updated, err := newBooksHandler().UpdateBookTitle(*id, expected, req, requestInfo())
+	if err != nil {
+		return err
+	}
+	res := map[string]string{"id": *id, "title": *title, "version": updated.String()}
+	return printResult(res, []string{"ID", "TITLE", "VERSION"}, [][]string{{*id, *title, updated.String()}})
+}
+
+func runBooksDelete(args []string) error {
+	flags := flag.NewFlagSet("books delete", flag.ContinueOnError)
+	id := flags.String("id", "", "book id")
+	version := addVersionFlag(flags)
+	if err := parseWithId(flags, args, id); err != nil {
+		return err
+	}
+
+	expected, err := version()
+	if err != nil {
+		return err
+	}
+	if err = newBooksHandler().DeleteBook(*id, expected, requestInfo()); err != nil {
+		return err
+	}
+	return printResult(map[string]string{"id": *id}, []string{"DELETED"}, [][]string{{*id}})
+}
+
+func runBooksSearch(args []string) error {
+	flags := flag.NewFlagSet("books search", flag.ContinueOnError)
+	filters := addBookFilterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	req := filters()
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return err
+	}
+	res, err := newBooksHandler().GetBooks(req)
+	if err != nil {
+		return err
+	}
+	return printResult(res, bookHeaders, bookRows(res.Books))
+}
+
+func runBooksTrash(args []string) error {
+	flags := flag.NewFlagSet("books trash", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := newBooksHandler().GetTrash()
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(res.Books))
+	for _, book := range res.Books {
+		rows = append(rows, []string{book.Id, book.Title, book.DeletedAt.Format(time.RFC3339), book.DeletedBy})
+	}
+	return printResult(res, []string{"ID", "TITLE", "DELETED AT", "DELETED BY"}, rows)
+}
+
+func runBooksRestore(args []string) error {
+	flags := flag.NewFlagSet("books restore", flag.ContinueOnError)
+	id := flags.String("id", "", "book id")
+	allowDuplicate := flags.Bool("allow-duplicate", false, "restore the book even if it looks like an existing one")
+	if err := parseWithId(flags, args, id); err != nil {
+		return err
+	}
+
+	if err := newBooksHandler().RestoreBook(*id, *allowDuplicate, requestInfo()); err != nil {
+		return err
+	}
+	return printResult(map[string]string{"id": *id}, []string{"RESTORED"}, [][]string{{*id}})
+}
+
+func runBooksPurge(args []string) error {
+	flags := flag.NewFlagSet("books purge", flag.ContinueOnError)
+	id := flags.String("id", "", "book id")
+	if err := parseWithId(flags, args, id); err != nil {
+		return err
+	}
+
+	if err := newBooksHandler().PurgeBook(*id, requestInfo()); err != nil {
+		return err
+	}
+	return printResult(map[string]string{"id": *id}, []string{"PURGED"}, [][]string{{*id}})
+}
+
+func parseWithId(flags *flag.FlagSet, args []string, id *string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+	return nil
+}
+
+// addVersionFlag registers -version, which makes a write fail if the book was
+// modified since that version. Without it the write applies to any version.
+func addVersionFlag(flags *flag.FlagSet) func() (*models.BookVersion, error) {
+	value := flags.String("version", "", "version printed by books get")
+	return func() (*models.BookVersion, error) {
+		if *value == "" {
+			return nil, nil
+		}
+		version, err := models.ParseBookVersion(*value)
+		if err != nil {
+			return nil, err
+		}
+		return &version, nil
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"io"
+	"os"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/formats"
+)
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", consts.FormatNdjson, "ndjson, csv or marcxml")
+	out := flags.String("out", "", "output file, standard output when omitted")
+	useGzip := flags.Bool("gzip", false, "gzip the output")
+	filters := addBookFilterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	buffered := bufio.NewWriter(w)
+	w = buffered
+	var gzipWriter *gzip.Writer
+	if *useGzip {
+		gzipWriter = gzip.NewWriter(buffered)
+		w = gzipWriter
+	}
+
+	writer, err := formats.NewBookWriter(*format, w)
+	if err != nil {
+		return err
+	}
+
+	if err = newBooksHandler().ExportBooks(filters(), writer); err != nil {
+		return err
+	}
+	if gzipWriter != nil {
+		if err = gzipWriter.Close(); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
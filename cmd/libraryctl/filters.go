@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"pkg/service/pkg/models/request"
+)
+
+// addBookFilterFlags registers the GET /books query parameters as flags and
+// returns a function building the request once the flags are parsed.
+func addBookFilterFlags(flags *flag.FlagSet) func() request.GetBooks {
+	req := request.GetBooks{}
+	flags.StringVar(&req.Query, "q", "", "full text query")
+	flags.StringVar(&req.Title, "title", "", "exact title")
+	flags.StringVar(&req.AuthorId, "author-id", "", "author id")
+	flags.StringVar(&req.AuthorName, "author-name", "", "exact author name")
+	flags.StringVar(&req.BranchId, "branch-id", "", "only books with copies available at this branch")
+	flags.StringVar(&req.Isbn, "isbn", "", "ISBN-10 or ISBN-13")
+	flags.StringVar(&req.Publisher, "publisher", "", "exact publisher")
+	flags.StringVar(&req.Genre, "genre", "", "exact genre")
+	flags.StringVar(&req.Language, "language", "", "language tag")
+	flags.StringVar(&req.PublishedAfter, "published-after", "", "YYYY, YYYY-MM or YYYY-MM-DD")
+	flags.StringVar(&req.PublishedBefore, "published-before", "", "YYYY, YYYY-MM or YYYY-MM-DD")
+	flags.StringVar(&req.Sort, "sort", "", "publish_date, price, prefixed with - for descending")
+	minPrice := flags.Float64("min-price", 0, "minimum price")
+	maxPrice := flags.Float64("max-price", 0, "maximum price")
+	minPages := flags.Int("min-pages", 0, "minimum page count")
+	maxPages := flags.Int("max-pages", 0, "maximum page count")
+	ebook := flags.Bool("ebook", false, "only books with (true) or without (false) an ebook")
+	decade := flags.Int("decade", 0, "only books published in the decade starting at this year")
+
+	return func() request.GetBooks {
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "min-price":
+				req.MinPrice = minPrice
+			case "max-price":
+				req.MaxPrice = maxPrice
+			case "min-pages":
+				req.MinPages = minPages
+			case "max-pages":
+				req.MaxPages = maxPages
+			case "ebook":
+				req.Ebook = ebook
+			case "decade":
+				req.Decade = decade
+			}
+		})
+		return req
+	}
+}
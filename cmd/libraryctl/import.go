@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/formats"
+	"pkg/service/pkg/models/request"
+	"strings"
+)
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("file", "", "path of the CSV or NDJSON file to import")
+	format := flags.String("format", "", "csv or ndjson, detected from the file extension when omitted")
+	importId := flags.String("import-id", "", "id used to checkpoint the import, derived from the file contents when omitted")
+	allowDuplicate := flags.Bool("allow-duplicate", false, "create books that only look like existing ones")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+	if *format == "" {
+		*format = detectFormat(*file)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Rerunning the same file after a crash picks up its checkpoint
+	if *importId == "" {
+		if *importId, err = formats.ImportId(f); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "import id: %s\n", *importId)
+
+	reader, err := formats.NewBookReader(*format, f)
+	if err != nil {
+		return err
+	}
+
+	req := request.ImportBooks{Format: *format, ImportId: *importId, AllowDuplicate: *allowDuplicate}
+	res, err := newBooksHandler().ImportBooks(reader, req, requestInfo())
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(res)
+}
+
+func detectFormat(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv":
+		return consts.FormatCsv
+	default:
+		return consts.FormatNdjson
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models/request"
+	"strconv"
+)
+
+func runInventory(args []string) error {
+	flags := flag.NewFlagSet("inventory", flag.ContinueOnError)
+	filters := addBookFilterFlags(flags)
+	topAuthors := flags.Int("top-authors", consts.InventoryTopAuthors, "number of authors with the most books to list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := newBooksHandler().GetStoreInventory(request.GetStoreInventory{GetBooks: filters(), TopAuthors: *topAuthors})
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		{"books", strconv.Itoa(res.Books)},
+		{"authors", strconv.Itoa(res.Authors)},
+		{"ebooks", strconv.Itoa(res.Ebooks)},
+		{"print only", strconv.Itoa(res.PrintOnly)},
+		{"total value", fmt.Sprintf("%.2f", res.TotalValue)},
+		{"price min/avg/max", fmt.Sprintf("%.2f / %.2f / %.2f", res.Price.Min, res.Price.Avg, res.Price.Max)},
+	}
+	for _, author := range res.TopAuthors {
+		rows = append(rows, []string{"author " + author.AuthorName, strconv.Itoa(author.Books)})
+	}
+	for _, year := range res.PublicationYears {
+		rows = append(rows, []string{"published " + strconv.Itoa(year.Year), strconv.Itoa(year.Books)})
+	}
+	return printResult(res, []string{"STAT", "VALUE"}, rows)
+}
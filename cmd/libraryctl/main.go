@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"pkg/service/pkg/config"
+	"pkg/service/pkg/consts"
+	books_handler "pkg/service/pkg/handler/books"
+	users_handler "pkg/service/pkg/handler/users"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/notification"
+	imports_repository "pkg/service/pkg/repository/imports/redis"
+	"sort"
+	"time"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"import":    {usage: "import books from a CSV or NDJSON file", run: runImport},
+	"export":    {usage: "export books as NDJSON, CSV or MARC-XML", run: runExport},
+	"books":     {usage: "create, get, update, delete and search books", run: runBooks},
+	"inventory": {usage: "print the store inventory", run: runInventory},
+	"activity":  {usage: "dump or clear the activity of a user", run: runActivity},
+	"migrate":   {usage: "create or update the index mappings and backfill legacy data", run: runMigrate},
+}
+
+var (
+	cfg          config.Config
+	outputFormat string
+	username     string
+	requestId    = fmt.Sprintf("libraryctl-%d-%d", time.Now().Unix(), os.Getpid())
+)
+
+func main() {
+	configPath := flag.String("config", "", "JSON config file, $"+consts.ConfigPathEnv+" when omitted")
+	flag.StringVar(&outputFormat, "output", outputTable, "table or json")
+	flag.StringVar(&username, "username", os.Getenv("USER"), "recorded as the user making changes")
+	flag.Usage = func() { printUsage("libraryctl [-config file] [-output table|json] [-username name]", commands) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if outputFormat != outputTable && outputFormat != outputJson {
+		fmt.Fprintf(os.Stderr, "unknown output %q\n", outputFormat)
+		os.Exit(2)
+	}
+
+	var err error
+	if cfg, err = config.Load(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "config: %s\n", err.Error())
+		os.Exit(2)
+	}
+
+	if err = runCommand(flag.Arg(0), commands, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", flag.Arg(0), err.Error())
+		os.Exit(1)
+	}
+}
+
+func runCommand(name string, commands map[string]command, args []string) error {
+	cmd, found := commands[name]
+	if !found {
+		return fmt.Errorf("unknown command %q, expected one of %v", name, commandNames(commands))
+	}
+	return cmd.run(args)
+}
+
+// subcommands returns a run function dispatching on its first argument.
+func subcommands(parent string, commands map[string]command) func(args []string) error {
+	return func(args []string) error {
+		if len(args) < 1 {
+			printUsage("libraryctl "+parent, commands)
+			return fmt.Errorf("missing subcommand")
+		}
+		return runCommand(args[0], commands, args[1:])
+	}
+}
+
+func printUsage(prefix string, commands map[string]command) {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n", prefix)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range commandNames(commands) {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
+
+func commandNames(commands map[string]command) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newBooksHandler() interfaces.BooksHandler {
+	booksRepository := config.NewBooksRepository(cfg)
+	copiesRepository := config.NewCopiesRepository(cfg)
+	authorsRepository := config.NewAuthorsRepository(cfg)
+	importsRepository := imports_repository.NewImportsRepositoryRedis()
+	auditRepository := config.NewAuditRepository(cfg)
+	notificationSink := config.NewNotificationSink(cfg, config.NewNotificationsRepository(cfg))
+	arrivalsNotifier := notification.NewSavedSearchNotifier(config.NewSavedSearchesRepository(cfg), notificationSink)
+
+	// Events wait in the outbox for the service to relay them to the stream
+	eventPublisher := config.NewEventPublisher(cfg, config.NewEventStream(cfg))
+
+	return books_handler.NewBooksHandler(booksRepository, copiesRepository, authorsRepository, importsRepository, auditRepository, arrivalsNotifier, eventPublisher)
+}
+
+// requestInfo attributes the changes of one libraryctl run in the audit trail.
+func requestInfo() models.RequestInfo {
+	return models.RequestInfo{Username: username, RequestId: requestId}
+}
+
+func newUsersHandler() interfaces.UsersHandler {
+	return users_handler.NewUsersHandler(config.NewUsersRepository(cfg))
+}
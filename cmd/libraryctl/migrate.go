@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"pkg/service/pkg/config"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	audit_repository "pkg/service/pkg/repository/audit/elastic"
+	authors_repository "pkg/service/pkg/repository/authors/elastic"
+	books_repository "pkg/service/pkg/repository/books/elastic"
+	saved_searches_repository "pkg/service/pkg/repository/saved_searches/elastic"
+	users_repository "pkg/service/pkg/repository/users/redis"
+	"strconv"
+	"strings"
+)
+
+type migrationResult struct {
+	Index  string `json:"index"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	rebuild := flags.Bool("rebuild", false, "recreate the books index when a field has the wrong type, copying its documents")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	results := make([]migrationResult, 0)
+	if cfg.BooksBackend == consts.BackendElastic {
+		result, err := migrateBooks(*rebuild)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	if cfg.AuthorsBackend == consts.BackendElastic {
+		if err := authors_repository.EnsureIndex(cfg.AuthorsIndex); err != nil {
+			return err
+		}
+		results = append(results, migrationResult{Index: cfg.AuthorsIndex, Status: "up to date"})
+	}
+
+	if cfg.BooksBackend == consts.BackendElastic && cfg.AuthorsBackend == consts.BackendElastic {
+		result, err := backfillAuthors()
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	if cfg.AuditBackend == consts.BackendElastic {
+		if err := audit_repository.EnsureIndex(cfg.AuditIndex); err != nil {
+			return err
+		}
+		results = append(results, migrationResult{Index: cfg.AuditIndex, Status: "up to date"})
+	}
+
+	if cfg.SavedSearchesBackend == consts.BackendElastic {
+		if err := saved_searches_repository.EnsureIndex(cfg.SavedSearchesIndex); err != nil {
+			return err
+		}
+		results = append(results, migrationResult{Index: cfg.SavedSearchesIndex, Status: "up to date"})
+	}
+
+	if cfg.UsersBackend == consts.BackendRedis {
+		deleted, err := users_repository.DeleteLegacyActivity()
+		if err != nil {
+			return err
+		}
+		result := migrationResult{Index: "user activity", Status: "up to date"}
+		if deleted > 0 {
+			result.Status = "migrated"
+			result.Detail = "deleted " + strconv.Itoa(deleted) + " legacy activity lists"
+		}
+		results = append(results, result)
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, []string{result.Index, result.Status, result.Detail})
+	}
+	return printResult(results, []string{"INDEX", "STATUS", "DETAIL"}, rows)
+}
+
+func migrateBooks(rebuild bool) (migrationResult, error) {
+	result := migrationResult{Index: cfg.BooksIndex, Status: "up to date"}
+	if err := books_repository.EnsureIndex(cfg.BooksIndex); err != nil {
+		return result, err
+	}
+
+	// Old publish dates are normalized first so the rebuild can copy them
+	normalized, cleared, err := books_repository.NormalizePublishDates(cfg.BooksIndex)
+	if err != nil {
+		return result, err
+	}
+	details := make([]string, 0)
+	if normalized > 0 || cleared > 0 {
+		result.Status = "migrated"
+		details = append(details, fmt.Sprintf("normalized %d publish dates, cleared %d unreadable ones", normalized, cleared))
+	}
+
+	mismatched, err := books_repository.MismatchedFields(cfg.BooksIndex)
+	if err != nil || len(mismatched) == 0 {
+		result.Detail = strings.Join(details, ", ")
+		return result, err
+	}
+
+	details = append(details, "wrong type: "+strings.Join(mismatched, ", "))
+	result.Detail = strings.Join(details, ", ")
+	if !rebuild {
+		result.Status = "needs rebuild"
+		fmt.Fprintln(os.Stderr, "rerun with -rebuild to recreate the books index with the current mapping")
+		return result, nil
+	}
+
+	copied, err := books_repository.RebuildIndex(cfg.BooksIndex)
+	if err != nil {
+		return result, err
+	}
+	result.Status = "rebuilt"
+	result.Detail += ", copied " + strconv.Itoa(copied) + " books"
+	return result, nil
+}
+
+// backfillAuthors links the books that still only have the legacy author_name
+// to author entities, creating the authors that do not exist yet.
+func backfillAuthors() (migrationResult, error) {
+	result := migrationResult{Index: cfg.BooksIndex + " authors", Status: "up to date"}
+	authorsRepository := config.NewAuthorsRepository(cfg)
+	authorIds := make(map[string]string)
+	resolve := func(name string) (string, error) {
+		if authorId, found := authorIds[name]; found {
+			return authorId, nil
+		}
+		author, err := authorsRepository.FindByName(name)
+		if err != nil {
+			return "", err
+		}
+		authorId := ""
+		if author != nil {
+			authorId = author.Id
+		} else if authorId, err = authorsRepository.Create(models.AuthorSource{Name: name, Aliases: []string{}}); err != nil {
+			return "", err
+		}
+		authorIds[name] = authorId
+		return authorId, nil
+	}
+
+	updated, err := books_repository.BackfillAuthors(cfg.BooksIndex, resolve)
+	if err != nil {
+		return result, err
+	}
+	if updated > 0 {
+		result.Status = "backfilled"
+		result.Detail = "linked " + strconv.Itoa(updated) + " books to " + strconv.Itoa(len(authorIds)) + " authors"
+	}
+	return result, nil
+}
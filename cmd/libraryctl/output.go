@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"pkg/service/pkg/models"
+	"strings"
+	"text/tabwriter"
+)
+
+const outputTable = "table"
+const outputJson = "json"
+
+// printResult writes v as indented JSON, or as a table of the given rows
+// when the table output is selected.
+func printResult(v interface{}, headers []string, rows [][]string) error {
+	if outputFormat == outputJson {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+var bookHeaders = []string{"ID", "TITLE", "AUTHORS", "PRICE", "EBOOK", "PUBLISHED", "ISBN-13", "VERSION"}
+
+func bookRows(books []models.Book) [][]string {
+	rows := make([][]string, 0, len(books))
+	for _, book := range books {
+		rows = append(rows, []string{
+			book.Id,
+			book.Title,
+			strings.Join(book.AuthorNames, ", "),
+			fmt.Sprintf("%.2f", book.Price),
+			fmt.Sprintf("%t", book.EbookAvailable),
+			book.PublishDate.String(),
+			book.Isbn13,
+			bookVersion(book),
+		})
+	}
+	return rows
+}
+
+func bookVersion(book models.Book) string {
+	if book.Version == nil {
+		return ""
+	}
+	return book.Version.String()
+}
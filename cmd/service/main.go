@@ -1,26 +1,133 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"pkg/service/pkg/config"
 	"pkg/service/pkg/consts"
 	"pkg/service/pkg/controller"
+	"pkg/service/pkg/events"
+	analytics_handler "pkg/service/pkg/handler/analytics"
+	audit_handler "pkg/service/pkg/handler/audit"
+	authors_handler "pkg/service/pkg/handler/authors"
 	books_handler "pkg/service/pkg/handler/books"
+	branches_handler "pkg/service/pkg/handler/branches"
+	events_handler "pkg/service/pkg/handler/events"
+	recommendations_handler "pkg/service/pkg/handler/recommendations"
+	saved_searches_handler "pkg/service/pkg/handler/saved_searches"
 	users_handler "pkg/service/pkg/handler/users"
+	webhooks_handler "pkg/service/pkg/handler/webhooks"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/notification"
+	"pkg/service/pkg/recommender"
+	audit_repository "pkg/service/pkg/repository/audit/elastic"
+	authors_repository "pkg/service/pkg/repository/authors/elastic"
 	books_repository "pkg/service/pkg/repository/books/elastic"
-	users_repository "pkg/service/pkg/repository/users/redis"
+	imports_repository "pkg/service/pkg/repository/imports/redis"
+	saved_searches_repository "pkg/service/pkg/repository/saved_searches/elastic"
+	users_analytics "pkg/service/pkg/repository/users/analytics"
+	users_repository "pkg/service/pkg/repository/users/async"
+	users_live "pkg/service/pkg/repository/users/live"
 	"pkg/service/pkg/router"
+	"pkg/service/pkg/webhook"
+	"syscall"
+	"time"
 )
 
 func main() {
-	booksRepository := books_repository.NewBooksRepositoryElastic(consts.BooksIndexName)
-	usersRepository := users_repository.NewUsersRepositoryRedis(consts.UserActivityActions)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	booksHandler := books_handler.NewBooksHandler(booksRepository)
+	cfg, err := config.Load("")
+	if err != nil {
+		panic(err)
+	}
+
+	if cfg.BooksBackend == consts.BackendElastic {
+		if err = books_repository.EnsureIndex(cfg.BooksIndex); err != nil {
+			log.Printf("failed to ensure books index: %s", err.Error())
+		}
+		if mismatched, err := books_repository.MismatchedFields(cfg.BooksIndex); err != nil {
+			log.Printf("failed to check books index mapping: %s", err.Error())
+		} else if containsField(mismatched, "publish_date") {
+			log.Printf("warning: publish_date is not mapped as a date in %s, so date filters and sorting are unreliable; run libraryctl migrate -rebuild", cfg.BooksIndex)
+		}
+	}
+	if cfg.AuthorsBackend == consts.BackendElastic {
+		if err = authors_repository.EnsureIndex(cfg.AuthorsIndex); err != nil {
+			log.Printf("failed to ensure authors index: %s", err.Error())
+		}
+	}
+	if cfg.AuditBackend == consts.BackendElastic {
+		if err = audit_repository.EnsureIndex(cfg.AuditIndex); err != nil {
+			log.Printf("failed to ensure audit index: %s", err.Error())
+		}
+	}
+
+	if cfg.SavedSearchesBackend == consts.BackendElastic {
+		if err = saved_searches_repository.EnsureIndex(cfg.SavedSearchesIndex); err != nil {
+			log.Printf("failed to ensure saved searches index: %s", err.Error())
+		}
+	}
+
+	liveFeed := events.NewHub(consts.LiveHistorySize, consts.LiveSubscriberBuffer)
+	booksRepository := config.NewBooksRepository(cfg)
+	analyticsRepository := config.NewAnalyticsRepository(cfg)
+	usersRepository := users_analytics.NewUsersRepositoryAnalytics(config.NewUsersRepository(cfg), analyticsRepository)
+	var activityWriter *users_repository.UsersRepositoryAsync
+	if cfg.UserActivityQueueSize > 0 {
+		activityWriter = config.NewAsyncUsersRepository(cfg, usersRepository)
+		usersRepository = activityWriter
+	}
+	// Published as the action is queued so subscribers see it right away
+	usersRepository = users_live.NewUsersRepositoryLive(usersRepository, liveFeed)
+	branchesRepository := config.NewBranchesRepository(cfg)
+	copiesRepository := config.NewCopiesRepository(cfg)
+	authorsRepository := config.NewAuthorsRepository(cfg)
+	importsRepository := imports_repository.NewImportsRepositoryRedis()
+	auditRepository := config.NewAuditRepository(cfg)
+	savedSearchesRepository := config.NewSavedSearchesRepository(cfg)
+	notificationsRepository := config.NewNotificationsRepository(cfg)
+	arrivalsNotifier := notification.NewSavedSearchNotifier(savedSearchesRepository, config.NewNotificationSink(cfg, notificationsRepository))
+	webhooksRepository := config.NewWebhooksRepository(cfg)
+	webhookDispatcher := webhook.NewDispatcher(webhooksRepository, webhook.DefaultOptions())
+	eventStream := config.NewEventStream(cfg)
+	eventPublisher := config.NewEventPublisher(cfg, eventStream)
+
+	booksHandler := books_handler.NewBooksHandler(booksRepository, copiesRepository, authorsRepository, importsRepository, auditRepository, arrivalsNotifier, eventPublisher)
 	usersHandler := users_handler.NewUsersHandler(usersRepository)
+	branchesHandler := branches_handler.NewBranchesHandler(branchesRepository, copiesRepository, booksRepository)
+	authorsHandler := authors_handler.NewAuthorsHandler(authorsRepository, booksRepository, booksHandler)
+	auditHandler := audit_handler.NewAuditHandler(auditRepository)
+	analyticsHandler := analytics_handler.NewAnalyticsHandler(analyticsRepository)
+	savedSearchesHandler := saved_searches_handler.NewSavedSearchesHandler(savedSearchesRepository, notificationsRepository)
+	webhooksHandler := webhooks_handler.NewWebhooksHandler(webhooksRepository, webhookDispatcher)
+	eventsHandler := events_handler.NewEventsHandler(eventStream, liveFeed)
+	recommendationsHandler := recommendations_handler.NewRecommendationsHandler(
+		booksRepository,
+		recommender.NewSimilarRecommender(booksRepository),
+		recommender.NewCoActivityRecommender(analyticsRepository, booksRepository),
+	)
 
-	libraryController := controller.NewLibraryController(booksHandler, usersHandler)
+	if cfg.TrashRetentionHours > 0 {
+		retention := time.Duration(cfg.TrashRetentionHours) * time.Hour
+		go books_handler.RunTrashRetention(ctx, booksHandler, retention, consts.TrashRetentionIntervalMinutes*time.Minute)
+	}
+
+	go eventPublisher.Run(ctx)
+	go webhookDispatcher.Run(ctx)
+	go events.TailStream(ctx, eventStream, liveFeed, consts.LiveTailPollMs*time.Millisecond)
+	webhooksConsumer := events.NewConsumer(consts.WebhooksConsumer, eventStream)
+	go webhooksConsumer.Run(ctx, consts.EventConsumerBatchSize, consts.EventConsumerPollSeconds*time.Second, func(batch []models.StreamEvent) error {
+		return webhookDispatcher.Dispatch(events.BookEvents(batch))
+	})
+
+	libraryController := controller.NewLibraryController(booksHandler, usersHandler, branchesHandler, authorsHandler, auditHandler, analyticsHandler, recommendationsHandler, savedSearchesHandler, webhooksHandler, eventsHandler, cfg.ImportMaxBytes)
 
 	libraryRouter := router.NewRouter(libraryController, &usersHandler)
 
@@ -29,7 +136,35 @@ func main() {
 		Handler: libraryRouter,
 	}
 
-	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		panic(err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), consts.ShutdownTimeoutSeconds*time.Second)
+	defer cancel()
+
+	// Event streams never finish on their own, so they are ended first
+	liveFeed.Close()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down server: %s", err.Error())
+	}
+	if activityWriter != nil {
+		if err = activityWriter.Close(shutdownCtx); err != nil {
+			log.Printf("failed to flush user activity: %s", err.Error())
+		}
+	}
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
 	}
+	return false
 }
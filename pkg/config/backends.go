@@ -0,0 +1,184 @@
+package config
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/events"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/notification"
+	analytics_memory "pkg/service/pkg/repository/analytics/memory"
+	analytics_redis "pkg/service/pkg/repository/analytics/redis"
+	audit_elastic "pkg/service/pkg/repository/audit/elastic"
+	audit_memory "pkg/service/pkg/repository/audit/memory"
+	authors_elastic "pkg/service/pkg/repository/authors/elastic"
+	authors_memory "pkg/service/pkg/repository/authors/memory"
+	books_cached "pkg/service/pkg/repository/books/cached"
+	books_elastic "pkg/service/pkg/repository/books/elastic"
+	books_memory "pkg/service/pkg/repository/books/memory"
+	branches_elastic "pkg/service/pkg/repository/branches/elastic"
+	branches_memory "pkg/service/pkg/repository/branches/memory"
+	cache_memory "pkg/service/pkg/repository/cache/memory"
+	cache_redis "pkg/service/pkg/repository/cache/redis"
+	copies_elastic "pkg/service/pkg/repository/copies/elastic"
+	copies_memory "pkg/service/pkg/repository/copies/memory"
+	event_outbox_memory "pkg/service/pkg/repository/event_outbox/memory"
+	event_outbox_redis "pkg/service/pkg/repository/event_outbox/redis"
+	event_stream_file "pkg/service/pkg/repository/event_stream/file"
+	event_stream_memory "pkg/service/pkg/repository/event_stream/memory"
+	event_stream_redis "pkg/service/pkg/repository/event_stream/redis"
+	notifications_memory "pkg/service/pkg/repository/notifications/memory"
+	notifications_redis "pkg/service/pkg/repository/notifications/redis"
+	saved_searches_elastic "pkg/service/pkg/repository/saved_searches/elastic"
+	saved_searches_memory "pkg/service/pkg/repository/saved_searches/memory"
+	users_async "pkg/service/pkg/repository/users/async"
+	users_memory "pkg/service/pkg/repository/users/memory"
+	users_redis "pkg/service/pkg/repository/users/redis"
+	webhooks_memory "pkg/service/pkg/repository/webhooks/memory"
+	webhooks_redis "pkg/service/pkg/repository/webhooks/redis"
+	"time"
+)
+
+// NewBooksRepository puts the configured cache tiers in front of the books
+// backend.
+func NewBooksRepository(cfg Config) interfaces.BooksRepository {
+	var booksRepository interfaces.BooksRepository
+	if cfg.BooksBackend == consts.BackendMemory {
+		booksRepository = books_memory.NewBooksRepositoryMemory()
+	} else {
+		booksRepository = books_elastic.NewBooksRepositoryElastic(cfg.BooksIndex)
+	}
+
+	missTtl := time.Duration(cfg.BooksCacheMissTtlSeconds) * time.Second
+	tiers := make([]books_cached.Tier, 0, 2)
+	if cfg.BooksCacheSize > 0 {
+		tiers = append(tiers, books_cached.Tier{
+			Name:    consts.BackendMemory,
+			Store:   cache_memory.NewCacheMemory(cfg.BooksCacheSize),
+			Ttl:     time.Duration(cfg.BooksCacheTtlSeconds) * time.Second,
+			MissTtl: missTtl,
+		})
+	}
+	if cfg.BooksCacheRedis {
+		tiers = append(tiers, books_cached.Tier{
+			Name:    consts.BackendRedis,
+			Store:   cache_redis.NewCacheRedis(consts.BooksCacheRedisKeyPrefix),
+			Ttl:     time.Duration(cfg.BooksCacheRedisTtlSeconds) * time.Second,
+			MissTtl: missTtl,
+		})
+	}
+	inventoryTtl := time.Duration(cfg.BooksInventoryCacheTtlSeconds) * time.Second
+	return books_cached.NewBooksRepositoryCached(booksRepository, inventoryTtl, tiers...)
+}
+
+func NewAuthorsRepository(cfg Config) interfaces.AuthorsRepository {
+	if cfg.AuthorsBackend == consts.BackendMemory {
+		return authors_memory.NewAuthorsRepositoryMemory()
+	}
+	return authors_elastic.NewAuthorsRepositoryElastic(cfg.AuthorsIndex)
+}
+
+func NewBranchesRepository(cfg Config) interfaces.BranchesRepository {
+	if cfg.BranchesBackend == consts.BackendMemory {
+		return branches_memory.NewBranchesRepositoryMemory()
+	}
+	return branches_elastic.NewBranchesRepositoryElastic(cfg.BranchesIndex)
+}
+
+// NewCopiesRepository keeps the copies next to the branches they are in.
+func NewCopiesRepository(cfg Config) interfaces.CopiesRepository {
+	if cfg.BranchesBackend == consts.BackendMemory {
+		return copies_memory.NewCopiesRepositoryMemory()
+	}
+	return copies_elastic.NewCopiesRepositoryElastic(cfg.BookCopiesIndex)
+}
+
+func NewUsersRepository(cfg Config) interfaces.UsersRepository {
+	retention := time.Duration(cfg.UserActivityRetentionHours) * time.Hour
+	if cfg.UsersBackend == consts.BackendMemory {
+		return users_memory.NewUsersRepositoryMemory(cfg.UserActivityActions, retention)
+	}
+	return users_redis.NewUsersRepositoryRedis(cfg.UserActivityActions, retention)
+}
+
+// NewAsyncUsersRepository writes activity to usersRepository in the
+// background, as configured by the user activity queue settings.
+func NewAsyncUsersRepository(cfg Config, usersRepository interfaces.UsersRepository) *users_async.UsersRepositoryAsync {
+	return users_async.NewUsersRepositoryAsync(usersRepository, users_async.Options{
+		QueueSize:      cfg.UserActivityQueueSize,
+		Workers:        cfg.UserActivityWorkers,
+		BatchSize:      cfg.UserActivityBatchSize,
+		FlushInterval:  time.Duration(cfg.UserActivityFlushIntervalMs) * time.Millisecond,
+		OverflowPolicy: cfg.UserActivityOverflowPolicy,
+		SpillPath:      cfg.UserActivitySpillPath,
+	})
+}
+
+func NewAuditRepository(cfg Config) interfaces.AuditRepository {
+	if cfg.AuditBackend == consts.BackendMemory {
+		return audit_memory.NewAuditRepositoryMemory()
+	}
+	return audit_elastic.NewAuditRepositoryElastic(cfg.AuditIndex)
+}
+
+func NewAnalyticsRepository(cfg Config) interfaces.AnalyticsRepository {
+	retention := consts.AnalyticsRetentionHours * time.Hour
+	if cfg.AnalyticsBackend == consts.BackendMemory {
+		return analytics_memory.NewAnalyticsRepositoryMemory(retention)
+	}
+	return analytics_redis.NewAnalyticsRepositoryRedis(retention)
+}
+
+func NewSavedSearchesRepository(cfg Config) interfaces.SavedSearchesRepository {
+	if cfg.SavedSearchesBackend == consts.BackendMemory {
+		return saved_searches_memory.NewSavedSearchesRepositoryMemory()
+	}
+	return saved_searches_elastic.NewSavedSearchesRepositoryElastic(cfg.SavedSearchesIndex)
+}
+
+func NewNotificationsRepository(cfg Config) interfaces.NotificationsRepository {
+	if cfg.NotificationsBackend == consts.BackendMemory {
+		return notifications_memory.NewNotificationsRepositoryMemory()
+	}
+	return notifications_redis.NewNotificationsRepositoryRedis()
+}
+
+// NewNotificationSink returns the configured sink. The outbox stores the
+// notifications in notificationsRepository.
+func NewNotificationSink(cfg Config, notificationsRepository interfaces.NotificationsRepository) interfaces.NotificationSink {
+	switch cfg.NotificationSink {
+	case consts.NotificationSinkLog:
+		return notification.NewLogSink()
+	case consts.NotificationSinkWebhook:
+		return notification.NewWebhookSink(cfg.NotificationWebhookUrl)
+	}
+	return notification.NewOutboxSink(notificationsRepository)
+}
+
+func NewWebhooksRepository(cfg Config) interfaces.WebhooksRepository {
+	if cfg.WebhooksBackend == consts.BackendMemory {
+		return webhooks_memory.NewWebhooksRepositoryMemory()
+	}
+	return webhooks_redis.NewWebhooksRepositoryRedis()
+}
+
+func NewEventOutboxRepository(cfg Config) interfaces.EventOutboxRepository {
+	if cfg.EventOutboxBackend == consts.BackendMemory {
+		return event_outbox_memory.NewEventOutboxRepositoryMemory()
+	}
+	return event_outbox_redis.NewEventOutboxRepositoryRedis()
+}
+
+func NewEventStream(cfg Config) interfaces.EventStream {
+	switch cfg.EventStreamBackend {
+	case consts.BackendMemory:
+		return event_stream_memory.NewEventStreamMemory()
+	case consts.BackendFile:
+		return event_stream_file.NewEventStreamFile(cfg.EventStreamPath)
+	}
+	return event_stream_redis.NewEventStreamRedis()
+}
+
+// NewEventPublisher publishes book events through the configured outbox to
+// the configured event stream.
+func NewEventPublisher(cfg Config, stream interfaces.EventStream) *events.Publisher {
+	return events.NewPublisher(NewEventOutboxRepository(cfg), stream, consts.EventRelayIntervalSeconds*time.Second)
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"pkg/service/pkg/consts"
+)
+
+// Config selects the storage backends and index names. Connection addresses
+// still come from ELASTICSEARCH_URL and REDIS_ADDR.
+type Config struct {
+	BooksBackend   string `json:"books_backend"`
+	AuthorsBackend string `json:"authors_backend"`
+	// BranchesBackend holds the branches and the book copies in them
+	BranchesBackend      string `json:"branches_backend"`
+	UsersBackend         string `json:"users_backend"`
+	AuditBackend         string `json:"audit_backend"`
+	AnalyticsBackend     string `json:"analytics_backend"`
+	SavedSearchesBackend string `json:"saved_searches_backend"`
+	NotificationsBackend string `json:"notifications_backend"`
+	WebhooksBackend      string `json:"webhooks_backend"`
+	EventOutboxBackend   string `json:"event_outbox_backend"`
+	// EventStreamBackend is redis for a Redis stream, memory, or file for a
+	// JSON lines file at EventStreamPath
+	EventStreamBackend  string `json:"event_stream_backend"`
+	EventStreamPath     string `json:"event_stream_path"`
+	BooksIndex          string `json:"books_index"`
+	AuthorsIndex        string `json:"authors_index"`
+	AuditIndex          string `json:"audit_index"`
+	BranchesIndex       string `json:"branches_index"`
+	BookCopiesIndex     string `json:"book_copies_index"`
+	SavedSearchesIndex  string `json:"saved_searches_index"`
+	UserActivityActions int    `json:"user_activity_actions"`
+	// UserActivityRetentionHours is how long activity events are kept, zero
+	// keeps them until they are pushed out by newer ones
+	UserActivityRetentionHours int `json:"user_activity_retention_hours"`
+	// TrashRetentionHours is how long deleted books stay restorable, zero
+	// keeps them until they are purged by hand
+	TrashRetentionHours int `json:"trash_retention_hours"`
+	// UserActivityQueueSize is how many activity events can wait to be
+	// written in the background, zero writes them during the request
+	UserActivityQueueSize       int `json:"user_activity_queue_size"`
+	UserActivityWorkers         int `json:"user_activity_workers"`
+	UserActivityBatchSize       int `json:"user_activity_batch_size"`
+	UserActivityFlushIntervalMs int `json:"user_activity_flush_interval_ms"`
+	// UserActivityOverflowPolicy is what happens to events when the queue is
+	// full: drop them, block the request, or spill them to a file
+	UserActivityOverflowPolicy string `json:"user_activity_overflow_policy"`
+	UserActivitySpillPath      string `json:"user_activity_spill_path"`
+	// NotificationSink is where saved search notifications go: the outbox
+	// users read them from, the log, or a webhook at NotificationWebhookUrl
+	NotificationSink       string `json:"notification_sink"`
+	NotificationWebhookUrl string `json:"notification_webhook_url"`
+	// BooksCacheSize is how many books are cached in process, zero turns the
+	// in-process cache off. BooksCacheRedis also caches books in Redis,
+	// shared by every instance. Missing books are cached in both for
+	// BooksCacheMissTtlSeconds. The store inventory of every filter set is
+	// cached in process for BooksInventoryCacheTtlSeconds, zero turns it off.
+	BooksCacheSize                int  `json:"books_cache_size"`
+	BooksCacheTtlSeconds          int  `json:"books_cache_ttl_seconds"`
+	BooksCacheRedis               bool `json:"books_cache_redis"`
+	BooksCacheRedisTtlSeconds     int  `json:"books_cache_redis_ttl_seconds"`
+	BooksCacheMissTtlSeconds      int  `json:"books_cache_miss_ttl_seconds"`
+	BooksInventoryCacheTtlSeconds int  `json:"books_inventory_cache_ttl_seconds"`
+	// ImportMaxBytes is the largest file a book import accepts
+	ImportMaxBytes int64 `json:"import_max_bytes"`
+}
+
+func Default() Config {
+	return Config{
+		BooksBackend:                  consts.BackendElastic,
+		AuthorsBackend:                consts.BackendElastic,
+		BranchesBackend:               consts.BackendElastic,
+		UsersBackend:                  consts.BackendRedis,
+		AuditBackend:                  consts.BackendElastic,
+		AnalyticsBackend:              consts.BackendRedis,
+		SavedSearchesBackend:          consts.BackendElastic,
+		NotificationsBackend:          consts.BackendRedis,
+		WebhooksBackend:               consts.BackendRedis,
+		EventOutboxBackend:            consts.BackendRedis,
+		EventStreamBackend:            consts.BackendRedis,
+		EventStreamPath:               consts.EventStreamPath,
+		BooksIndex:                    consts.BooksIndexName,
+		AuthorsIndex:                  consts.AuthorsIndexName,
+		AuditIndex:                    consts.AuditIndexName,
+		BranchesIndex:                 consts.BranchesIndexName,
+		BookCopiesIndex:               consts.BookCopiesIndexName,
+		SavedSearchesIndex:            consts.SavedSearchesIndexName,
+		UserActivityActions:           consts.UserActivityActions,
+		UserActivityRetentionHours:    consts.UserActivityRetentionHours,
+		TrashRetentionHours:           consts.TrashRetentionHours,
+		UserActivityQueueSize:         consts.UserActivityQueueSize,
+		UserActivityWorkers:           consts.UserActivityWorkers,
+		UserActivityBatchSize:         consts.UserActivityBatchSize,
+		UserActivityFlushIntervalMs:   consts.UserActivityFlushIntervalMs,
+		UserActivityOverflowPolicy:    consts.OverflowPolicyDrop,
+		UserActivitySpillPath:         consts.UserActivitySpillPath,
+		NotificationSink:              consts.NotificationSinkOutbox,
+		BooksCacheTtlSeconds:          consts.BooksCacheTtlSeconds,
+		BooksCacheRedisTtlSeconds:     consts.BooksCacheRedisTtlSeconds,
+		BooksCacheMissTtlSeconds:      consts.BooksCacheMissTtlSeconds,
+		BooksInventoryCacheTtlSeconds: consts.BooksInventoryCacheTtlSeconds,
+		ImportMaxBytes:                consts.ImportMaxBytes,
+	}
+}
+
+// Load reads the JSON file at path over the defaults, falling back to the
+// LIBRARY_CONFIG environment variable when path is empty. The backend
+// environment variables override the file.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		path = os.Getenv(consts.ConfigPathEnv)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		if err = json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	if backend := os.Getenv(consts.BooksBackendEnv); backend != "" {
+		cfg.BooksBackend = backend
+	}
+	if backend := os.Getenv(consts.AuthorsBackendEnv); backend != "" {
+		cfg.AuthorsBackend = backend
+	}
+	if backend := os.Getenv(consts.BranchesBackendEnv); backend != "" {
+		cfg.BranchesBackend = backend
+	}
+	if backend := os.Getenv(consts.UsersBackendEnv); backend != "" {
+		cfg.UsersBackend = backend
+	}
+	if backend := os.Getenv(consts.AuditBackendEnv); backend != "" {
+		cfg.AuditBackend = backend
+	}
+
+	if backend := os.Getenv(consts.AnalyticsBackendEnv); backend != "" {
+		cfg.AnalyticsBackend = backend
+	}
+	if backend := os.Getenv(consts.SavedSearchesBackendEnv); backend != "" {
+		cfg.SavedSearchesBackend = backend
+	}
+	if backend := os.Getenv(consts.NotificationsBackendEnv); backend != "" {
+		cfg.NotificationsBackend = backend
+	}
+	if backend := os.Getenv(consts.WebhooksBackendEnv); backend != "" {
+		cfg.WebhooksBackend = backend
+	}
+	if backend := os.Getenv(consts.EventOutboxBackendEnv); backend != "" {
+		cfg.EventOutboxBackend = backend
+	}
+	if backend := os.Getenv(consts.EventStreamBackendEnv); backend != "" {
+		cfg.EventStreamBackend = backend
+	}
+	if sink := os.Getenv(consts.NotificationSinkEnv); sink != "" {
+		cfg.NotificationSink = sink
+	}
+
+	return cfg, cfg.validate()
+}
+
+func (c Config) validate() error {
+	if c.BooksBackend != consts.BackendElastic && c.BooksBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown books backend %q", c.BooksBackend)
+	}
+	if c.AuthorsBackend != consts.BackendElastic && c.AuthorsBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown authors backend %q", c.AuthorsBackend)
+	}
+	if c.BranchesBackend != consts.BackendElastic && c.BranchesBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown branches backend %q", c.BranchesBackend)
+	}
+	if c.UsersBackend != consts.BackendRedis && c.UsersBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown users backend %q", c.UsersBackend)
+	}
+	if c.AuditBackend != consts.BackendElastic && c.AuditBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown audit backend %q", c.AuditBackend)
+	}
+	if c.AnalyticsBackend != consts.BackendRedis && c.AnalyticsBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown analytics backend %q", c.AnalyticsBackend)
+	}
+	if c.SavedSearchesBackend != consts.BackendElastic && c.SavedSearchesBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown saved searches backend %q", c.SavedSearchesBackend)
+	}
+	if c.NotificationsBackend != consts.BackendRedis && c.NotificationsBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown notifications backend %q", c.NotificationsBackend)
+	}
+	if c.WebhooksBackend != consts.BackendRedis && c.WebhooksBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown webhooks backend %q", c.WebhooksBackend)
+	}
+	if c.EventOutboxBackend != consts.BackendRedis && c.EventOutboxBackend != consts.BackendMemory {
+		return fmt.Errorf("unknown event outbox backend %q", c.EventOutboxBackend)
+	}
+	switch c.EventStreamBackend {
+	case consts.BackendRedis, consts.BackendMemory:
+	case consts.BackendFile:
+		if c.EventStreamPath == "" {
+			return fmt.Errorf("the file event stream needs an event stream path")
+		}
+	default:
+		return fmt.Errorf("unknown event stream backend %q", c.EventStreamBackend)
+	}
+	switch c.NotificationSink {
+	case consts.NotificationSinkOutbox, consts.NotificationSinkLog:
+	case consts.NotificationSinkWebhook:
+		if c.NotificationWebhookUrl == "" {
+			return fmt.Errorf("the webhook notification sink needs a notification webhook url")
+		}
+	default:
+		return fmt.Errorf("unknown notification sink %q", c.NotificationSink)
+	}
+	switch c.UserActivityOverflowPolicy {
+	case consts.OverflowPolicyDrop, consts.OverflowPolicyBlock, consts.OverflowPolicySpill:
+	default:
+		return fmt.Errorf("unknown user activity overflow policy %q", c.UserActivityOverflowPolicy)
+	}
+	if c.UserActivityQueueSize > 0 && (c.UserActivityWorkers <= 0 || c.UserActivityBatchSize <= 0 || c.UserActivityFlushIntervalMs <= 0) {
+		return fmt.Errorf("user activity workers, batch size and flush interval must be positive")
+	}
+	if c.BooksCacheSize > 0 && c.BooksCacheTtlSeconds <= 0 {
+		return fmt.Errorf("the books cache ttl must be positive")
+	}
+	if c.BooksCacheRedis && c.BooksCacheRedisTtlSeconds <= 0 {
+		return fmt.Errorf("the books redis cache ttl must be positive")
+	}
+	if c.BooksCacheMissTtlSeconds < 0 {
+		return fmt.Errorf("the books cache miss ttl cannot be negative")
+	}
+	if c.BooksInventoryCacheTtlSeconds < 0 {
+		return fmt.Errorf("the books inventory cache ttl cannot be negative")
+	}
+	if c.ImportMaxBytes <= 0 {
+		return fmt.Errorf("the import max bytes must be positive")
+	}
+	return nil
+}
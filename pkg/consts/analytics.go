@@ -0,0 +1,15 @@
+package consts
+
+const AnalyticsRedisKey = "books_library_exercise:analytics:%s:%d"
+const AnalyticsRedisUnionKey = "books_library_exercise:analytics:union:%s"
+const AnalyticsRetentionHours = 8 * 24
+const AnalyticsTopSize = 10
+const AnalyticsDimensionBooks = "books"
+const AnalyticsDimensionUsers = "users"
+const AnalyticsDimensionRoutes = "routes"
+const AnalyticsWindowHour = "hour"
+const AnalyticsWindowDay = "day"
+const AnalyticsWindowWeek = "week"
+const AnalyticsBookViewersRedisKey = "books_library_exercise:analytics:viewers:%s"
+const AnalyticsUserBooksRedisKey = "books_library_exercise:analytics:viewed:%s"
+const CoViewHistory = 100
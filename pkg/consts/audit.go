@@ -0,0 +1,11 @@
+package consts
+
+const AuditIndexName = "book_audit"
+const AuditQuerySize = 100
+const AuditMaxQuerySize = 1000
+const AuditActionCreate = "create"
+const AuditActionUpdate = "update"
+const AuditActionDelete = "delete"
+const AuditActionRestore = "restore"
+const AuditActionPurge = "purge"
+const SystemActor = "system"
@@ -0,0 +1,4 @@
+package consts
+
+const AuthorsIndexName = "authors"
+const RelinkAuthorsAttempts = 3
@@ -2,4 +2,34 @@ package consts
 
 const BooksIndexName = "books_shahar_with_synonym"
 const BooksQuerySize = 1000
+const BooksScrollSize = 500
+const BooksScrollKeepAlive = "1m"
 const UniqueAuthorsAggregationName = "unique_authors"
+const PublishDateFormat = "yyyy-MM-dd||yyyy-MM||yyyy"
+const ImportBatchSize = 500
+const FormatCsv = "csv"
+const FormatNdjson = "ndjson"
+const FormatMarcXml = "marcxml"
+const BulkActionCreate = "create"
+const BulkActionUpdate = "update"
+const BulkActionDelete = "delete"
+const TrashRetentionHours = 30 * 24
+const TrashRetentionIntervalMinutes = 60
+const InventoryTopAuthors = 10
+const PriceStatsAggregationName = "price_stats"
+const PricePercentilesAggregationName = "price_percentiles"
+const EbooksAggregationName = "ebooks"
+const TopAuthorsAggregationName = "top_authors"
+const TopAuthorNameAggregationName = "author_name"
+const PublicationYearsAggregationName = "publication_years"
+const FacetAuthors = "authors"
+const FacetPrice = "price"
+const FacetEbook = "ebook"
+const FacetDecade = "decade"
+const FacetSize = 10
+const SuggestFieldTitle = "title"
+const SuggestFieldAuthor = "author"
+const SuggestSize = 10
+const RecommendationsSize = 10
+const SimilarPriceWeight = 1.0
+const SimilarPriceScale = 0.5
@@ -0,0 +1,12 @@
+package consts
+
+const BooksCacheRedisKeyPrefix = "books_library_exercise:books_cache:"
+const BooksCacheTtlSeconds = 30
+const BooksCacheRedisTtlSeconds = 300
+const BooksCacheMissTtlSeconds = 5
+const CacheClearBatchSize = 500
+const CacheVersionTtlSeconds = 60
+const CacheVersionKeyPrefix = "version:"
+const BooksInventoryCacheTtlSeconds = 30
+const BooksInventoryCacheSize = 100
+const InventoryCacheTier = "inventory"
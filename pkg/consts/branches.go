@@ -0,0 +1,12 @@
+package consts
+
+const BranchesIndexName = "branches"
+const BookCopiesIndexName = "book_copies"
+const CopyStatusAvailable = "available"
+const CopyStatusInTransit = "in_transit"
+const AvailableBookIdsAggregationName = "available_book_ids"
+const CopiedBooksAggregationName = "copied_books"
+const BranchCopiesAggregationName = "branch_copies"
+const AvailableCopiesAggregationName = "available_copies"
+const OutgoingCopiesAggregationName = "outgoing_copies"
+const IncomingCopiesAggregationName = "incoming_copies"
@@ -2,12 +2,73 @@ package consts
 
 const ServerPort = 8080
 const DefaultRedisAddress = "localhost:6379"
+const UsernameHeader = "X-Username"
 const BooksRequestTimeout = 10
 const UsersRequestTimeout = 5
+const ShutdownTimeoutSeconds = 10
 const GetBooksUrlPath = "/books"
+const BulkBooksUrlPath = "/books/_bulk"
+const BulkBooksByQueryUrlPath = "/books/_bulk/by_query"
+const ImportBooksUrlPath = "/books/_import"
+const ExportBooksUrlPath = "/books/_export"
 const GetBookUrlPath = "/books/:id"
+const SuggestBooksUrlPath = "/books/suggest"
 const CreateBookUrlPath = "/books"
 const UpdateBookUrlPath = "/books/:id"
+const PatchBookUrlPath = "/books/:id"
 const DeleteBookUrlPath = "/books/:id"
 const GetStoreInventoryUrlPath = "/store"
 const GetUserActivityUrlPath = "/activity/:username"
+const CreateBranchUrlPath = "/branches"
+const GetBranchesUrlPath = "/branches"
+const GetBranchInventoryUrlPath = "/branches/:id/inventory"
+const AddBranchCopiesUrlPath = "/branches/:id/copies"
+const CreateTransferUrlPath = "/transfers"
+const CompleteTransferUrlPath = "/transfers/:id/complete"
+const GetAuthorsUrlPath = "/authors"
+const CreateAuthorUrlPath = "/authors"
+const GetAuthorUrlPath = "/authors/:id"
+const MergeAuthorsUrlPath = "/authors/:id/merge"
+const ConfigPathEnv = "LIBRARY_CONFIG"
+const BooksBackendEnv = "LIBRARY_BOOKS_BACKEND"
+const UsersBackendEnv = "LIBRARY_USERS_BACKEND"
+const AuthorsBackendEnv = "LIBRARY_AUTHORS_BACKEND"
+const BranchesBackendEnv = "LIBRARY_BRANCHES_BACKEND"
+const BackendElastic = "elastic"
+const BackendRedis = "redis"
+const BackendMemory = "memory"
+const UsernameContextKey = "username"
+const GetTrashUrlPath = "/books/_trash"
+const RestoreBookUrlPath = "/books/_trash/:id/restore"
+const PurgeBookUrlPath = "/books/_trash/:id"
+const RequestIdHeader = "X-Request-Id"
+const RequestIdContextKey = "request_id"
+const DebugVarsUrlPath = "/debug/vars"
+const GetBookHistoryUrlPath = "/books/:id/history"
+const GetSimilarBooksUrlPath = "/books/:id/similar"
+const GetAlsoViewedBooksUrlPath = "/books/:id/also-viewed"
+const GetAuditUrlPath = "/audit"
+const AuditBackendEnv = "LIBRARY_AUDIT_BACKEND"
+const AnalyticsBackendEnv = "LIBRARY_ANALYTICS_BACKEND"
+const GetTopBooksUrlPath = "/analytics/top-books"
+const GetTopUsersUrlPath = "/analytics/top-users"
+const GetTrafficUrlPath = "/analytics/traffic"
+const SavedSearchesBackendEnv = "LIBRARY_SAVED_SEARCHES_BACKEND"
+const NotificationsBackendEnv = "LIBRARY_NOTIFICATIONS_BACKEND"
+const NotificationSinkEnv = "LIBRARY_NOTIFICATION_SINK"
+const CreateSavedSearchUrlPath = "/users/:username/saved-searches"
+const GetSavedSearchesUrlPath = "/users/:username/saved-searches"
+const DeleteSavedSearchUrlPath = "/users/:username/saved-searches/:id"
+const GetNotificationsUrlPath = "/users/:username/notifications"
+const WebhooksBackendEnv = "LIBRARY_WEBHOOKS_BACKEND"
+const CreateWebhookUrlPath = "/webhooks"
+const GetWebhooksUrlPath = "/webhooks"
+const DeleteWebhookUrlPath = "/webhooks/:id"
+const GetWebhookDeliveriesUrlPath = "/webhooks/:id/deliveries"
+const ReplayWebhookDeliveryUrlPath = "/webhooks/:id/deliveries/:delivery_id/replay"
+const EventOutboxBackendEnv = "LIBRARY_EVENT_OUTBOX_BACKEND"
+const EventStreamBackendEnv = "LIBRARY_EVENT_STREAM_BACKEND"
+const BackendFile = "file"
+const GetConsumerEventsUrlPath = "/event-log/consumers/:name/events"
+const CommitConsumerOffsetUrlPath = "/event-log/consumers/:name/offset"
+const StreamEventsUrlPath = "/events"
@@ -0,0 +1,11 @@
+package consts
+
+const ErrorCodeBadRequest = "bad_request"
+const ErrorCodeValidation = "validation_failed"
+const ErrorCodeNotFound = "not_found"
+const ErrorCodeDuplicateBook = "duplicate_book"
+const ErrorCodeConflict = "conflict"
+const ErrorCodeVersionConflict = "version_conflict"
+const ErrorCodePreconditionRequired = "precondition_required"
+const ErrorCodeRequestTooLarge = "request_too_large"
+const ErrorCodeInternal = "internal_error"
@@ -0,0 +1,14 @@
+package consts
+
+const EventOutboxRedisKey = "books_library_exercise:events:outbox"
+const EventStreamRedisKey = "books_library_exercise:events:stream"
+const EventOffsetsRedisKey = "books_library_exercise:events:offsets"
+const EventStreamMaxLength = 100000
+const EventStreamPath = "book_events.jsonl"
+const EventRelayBatchSize = 100
+const EventRelayIntervalSeconds = 1
+const EventConsumerPollSeconds = 1
+const EventConsumerBatchSize = 100
+const EventReadSize = 100
+const WebhooksConsumer = "webhooks"
+const EventRetryQueueSize = 10000
@@ -0,0 +1,8 @@
+package consts
+
+const ImportCheckpointRedisKey = "books_library_exercise:imports:%s:checkpoint"
+const ImportCheckpointTtlHours = 7 * 24
+const ImportMaxBytes = 100 << 20
+const ImportStatusCreated = "created"
+const ImportStatusSkipped = "skipped"
+const ImportStatusFailed = "failed"
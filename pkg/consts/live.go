@@ -0,0 +1,11 @@
+package consts
+
+const LiveTopicBooks = "books"
+const LiveTopicActivity = "activity"
+const LiveEventActivity = "activity"
+const LiveEventReset = "reset"
+const LiveHistorySize = 1000
+const LiveSubscriberBuffer = 256
+const LiveTailPollMs = 500
+const LiveHeartbeatSeconds = 15
+const LastEventIdHeader = "Last-Event-ID"
@@ -0,0 +1,12 @@
+package consts
+
+const SavedSearchesIndexName = "saved_searches"
+const SavedSearchesPerUser = 50
+const SavedSearchMatchSize = 1000
+const NotificationsRedisKey = "books_library_exercise:notifications:%s"
+const NotificationsPerUser = 200
+const NotificationsQuerySize = 50
+const NotificationSinkOutbox = "outbox"
+const NotificationSinkLog = "log"
+const NotificationSinkWebhook = "webhook"
+const NotificationWebhookTimeout = 5
@@ -1,4 +1,19 @@
 package consts
 
-const UserActivityActions = 3
-const UserActivityRedisKey = "books_library_exercise:users:activity:%s"
+const UserActivityActions = 1000
+const UserActivityRetentionHours = 30 * 24
+const UserActivityQuerySize = 50
+const UserActivityRedisKey = "books_library_exercise:users:events:%s"
+const ResourceIdContextKey = "resource_id"
+const ErrorCodeContextKey = "error_code"
+const UserActivityQueueSize = 10000
+const UserActivityWorkers = 2
+const UserActivityBatchSize = 100
+const UserActivityFlushIntervalMs = 500
+const UserActivitySpillPath = "user_activity.spill"
+const UserActivitySpillReplaySeconds = 30
+const OverflowPolicyDrop = "drop"
+const OverflowPolicyBlock = "block"
+const OverflowPolicySpill = "spill"
+const LegacyUserActivityRedisKeys = "books_library_exercise:users:activity:*"
+const LegacyUserActivityDeleteBatchSize = 500
@@ -0,0 +1,28 @@
+package consts
+
+// There is no loan domain yet, so subscribers can only ask for book events
+const EventBookCreated = "book.created"
+const EventBookUpdated = "book.updated"
+const EventBookDeleted = "book.deleted"
+const WebhookSubscriptionsRedisKey = "books_library_exercise:webhooks:subscriptions"
+const WebhookDeliveriesRedisKey = "books_library_exercise:webhooks:deliveries"
+const WebhookSubscriptionDeliveriesRedisKey = "books_library_exercise:webhooks:deliveries:%s"
+const WebhookDueDeliveriesRedisKey = "books_library_exercise:webhooks:due"
+const WebhookDeliveryClaimsRedisKey = "books_library_exercise:webhooks:claims"
+const WebhookDeliveryHistory = 500
+const WebhookDeliveriesQuerySize = 50
+const WebhookMaxAttempts = 8
+const WebhookInitialBackoffSeconds = 5
+const WebhookMaxBackoffSeconds = 60 * 60
+const WebhookPollIntervalSeconds = 1
+const WebhookDueBatchSize = 100
+const WebhookClaimLeaseSeconds = 60
+const WebhookWorkers = 8
+const WebhookRequestTimeout = 10
+const WebhookSecretBytes = 32
+const WebhookSignatureHeader = "X-Library-Signature"
+const WebhookEventHeader = "X-Library-Event"
+const WebhookDeliveryHeader = "X-Library-Delivery"
+const WebhookDeliveryPending = "pending"
+const WebhookDeliverySucceeded = "succeeded"
+const WebhookDeliveryFailed = "failed"
@@ -1,21 +1,52 @@
 package controller
 
 import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/formats"
 	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
 	"pkg/service/pkg/models/request"
+	"strconv"
+	"time"
 )
 
 type LibraryController struct {
-	booksHandler interfaces.BooksHandler
-	usersHandler interfaces.UsersHandler
+	booksHandler           interfaces.BooksHandler
+	usersHandler           interfaces.UsersHandler
+	branchesHandler        interfaces.BranchesHandler
+	authorsHandler         interfaces.AuthorsHandler
+	auditHandler           interfaces.AuditHandler
+	analyticsHandler       interfaces.AnalyticsHandler
+	recommendationsHandler interfaces.RecommendationsHandler
+	savedSearchesHandler   interfaces.SavedSearchesHandler
+	webhooksHandler        interfaces.WebhooksHandler
+	eventsHandler          interfaces.EventsHandler
+	// importMaxBytes is the largest file a book import accepts
+	importMaxBytes int64
 }
 
-func NewLibraryController(booksHandler interfaces.BooksHandler, usersHandler interfaces.UsersHandler) *LibraryController {
+func NewLibraryController(booksHandler interfaces.BooksHandler, usersHandler interfaces.UsersHandler, branchesHandler interfaces.BranchesHandler, authorsHandler interfaces.AuthorsHandler, auditHandler interfaces.AuditHandler, analyticsHandler interfaces.AnalyticsHandler, recommendationsHandler interfaces.RecommendationsHandler, savedSearchesHandler interfaces.SavedSearchesHandler, webhooksHandler interfaces.WebhooksHandler, eventsHandler interfaces.EventsHandler, importMaxBytes int64) *LibraryController {
 	return &LibraryController{
-		booksHandler: booksHandler,
-		usersHandler: usersHandler,
+		booksHandler:           booksHandler,
+		usersHandler:           usersHandler,
+		branchesHandler:        branchesHandler,
+		authorsHandler:         authorsHandler,
+		auditHandler:           auditHandler,
+		analyticsHandler:       analyticsHandler,
+		recommendationsHandler: recommendationsHandler,
+		savedSearchesHandler:   savedSearchesHandler,
+		webhooksHandler:        webhooksHandler,
+		eventsHandler:          eventsHandler,
+		importMaxBytes:         importMaxBytes,
 	}
 }
 
@@ -26,12 +57,19 @@ func (lc *LibraryController) CreateBook(ctx *gin.Context) {
 		return
 	}
 
-	bookId, err := lc.booksHandler.CreateBook(req)
+	allowDuplicate, err := strconv.ParseBool(ctx.DefaultQuery("allow_duplicate", "false"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "allow_duplicate must be a boolean"})
+		return
+	}
+
+	bookId, err := lc.booksHandler.CreateBook(req, allowDuplicate, requestInfo(ctx))
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
 
+	ctx.Set(consts.ResourceIdContextKey, bookId)
 	ctx.IndentedJSON(http.StatusCreated, gin.H{"id": bookId})
 }
 
@@ -44,21 +82,171 @@ func (lc *LibraryController) GetBooks(ctx *gin.Context) {
 
 	res, err := lc.booksHandler.GetBooks(req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
+	// Facets need an object around the books, which stay a plain list otherwise
+	if req.Facets != "" {
+		ctx.IndentedJSON(http.StatusOK, res)
+		return
+	}
 	ctx.IndentedJSON(http.StatusOK, res.Books)
 }
 
+func (lc *LibraryController) BulkBooks(ctx *gin.Context) {
+	req := request.BulkBooks{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allowDuplicate, err := strconv.ParseBool(ctx.DefaultQuery("allow_duplicate", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "allow_duplicate must be a boolean"})
+		return
+	}
+
+	res, err := lc.booksHandler.BulkBooks(req, allowDuplicate, requestInfo(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) BulkBooksByQuery(ctx *gin.Context) {
+	filtersReq := request.GetBooks{}
+	if err := ctx.ShouldBindQuery(&filtersReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := request.BulkBooksByQuery{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.booksHandler.BulkBooksByQuery(filtersReq, req, requestInfo(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) ImportBooks(ctx *gin.Context) {
+	req := request.ImportBooks{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, lc.importMaxBytes)
+	if req.ImportId == "" {
+		// Uploading the same file again resumes its import, as with libraryctl
+		file, err := spoolBody(ctx, lc.importMaxBytes)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		defer closeSpooled(file)
+
+		if req.ImportId, err = formats.ImportId(file); err != nil {
+			writeError(ctx, err)
+			return
+		}
+		body = file
+	}
+
+	reader, err := formats.NewBookReader(req.Format, body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.booksHandler.ImportBooks(reader, req, requestInfo(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) ExportBooks(ctx *gin.Context) {
+	req := request.ExportBooks{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := "books" + formats.FileExtension(req.Format)
+	contentType := formats.ContentType(req.Format)
+	var out io.Writer = ctx.Writer
+	var gzipWriter *gzip.Writer
+	if req.Gzip {
+		gzipWriter = gzip.NewWriter(ctx.Writer)
+		out = gzipWriter
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+
+	writer, err := formats.NewBookWriter(req.Format, out)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	err = lc.booksHandler.ExportBooks(req.GetBooks, writer)
+	if err == nil && gzipWriter != nil {
+		err = gzipWriter.Close()
+	}
+	if err != nil {
+		// Once streaming started the status is sent, so the download is cut short instead
+		if ctx.Writer.Written() {
+			log.Printf("error exporting books: %s", err.Error())
+			ctx.Abort()
+			return
+		}
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		writeError(ctx, err)
+	}
+}
+
+func (lc *LibraryController) SuggestBooks(ctx *gin.Context) {
+	req := request.SuggestBooks{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.booksHandler.SuggestBooks(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
 func (lc *LibraryController) GetBookById(ctx *gin.Context) {
 	bookId := ctx.Param("id")
 	res, err := lc.booksHandler.GetBookById(bookId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
+	if res.Book.Version != nil {
+		ctx.Header("ETag", res.Book.Version.ETag())
+	}
 	ctx.IndentedJSON(http.StatusOK, res.Book)
 }
 
@@ -69,31 +257,240 @@ func (lc *LibraryController) UpdateBookTitle(ctx *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
 	bookId := ctx.Param("id")
-	err := lc.booksHandler.UpdateBookTitle(bookId, req)
+	updated, err := lc.booksHandler.UpdateBookTitle(bookId, version, req, requestInfo(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
+	ctx.Header("ETag", updated.ETag())
 	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "book title updated successfully"})
 }
 
 func (lc *LibraryController) DeleteBook(ctx *gin.Context) {
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
 	bookId := ctx.Param("id")
-	err := lc.booksHandler.DeleteBook(bookId)
+	err := lc.booksHandler.DeleteBook(bookId, version, requestInfo(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
 	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "book deleted successfully"})
 }
 
-func (lc *LibraryController) GetStoreInventory(ctx *gin.Context) {
-	res, err := lc.booksHandler.GetStoreInventory()
+func (lc *LibraryController) GetTrash(ctx *gin.Context) {
+	res, err := lc.booksHandler.GetTrash()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Books)
+}
+
+func (lc *LibraryController) RestoreBook(ctx *gin.Context) {
+	bookId := ctx.Param("id")
+	allowDuplicate, err := strconv.ParseBool(ctx.DefaultQuery("allow_duplicate", "false"))
 	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "allow_duplicate must be a boolean"})
+		return
+	}
+
+	err = lc.booksHandler.RestoreBook(bookId, allowDuplicate, requestInfo(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "book restored successfully"})
+}
+
+func (lc *LibraryController) PurgeBook(ctx *gin.Context) {
+	bookId := ctx.Param("id")
+	err := lc.booksHandler.PurgeBook(bookId, requestInfo(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "book purged successfully"})
+}
+
+func (lc *LibraryController) GetBookHistory(ctx *gin.Context) {
+	bookId := ctx.Param("id")
+	res, err := lc.auditHandler.GetBookHistory(bookId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Entries)
+}
+
+func (lc *LibraryController) GetSimilarBooks(ctx *gin.Context) {
+	req := request.GetRecommendations{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.recommendationsHandler.GetSimilarBooks(ctx.Param("id"), req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) GetAlsoViewedBooks(ctx *gin.Context) {
+	req := request.GetRecommendations{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.recommendationsHandler.GetAlsoViewedBooks(ctx.Param("id"), req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) GetAudit(ctx *gin.Context) {
+	req := request.GetAudit{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.auditHandler.GetAudit(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Entries)
+}
+
+// spoolBody copies an upload of up to limit bytes to a temporary file, for
+// when it has to be read more than once.
+func spoolBody(ctx *gin.Context, limit int64) (*os.File, error) {
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit)
+	file, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(file, body); err != nil {
+		closeSpooled(file)
+		return nil, err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		closeSpooled(file)
+		return nil, err
+	}
+	return file, nil
+}
+
+func closeSpooled(file *os.File) {
+	if err := file.Close(); err != nil {
+		log.Printf("failed to close %s: %s", file.Name(), err.Error())
+	}
+	if err := os.Remove(file.Name()); err != nil {
+		log.Printf("failed to remove %s: %s", file.Name(), err.Error())
+	}
+}
+
+// requestInfo identifies the user and request behind a change, as set by the
+// middlewares.
+func requestInfo(ctx *gin.Context) models.RequestInfo {
+	return models.RequestInfo{
+		Username:  ctx.GetString(consts.UsernameContextKey),
+		RequestId: ctx.GetString(consts.RequestIdContextKey),
+	}
+}
+
+// ifMatchVersion reads the book version a write is conditioned on from the
+// If-Match header, where "*" matches any version. It writes the error response
+// itself when the header is missing or cannot match a version.
+func ifMatchVersion(ctx *gin.Context) (*models.BookVersion, bool) {
+	ifMatch := ctx.GetHeader("If-Match")
+	if ifMatch == "" {
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodePreconditionRequired)
+		ctx.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the book ETag is required"})
+		return nil, false
+	}
+	if ifMatch == "*" {
+		return nil, true
+	}
+
+	version, err := models.ParseETag(ifMatch)
+	if err != nil {
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeVersionConflict)
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the book version"})
+		return nil, false
+	}
+	return &version, true
+}
+
+// writeError responds with the status matching the type of err, and keeps a
+// machine readable error code for the activity log.
+func writeError(ctx *gin.Context, err error) {
+	var validationErr *models.ValidationError
+	var notFoundErr *models.NotFoundError
+	var duplicateErr *models.DuplicateBookError
+	var conflictErr *models.VersionConflictError
+	var stateConflictErr *models.ConflictError
+	var tooLargeErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &validationErr):
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeValidation)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &notFoundErr):
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeNotFound)
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &duplicateErr):
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeDuplicateBook)
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "existing_id": duplicateErr.ExistingId})
+	case errors.As(err, &stateConflictErr):
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeConflict)
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &tooLargeErr):
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeRequestTooLarge)
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("the upload is larger than %d bytes", tooLargeErr.Limit)})
+	case errors.As(err, &conflictErr):
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeVersionConflict)
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	default:
+		ctx.Set(consts.ErrorCodeContextKey, consts.ErrorCodeInternal)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (lc *LibraryController) GetStoreInventory(ctx *gin.Context) {
+	req := request.GetStoreInventory{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.booksHandler.GetStoreInventory(req)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
 
@@ -101,12 +498,425 @@ func (lc *LibraryController) GetStoreInventory(ctx *gin.Context) {
 }
 
 func (lc *LibraryController) GetUserActivity(ctx *gin.Context) {
+	req := request.GetUserActivity{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	username := ctx.Param("username")
-	res, err := lc.usersHandler.GetUserActivity(username)
+	res, err := lc.usersHandler.GetUserActivity(username, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) CreateSavedSearch(ctx *gin.Context) {
+	req := request.CreateSavedSearch{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	username := ctx.Param("username")
+	savedSearchId, err := lc.savedSearchesHandler.CreateSavedSearch(username, req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Set(consts.ResourceIdContextKey, savedSearchId)
+	ctx.IndentedJSON(http.StatusCreated, gin.H{"id": savedSearchId})
+}
+
+func (lc *LibraryController) GetSavedSearches(ctx *gin.Context) {
+	username := ctx.Param("username")
+	res, err := lc.savedSearchesHandler.GetSavedSearches(username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.SavedSearches)
+}
+
+func (lc *LibraryController) DeleteSavedSearch(ctx *gin.Context) {
+	username := ctx.Param("username")
+	savedSearchId := ctx.Param("id")
+	if err := lc.savedSearchesHandler.DeleteSavedSearch(username, savedSearchId); err != nil {
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, res.Actions)
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "saved search deleted successfully"})
+}
+
+func (lc *LibraryController) GetNotifications(ctx *gin.Context) {
+	req := request.GetNotifications{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	username := ctx.Param("username")
+	res, err := lc.savedSearchesHandler.GetNotifications(username, req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Notifications)
+}
+
+func (lc *LibraryController) CreateWebhook(ctx *gin.Context) {
+	req := request.CreateWebhook{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.webhooksHandler.CreateWebhook(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Set(consts.ResourceIdContextKey, res.Id)
+	ctx.IndentedJSON(http.StatusCreated, res)
+}
+
+func (lc *LibraryController) GetWebhooks(ctx *gin.Context) {
+	res, err := lc.webhooksHandler.GetWebhooks()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Webhooks)
+}
+
+func (lc *LibraryController) DeleteWebhook(ctx *gin.Context) {
+	webhookId := ctx.Param("id")
+	if err := lc.webhooksHandler.DeleteWebhook(webhookId); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "webhook deleted successfully"})
+}
+
+func (lc *LibraryController) GetWebhookDeliveries(ctx *gin.Context) {
+	req := request.GetWebhookDeliveries{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhookId := ctx.Param("id")
+	res, err := lc.webhooksHandler.GetWebhookDeliveries(webhookId, req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Deliveries)
+}
+
+func (lc *LibraryController) ReplayWebhookDelivery(ctx *gin.Context) {
+	webhookId := ctx.Param("id")
+	deliveryId := ctx.Param("delivery_id")
+	res, err := lc.webhooksHandler.ReplayWebhookDelivery(webhookId, deliveryId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Set(consts.ResourceIdContextKey, res.Id)
+	ctx.IndentedJSON(http.StatusAccepted, res)
+}
+
+func (lc *LibraryController) GetConsumerEvents(ctx *gin.Context) {
+	req := request.GetConsumerEvents{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	consumer := ctx.Param("name")
+	res, err := lc.eventsHandler.GetConsumerEvents(consumer, req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) CommitConsumerOffset(ctx *gin.Context) {
+	req := request.CommitConsumerOffset{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	consumer := ctx.Param("name")
+	if err := lc.eventsHandler.CommitConsumerOffset(consumer, req); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{"consumer": consumer, "offset": req.Offset})
+}
+
+// StreamEvents streams the live feed as server-sent events. A client that
+// resumes with a Last-Event-ID the feed no longer knows gets a reset event
+// first, to reload what it shows. The stream ends when the client goes away
+// or falls too far behind, and the client reconnects with its Last-Event-ID.
+func (lc *LibraryController) StreamEvents(ctx *gin.Context) {
+	req := request.StreamEvents{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lastEventId := ctx.GetHeader(consts.LastEventIdHeader)
+	subscription, err := lc.eventsHandler.SubscribeLive(req, lastEventId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	defer subscription.Cancel()
+
+	// Set up front since the headers are flushed before the first event
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	if !subscription.Resumed {
+		ctx.Render(-1, sse.Event{Event: consts.LiveEventReset, Data: gin.H{"last_event_id": lastEventId}})
+	}
+	for _, event := range subscription.Backlog {
+		renderLiveEvent(ctx, event)
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(consts.LiveHeartbeatSeconds * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			renderLiveEvent(ctx, event)
+		case <-heartbeat.C:
+			// A comment line keeps proxies from closing an idle stream
+			if _, err = ctx.Writer.WriteString(": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func renderLiveEvent(ctx *gin.Context, event models.LiveEvent) {
+	ctx.Render(-1, sse.Event{Id: event.Id, Event: event.Type, Data: event.Data})
+}
+
+func (lc *LibraryController) CreateBranch(ctx *gin.Context) {
+	req := request.CreateBranch{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	branchId, err := lc.branchesHandler.CreateBranch(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Set(consts.ResourceIdContextKey, branchId)
+	ctx.IndentedJSON(http.StatusCreated, gin.H{"id": branchId})
+}
+
+func (lc *LibraryController) GetBranches(ctx *gin.Context) {
+	res, err := lc.branchesHandler.GetBranches()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Branches)
+}
+
+func (lc *LibraryController) GetBranchInventory(ctx *gin.Context) {
+	branchId := ctx.Param("id")
+	res, err := lc.branchesHandler.GetBranchInventory(branchId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) AddBranchCopies(ctx *gin.Context) {
+	req := request.AddCopies{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	branchId := ctx.Param("id")
+	res, err := lc.branchesHandler.AddCopies(branchId, req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusCreated, res)
+}
+
+func (lc *LibraryController) CreateTransfer(ctx *gin.Context) {
+	req := request.CreateTransfer{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.branchesHandler.CreateTransfer(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Set(consts.ResourceIdContextKey, res.Id)
+	ctx.IndentedJSON(http.StatusCreated, res)
+}
+
+func (lc *LibraryController) CompleteTransfer(ctx *gin.Context) {
+	transferId := ctx.Param("id")
+	res, err := lc.branchesHandler.CompleteTransfer(transferId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) CreateAuthor(ctx *gin.Context) {
+	req := request.CreateAuthor{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authorId, err := lc.authorsHandler.CreateAuthor(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Set(consts.ResourceIdContextKey, authorId)
+	ctx.IndentedJSON(http.StatusCreated, gin.H{"id": authorId})
+}
+
+func (lc *LibraryController) GetAuthors(ctx *gin.Context) {
+	req := request.GetAuthors{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.authorsHandler.GetAuthors(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res.Authors)
+}
+
+func (lc *LibraryController) GetAuthorById(ctx *gin.Context) {
+	authorId := ctx.Param("id")
+	res, err := lc.authorsHandler.GetAuthorById(authorId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) MergeAuthors(ctx *gin.Context) {
+	req := request.MergeAuthors{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authorId := ctx.Param("id")
+	res, err := lc.authorsHandler.MergeAuthors(authorId, req, requestInfo(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) GetTopBooks(ctx *gin.Context) {
+	req := request.GetAnalyticsTop{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.analyticsHandler.GetTopBooks(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) GetTopUsers(ctx *gin.Context) {
+	req := request.GetAnalyticsTop{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.analyticsHandler.GetTopUsers(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
+}
+
+func (lc *LibraryController) GetTraffic(ctx *gin.Context) {
+	req := request.GetTraffic{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := lc.analyticsHandler.GetTraffic(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, res)
 }
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"log"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+// Consumer reads the event stream from the offset committed under its name.
+type Consumer struct {
+	name   string
+	stream interfaces.EventStream
+}
+
+func NewConsumer(name string, stream interfaces.EventStream) *Consumer {
+	return &Consumer{name: name, stream: stream}
+}
+
+// Poll returns the next events after the committed offset without committing.
+func (c *Consumer) Poll(limit int) ([]models.StreamEvent, error) {
+	offset, err := c.stream.GetOffset(c.name)
+	if err != nil {
+		return nil, err
+	}
+	return c.stream.Read(offset, limit)
+}
+
+func (c *Consumer) Commit(offset string) error {
+	return c.stream.CommitOffset(c.name, offset)
+}
+
+// Run hands every batch of events to handle and commits the batch once handle
+// succeeds. A failed batch is handed over again on the next poll, so handle
+// has to cope with seeing events more than once.
+func (c *Consumer) Run(ctx context.Context, batchSize int, pollInterval time.Duration, handle func(events []models.StreamEvent) error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.consume(batchSize, handle); err != nil {
+			log.Printf("consumer %s failed: %s", c.name, err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Consumer) consume(batchSize int, handle func(events []models.StreamEvent) error) error {
+	for {
+		batch, err := c.Poll(batchSize)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+		if err = handle(batch); err != nil {
+			return err
+		}
+		if err = c.Commit(batch[len(batch)-1].Offset); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// BookEvents returns the events of a batch without their offsets.
+func BookEvents(batch []models.StreamEvent) []models.BookEvent {
+	events := make([]models.BookEvent, 0, len(batch))
+	for _, streamEvent := range batch {
+		events = append(events, streamEvent.Event)
+	}
+	return events
+}
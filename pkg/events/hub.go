@@ -0,0 +1,165 @@
+package events
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ interfaces.LiveFeed = &Hub{}
+
+var liveMetrics = expvar.NewMap("live_events")
+
+// Hub keeps the latest events in memory so reconnecting subscribers can pick
+// up after their Last-Event-ID. Event ids start with the time the hub was
+// created, so ids from before a restart are told apart from current ones.
+//
+// Every subscriber has a buffered channel. Publish never blocks on it: a
+// subscriber whose buffer is full is dropped, and resumes from the history
+// when it reconnects.
+type Hub struct {
+	mu          sync.Mutex
+	epoch       string
+	sequence    int64
+	history     []models.LiveEvent
+	historySize int
+	bufferSize  int
+	subscribers map[*subscriber]bool
+	closed      bool
+}
+
+type subscriber struct {
+	filter models.LiveFilter
+	events chan models.LiveEvent
+}
+
+func NewHub(historySize int, bufferSize int) *Hub {
+	hub := &Hub{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*subscriber]bool),
+	}
+	liveMetrics.Set("subscribers", expvar.Func(func() interface{} {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return len(hub.subscribers)
+	}))
+	return hub
+}
+
+func (h *Hub) Publish(topic string, eventType string, username string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("failed to encode %s live event: %s", eventType, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.sequence++
+	event := models.LiveEvent{
+		Id:       fmt.Sprintf("%s-%d", h.epoch, h.sequence),
+		Topic:    topic,
+		Type:     eventType,
+		Username: username,
+		Data:     encoded,
+	}
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+	liveMetrics.Add("published", 1)
+
+	for s := range h.subscribers {
+		if !s.filter.Matches(event) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			h.remove(s)
+			liveMetrics.Add("dropped_subscribers", 1)
+		}
+	}
+}
+
+// Subscribe registers the subscriber and collects its backlog under the same
+// lock, so no event is missed or sent twice in between.
+func (h *Hub) Subscribe(filter models.LiveFilter, lastEventId string) models.LiveSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := &subscriber{filter: filter, events: make(chan models.LiveEvent, h.bufferSize)}
+	subscription := models.LiveSubscription{Resumed: lastEventId == "", Events: s.events}
+	if lastEventId != "" {
+		subscription.Backlog, subscription.Resumed = h.since(lastEventId, filter)
+	}
+
+	if h.closed {
+		close(s.events)
+	} else {
+		h.subscribers[s] = true
+	}
+	subscription.Cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(s)
+	}
+	return subscription
+}
+
+// Close ends every subscription, so the streams can finish before the server
+// shuts down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subscribers {
+		h.remove(s)
+	}
+}
+
+func (h *Hub) remove(s *subscriber) {
+	if h.subscribers[s] {
+		delete(h.subscribers, s)
+		close(s.events)
+	}
+}
+
+// since returns the events in the history after lastEventId. It cannot resume
+// from ids of an earlier hub or older than the history.
+func (h *Hub) since(lastEventId string, filter models.LiveFilter) ([]models.LiveEvent, bool) {
+	epoch, sequence, found := strings.Cut(lastEventId, "-")
+	if !found || epoch != h.epoch {
+		return nil, false
+	}
+	last, err := strconv.ParseInt(sequence, 10, 64)
+	if err != nil || last > h.sequence {
+		return nil, false
+	}
+
+	oldest := h.sequence - int64(len(h.history)) + 1
+	if last < oldest-1 {
+		return nil, false
+	}
+	backlog := make([]models.LiveEvent, 0)
+	for _, event := range h.history[last-oldest+1:] {
+		if filter.Matches(event) {
+			backlog = append(backlog, event)
+		}
+	}
+	return backlog, true
+}
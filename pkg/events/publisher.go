@@ -0,0 +1,133 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+	"time"
+)
+
+var _ interfaces.EventPublisher = &Publisher{}
+
+// Publisher puts the events in the outbox, and Run relays them from there to
+// the event stream, acknowledging them only once the stream has them. Events
+// whose append fails stay in the outbox and are relayed again, so the stream
+// gets every event at least once and in order.
+//
+// The books and the outbox live in different stores that cannot share a
+// transaction, so the events are added right after the change is stored.
+// The change is stored by then, so events the outbox does not take are kept
+// in memory and added again by Run rather than failing the request. Later
+// events queue up behind them to keep the order. The window left is the
+// service stopping in between, or more than consts.EventRetryQueueSize
+// events waiting.
+type Publisher struct {
+	outboxRepository interfaces.EventOutboxRepository
+	stream           interfaces.EventStream
+	interval         time.Duration
+	wake             chan struct{}
+	mu               sync.Mutex
+	unrecorded       []models.BookEvent
+}
+
+func NewPublisher(outboxRepository interfaces.EventOutboxRepository, stream interfaces.EventStream, interval time.Duration) *Publisher {
+	return &Publisher{
+		outboxRepository: outboxRepository,
+		stream:           stream,
+		interval:         interval,
+		wake:             make(chan struct{}, 1),
+	}
+}
+
+func (p *Publisher) Publish(events []models.BookEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.unrecorded) == 0 {
+		err := p.outboxRepository.Add(events)
+		if err == nil {
+			p.notify()
+			return nil
+		}
+		log.Printf("failed to add %d events to the outbox, retrying: %s", len(events), err.Error())
+	}
+	if len(p.unrecorded)+len(events) > consts.EventRetryQueueSize {
+		return errors.New("too many events waiting to be added to the outbox")
+	}
+	p.unrecorded = append(p.unrecorded, events...)
+	return nil
+}
+
+// Record adds the events the outbox did not take when they were published.
+func (p *Publisher) Record() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.unrecorded) == 0 {
+		return nil
+	}
+	if err := p.outboxRepository.Add(p.unrecorded); err != nil {
+		return err
+	}
+	p.unrecorded = nil
+	p.notify()
+	return nil
+}
+
+func (p *Publisher) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run relays the outbox every interval and as soon as events are published,
+// until ctx is done.
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Record(); err != nil {
+			log.Printf("failed to add events to the outbox: %s", err.Error())
+		}
+		if err := p.Relay(); err != nil {
+			log.Printf("failed to relay events: %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+	}
+}
+
+// Relay moves the pending events of the outbox to the stream.
+func (p *Publisher) Relay() error {
+	for {
+		pending, err := p.outboxRepository.Pending(consts.EventRelayBatchSize)
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+		if err = p.stream.Append(pending); err != nil {
+			return err
+		}
+		eventIds := make([]string, 0, len(pending))
+		for _, event := range pending {
+			eventIds = append(eventIds, event.Id)
+		}
+		if err = p.outboxRepository.Ack(eventIds); err != nil {
+			return err
+		}
+		if len(pending) < consts.EventRelayBatchSize {
+			return nil
+		}
+	}
+}
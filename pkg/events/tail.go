@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"time"
+)
+
+// TailStream publishes the book events appended to the stream to the live
+// feed, starting with the events appended after it is called. Every instance
+// tails the stream, so the subscribers of any instance see every change.
+func TailStream(ctx context.Context, stream interfaces.EventStream, feed interfaces.LiveFeed, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	offset, started := "", false
+	for {
+		var err error
+		if !started {
+			if offset, err = stream.LastOffset(); err == nil {
+				started = true
+			}
+		} else {
+			offset, err = tail(stream, feed, offset)
+		}
+		if err != nil {
+			log.Printf("failed to tail the event stream: %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func tail(stream interfaces.EventStream, feed interfaces.LiveFeed, offset string) (string, error) {
+	for {
+		batch, err := stream.Read(offset, consts.EventReadSize)
+		if err != nil {
+			return offset, err
+		}
+		for _, streamEvent := range batch {
+			feed.Publish(consts.LiveTopicBooks, streamEvent.Event.Type, "", streamEvent.Event)
+			offset = streamEvent.Offset
+		}
+		if len(batch) < consts.EventReadSize {
+			return offset, nil
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package formats
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"strconv"
+	"strings"
+)
+
+var _ interfaces.BookReader = &CsvBookReader{}
+
+// CsvBookReader reads books from a CSV file whose header names the columns
+// after the JSON fields of request.CreateBook. List columns such as
+// author_names and genres are separated by ListSeparator.
+type CsvBookReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	row     int
+}
+
+const ListSeparator = ";"
+
+func NewCsvBookReader(r io.Reader) interfaces.BookReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &CsvBookReader{reader: reader}
+}
+
+func (c *CsvBookReader) Read() (int, *request.CreateBook, error) {
+	if c.columns == nil {
+		header, err := c.reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, nil, err
+			}
+			return 0, nil, fmt.Errorf("invalid header: %w", err)
+		}
+		c.columns = make(map[string]int, len(header))
+		for i, column := range header {
+			c.columns[strings.TrimSpace(column)] = i
+		}
+	}
+
+	record, err := c.reader.Read()
+	var parseErr *csv.ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+		return 0, nil, err
+	}
+	c.row++
+	if err != nil {
+		return c.row, nil, err
+	}
+
+	book, err := c.parseRecord(record)
+	return c.row, book, err
+}
+
+func (c *CsvBookReader) parseRecord(record []string) (*request.CreateBook, error) {
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	book := &request.CreateBook{
+		Title:         field("title"),
+		AuthorNames:   splitList(field("author_names")),
+		Isbn10:        field("isbn_10"),
+		Isbn13:        field("isbn_13"),
+		Publisher:     field("publisher"),
+		Genres:        splitList(field("genres")),
+		Language:      field("language"),
+		Edition:       field("edition"),
+		Description:   field("description"),
+		CoverImageUrl: field("cover_image_url"),
+	}
+
+	var err error
+	if value := field("price"); value != "" {
+		if book.Price, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, errors.New("price must be a number")
+		}
+	}
+	if value := field("ebook_available"); value != "" {
+		if book.EbookAvailable, err = strconv.ParseBool(value); err != nil {
+			return nil, errors.New("ebook_available must be a boolean")
+		}
+	}
+	if value := field("page_count"); value != "" {
+		if book.PageCount, err = strconv.Atoi(value); err != nil {
+			return nil, errors.New("page_count must be an integer")
+		}
+	}
+	if value := field("publish_date"); value != "" {
+		publishDate, err := models.ParsePublishDate(value)
+		if err != nil {
+			return nil, err
+		}
+		book.PublishDate = &publishDate
+	}
+
+	return book, nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
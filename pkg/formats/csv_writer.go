@@ -0,0 +1,70 @@
+package formats
+
+import (
+	"encoding/csv"
+	"io"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"strconv"
+	"strings"
+)
+
+var _ interfaces.BookWriter = &CsvBookWriter{}
+
+// CsvBookWriter writes the columns read by CsvBookReader, plus the book id,
+// so an export can be imported back as is.
+type CsvBookWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+var csvColumns = []string{
+	"id", "title", "author_names", "price", "ebook_available", "publish_date", "isbn_10", "isbn_13",
+	"publisher", "genres", "language", "page_count", "edition", "description", "cover_image_url",
+}
+
+func NewCsvBookWriter(w io.Writer) interfaces.BookWriter {
+	return &CsvBookWriter{writer: csv.NewWriter(w)}
+}
+
+func (c *CsvBookWriter) Write(book models.Book) error {
+	if !c.headerWritten {
+		if err := c.writer.Write(csvColumns); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	pageCount := ""
+	if book.PageCount > 0 {
+		pageCount = strconv.Itoa(book.PageCount)
+	}
+
+	return c.writer.Write([]string{
+		book.Id,
+		book.Title,
+		strings.Join(book.AuthorNames, ListSeparator),
+		strconv.FormatFloat(book.Price, 'f', -1, 64),
+		strconv.FormatBool(book.EbookAvailable),
+		book.PublishDate.String(),
+		book.Isbn10,
+		book.Isbn13,
+		book.Publisher,
+		strings.Join(book.Genres, ListSeparator),
+		book.Language,
+		pageCount,
+		book.Edition,
+		book.Description,
+		book.CoverImageUrl,
+	})
+}
+
+func (c *CsvBookWriter) Close() error {
+	if !c.headerWritten {
+		if err := c.writer.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,68 @@
+package formats
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+)
+
+func NewBookReader(format string, r io.Reader) (interfaces.BookReader, error) {
+	switch format {
+	case consts.FormatCsv:
+		return NewCsvBookReader(r), nil
+	case consts.FormatNdjson:
+		return NewNdjsonBookReader(r), nil
+	default:
+		return nil, errors.New("unsupported import format: " + format)
+	}
+}
+
+// ImportId derives the id an import is checkpointed under from the contents
+// being imported, so importing the same file again resumes it. The reader is
+// rewound for the import itself.
+func ImportId(r io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:32], nil
+}
+
+func NewBookWriter(format string, w io.Writer) (interfaces.BookWriter, error) {
+	switch format {
+	case consts.FormatCsv:
+		return NewCsvBookWriter(w), nil
+	case consts.FormatNdjson:
+		return NewNdjsonBookWriter(w), nil
+	case consts.FormatMarcXml:
+		return NewMarcXmlBookWriter(w), nil
+	default:
+		return nil, errors.New("unsupported export format: " + format)
+	}
+}
+
+func ContentType(format string) string {
+	switch format {
+	case consts.FormatCsv:
+		return "text/csv; charset=utf-8"
+	case consts.FormatMarcXml:
+		return "application/marcxml+xml"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+func FileExtension(format string) string {
+	switch format {
+	case consts.FormatMarcXml:
+		return ".xml"
+	default:
+		return "." + format
+	}
+}
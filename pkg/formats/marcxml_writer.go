@@ -0,0 +1,133 @@
+package formats
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"strconv"
+)
+
+var _ interfaces.BookWriter = &MarcXmlBookWriter{}
+
+// MarcXmlBookWriter writes a MARC 21 slim collection with one bibliographic
+// record per book.
+type MarcXmlBookWriter struct {
+	w             io.Writer
+	encoder       *xml.Encoder
+	headerWritten bool
+}
+
+const marcXmlHeader = xml.Header + `<collection xmlns="http://www.loc.gov/MARC21/slim">` + "\n"
+const marcXmlFooter = "</collection>\n"
+const marcLeader = "00000nam a2200000 a 4500"
+
+type marcRecord struct {
+	XMLName       xml.Name           `xml:"record"`
+	Leader        string             `xml:"leader"`
+	ControlFields []marcControlField `xml:"controlfield"`
+	DataFields    []marcDataField    `xml:"datafield"`
+}
+
+type marcControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcDataField struct {
+	Tag       string         `xml:"tag,attr"`
+	Ind1      string         `xml:"ind1,attr"`
+	Ind2      string         `xml:"ind2,attr"`
+	Subfields []marcSubfield `xml:"subfield"`
+}
+
+type marcSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+func NewMarcXmlBookWriter(w io.Writer) interfaces.BookWriter {
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return &MarcXmlBookWriter{w: w, encoder: encoder}
+}
+
+func (m *MarcXmlBookWriter) Write(book models.Book) error {
+	if err := m.writeHeader(); err != nil {
+		return err
+	}
+	if err := m.encoder.Encode(newMarcRecord(book)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.w, "\n")
+	return err
+}
+
+func (m *MarcXmlBookWriter) Close() error {
+	if err := m.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.w, marcXmlFooter)
+	return err
+}
+
+func (m *MarcXmlBookWriter) writeHeader() error {
+	if m.headerWritten {
+		return nil
+	}
+	m.headerWritten = true
+	_, err := io.WriteString(m.w, marcXmlHeader)
+	return err
+}
+
+func newMarcRecord(book models.Book) marcRecord {
+	record := marcRecord{
+		Leader:        marcLeader,
+		ControlFields: []marcControlField{{Tag: "001", Value: book.Id}},
+	}
+	addField := func(tag string, ind1 string, subfields ...marcSubfield) {
+		values := make([]marcSubfield, 0, len(subfields))
+		for _, subfield := range subfields {
+			if subfield.Value != "" {
+				values = append(values, subfield)
+			}
+		}
+		if len(values) > 0 {
+			record.DataFields = append(record.DataFields, marcDataField{Tag: tag, Ind1: ind1, Ind2: " ", Subfields: values})
+		}
+	}
+
+	addField("020", " ", marcSubfield{Code: "a", Value: book.Isbn13})
+	addField("020", " ", marcSubfield{Code: "a", Value: book.Isbn10})
+	addField("041", "0", marcSubfield{Code: "a", Value: book.Language})
+	for i, authorName := range book.AuthorNames {
+		tag := "700"
+		if i == 0 {
+			tag = "100"
+		}
+		addField(tag, "1", marcSubfield{Code: "a", Value: authorName})
+	}
+	addField("245", "1", marcSubfield{Code: "a", Value: book.Title})
+	addField("250", " ", marcSubfield{Code: "a", Value: book.Edition})
+	addField("264", " ",
+		marcSubfield{Code: "b", Value: book.Publisher},
+		marcSubfield{Code: "c", Value: book.PublishDate.String()},
+	)
+	if book.PageCount > 0 {
+		addField("300", " ", marcSubfield{Code: "a", Value: strconv.Itoa(book.PageCount) + " pages"})
+	}
+	addField("365", " ", marcSubfield{Code: "b", Value: fmt.Sprintf("%.2f", book.Price)})
+	addField("520", " ", marcSubfield{Code: "a", Value: book.Description})
+	for _, genre := range book.Genres {
+		addField("650", " ", marcSubfield{Code: "a", Value: genre})
+	}
+	if book.EbookAvailable {
+		addField("655", " ", marcSubfield{Code: "a", Value: "Electronic books"})
+	}
+	if book.CoverImageUrl != "" {
+		addField("856", "4", marcSubfield{Code: "u", Value: book.CoverImageUrl}, marcSubfield{Code: "3", Value: "Cover image"})
+	}
+
+	return record
+}
@@ -0,0 +1,49 @@
+package formats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models/request"
+	"strings"
+)
+
+var _ interfaces.BookReader = &NdjsonBookReader{}
+
+// NdjsonBookReader reads one request.CreateBook JSON object per line.
+// Blank lines are skipped but still counted so row numbers match the file.
+type NdjsonBookReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+const maxNdjsonLineSize = 1024 * 1024
+
+func NewNdjsonBookReader(r io.Reader) interfaces.BookReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNdjsonLineSize)
+	return &NdjsonBookReader{scanner: scanner}
+}
+
+func (n *NdjsonBookReader) Read() (int, *request.CreateBook, error) {
+	for n.scanner.Scan() {
+		n.row++
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		book := &request.CreateBook{}
+		if err := json.Unmarshal([]byte(line), book); err != nil {
+			return n.row, nil, errors.New("invalid json: " + err.Error())
+		}
+		return n.row, book, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	return 0, nil, io.EOF
+}
@@ -0,0 +1,26 @@
+package formats
+
+import (
+	"encoding/json"
+	"io"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+)
+
+var _ interfaces.BookWriter = &NdjsonBookWriter{}
+
+type NdjsonBookWriter struct {
+	encoder *json.Encoder
+}
+
+func NewNdjsonBookWriter(w io.Writer) interfaces.BookWriter {
+	return &NdjsonBookWriter{encoder: json.NewEncoder(w)}
+}
+
+func (n *NdjsonBookWriter) Write(book models.Book) error {
+	return n.encoder.Encode(book)
+}
+
+func (n *NdjsonBookWriter) Close() error {
+	return nil
+}
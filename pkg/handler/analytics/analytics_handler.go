@@ -0,0 +1,117 @@
+package analytics_handler
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+	"time"
+)
+
+var _ interfaces.AnalyticsHandler = &AnalyticsHandler{}
+
+type AnalyticsHandler struct {
+	analyticsRepository interfaces.AnalyticsRepository
+}
+
+func NewAnalyticsHandler(analyticsRepository interfaces.AnalyticsRepository) interfaces.AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsRepository: analyticsRepository,
+	}
+}
+
+func (a *AnalyticsHandler) GetTopBooks(req request.GetAnalyticsTop) (*response.GetAnalyticsTop, error) {
+	return a.getTop(consts.AnalyticsDimensionBooks, req)
+}
+
+func (a *AnalyticsHandler) GetTopUsers(req request.GetAnalyticsTop) (*response.GetAnalyticsTop, error) {
+	return a.getTop(consts.AnalyticsDimensionUsers, req)
+}
+
+// GetTraffic returns the requests per route in every interval of the window.
+// Daily intervals start at midnight UTC.
+func (a *AnalyticsHandler) GetTraffic(req request.GetTraffic) (*response.GetTraffic, error) {
+	res := &response.GetTraffic{Window: req.Window, Interval: req.Interval, Buckets: make([]models.TrafficBucket, 0)}
+	if res.Window == "" {
+		res.Window = consts.AnalyticsWindowDay
+	}
+	if res.Interval == "" {
+		res.Interval = consts.AnalyticsWindowHour
+		if res.Window == consts.AnalyticsWindowWeek {
+			res.Interval = consts.AnalyticsWindowDay
+		}
+	}
+	res.From, res.To = windowRange(res.Window)
+
+	hourly, err := a.analyticsRepository.Traffic(res.From, res.To)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bucket := range hourly {
+		start := bucket.Start
+		if res.Interval == consts.AnalyticsWindowDay {
+			start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if n := len(res.Buckets); n == 0 || !res.Buckets[n-1].Start.Equal(start) {
+			res.Buckets = append(res.Buckets, models.TrafficBucket{Start: start, Routes: make([]models.AnalyticsCount, 0)})
+		}
+		res.Buckets[len(res.Buckets)-1] = addTraffic(res.Buckets[len(res.Buckets)-1], bucket)
+		res.Requests += bucket.Requests
+	}
+
+	return res, nil
+}
+
+func (a *AnalyticsHandler) getTop(dimension string, req request.GetAnalyticsTop) (*response.GetAnalyticsTop, error) {
+	res := &response.GetAnalyticsTop{Window: req.Window}
+	if res.Window == "" {
+		res.Window = consts.AnalyticsWindowDay
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = consts.AnalyticsTopSize
+	}
+	res.From, res.To = windowRange(res.Window)
+
+	top, err := a.analyticsRepository.Top(dimension, res.From, res.To, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Top = top
+	return res, nil
+}
+
+// windowRange is the window that ends now, widened to whole hours since that
+// is what the counters are kept in.
+func windowRange(window string) (time.Time, time.Time) {
+	to := time.Now().UTC()
+	length := time.Hour
+	switch window {
+	case consts.AnalyticsWindowDay:
+		length = 24 * time.Hour
+	case consts.AnalyticsWindowWeek:
+		length = 7 * 24 * time.Hour
+	}
+	return to.Add(-length).Truncate(time.Hour), to
+}
+
+func addTraffic(total models.TrafficBucket, bucket models.TrafficBucket) models.TrafficBucket {
+	routes := make(map[string]int)
+	for _, route := range total.Routes {
+		routes[route.Key] = route.Count
+	}
+	for _, route := range bucket.Routes {
+		routes[route.Key] += route.Count
+	}
+
+	total.Routes = make([]models.AnalyticsCount, 0, len(routes))
+	for key, count := range routes {
+		total.Routes = append(total.Routes, models.AnalyticsCount{Key: key, Count: count})
+	}
+	models.SortAnalyticsCounts(total.Routes)
+	total.Requests += bucket.Requests
+	return total
+}
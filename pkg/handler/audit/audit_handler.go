@@ -0,0 +1,57 @@
+package audit_handler
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+var _ interfaces.AuditHandler = &AuditHandler{}
+
+type AuditHandler struct {
+	auditRepository interfaces.AuditRepository
+}
+
+func NewAuditHandler(auditRepository interfaces.AuditRepository) interfaces.AuditHandler {
+	return &AuditHandler{
+		auditRepository: auditRepository,
+	}
+}
+
+// GetBookHistory returns the changes to a book, newest first. It also covers
+// books that are in the trash or were purged.
+func (a *AuditHandler) GetBookHistory(bookId string) (*response.GetAudit, error) {
+	entries, err := a.auditRepository.Find(models.AuditFilters{BookId: bookId, Limit: consts.AuditMaxQuerySize})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetAudit{Entries: entries}, nil
+}
+
+func (a *AuditHandler) GetAudit(req request.GetAudit) (*response.GetAudit, error) {
+	if !req.From.IsZero() && !req.To.IsZero() && req.From.After(req.To) {
+		return nil, &models.ValidationError{Message: "from must be before to"}
+	}
+
+	filters := models.AuditFilters{
+		BookId: req.BookId,
+		Actor:  req.Actor,
+		Action: req.Action,
+		From:   req.From,
+		To:     req.To,
+		Limit:  req.Limit,
+	}
+	if filters.Limit == 0 {
+		filters.Limit = consts.AuditQuerySize
+	}
+
+	entries, err := a.auditRepository.Find(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetAudit{Entries: entries}, nil
+}
@@ -0,0 +1,131 @@
+package authors_handler
+
+import (
+	"fmt"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+	"strings"
+)
+
+var _ interfaces.AuthorsHandler = &AuthorsHandler{}
+
+type AuthorsHandler struct {
+	authorsRepository interfaces.AuthorsRepository
+	booksRepository   interfaces.BooksRepository
+	authorRelinker    interfaces.AuthorRelinker
+}
+
+func NewAuthorsHandler(authorsRepository interfaces.AuthorsRepository, booksRepository interfaces.BooksRepository, authorRelinker interfaces.AuthorRelinker) interfaces.AuthorsHandler {
+	return &AuthorsHandler{
+		authorsRepository: authorsRepository,
+		booksRepository:   booksRepository,
+		authorRelinker:    authorRelinker,
+	}
+}
+
+func (a *AuthorsHandler) CreateAuthor(req request.CreateAuthor) (string, error) {
+	existing, err := a.authorsRepository.FindByName(req.Name)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "", &models.ConflictError{Message: "author already exists"}
+	}
+
+	authorSource := models.AuthorSource{
+		Name:    req.Name,
+		Aliases: mergeAliases(req.Name, req.Aliases),
+		Bio:     req.Bio,
+	}
+
+	return a.authorsRepository.Create(authorSource)
+}
+
+func (a *AuthorsHandler) GetAuthors(req request.GetAuthors) (*response.GetAuthors, error) {
+	authors, err := a.authorsRepository.Get(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetAuthors{Authors: *authors}, nil
+}
+
+func (a *AuthorsHandler) GetAuthorById(authorId string) (*response.GetAuthorById, error) {
+	author, err := a.authorsRepository.GetById(authorId)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := a.booksRepository.Get(models.BookFilters{AuthorId: authorId})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetAuthorById{Author: *author, Books: *books}, nil
+}
+
+// MergeAuthors folds the source authors into the target. The sources are only
+// deleted once every one of their books, including the ones in the trash,
+// points at the target.
+func (a *AuthorsHandler) MergeAuthors(authorId string, req request.MergeAuthors, info models.RequestInfo) (*response.GetAuthorById, error) {
+	target, err := a.authorsRepository.GetById(authorId)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := target.Aliases
+	seen := make(map[string]bool, len(req.SourceIds))
+	for _, sourceId := range req.SourceIds {
+		if sourceId == authorId {
+			return nil, &models.ValidationError{Message: "cannot merge an author into itself"}
+		}
+		if seen[sourceId] {
+			return nil, &models.ValidationError{Message: fmt.Sprintf("author %s is given more than once", sourceId)}
+		}
+		seen[sourceId] = true
+		source, err := a.authorsRepository.GetById(sourceId)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, source.Name)
+		aliases = append(aliases, source.Aliases...)
+	}
+
+	target.Aliases = mergeAliases(target.Name, aliases)
+	err = a.authorsRepository.Update(target.Id, models.AuthorSource{
+		Name:    target.Name,
+		Aliases: target.Aliases,
+		Bio:     target.Bio,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.authorRelinker.RelinkAuthors(req.SourceIds, *target, info); err != nil {
+		return nil, err
+	}
+
+	for _, sourceId := range req.SourceIds {
+		if err = a.authorsRepository.Delete(sourceId); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.GetAuthorById(target.Id)
+}
+
+func mergeAliases(name string, aliases []string) []string {
+	seen := map[string]bool{strings.ToLower(name): true}
+	merged := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		key := strings.ToLower(alias)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, alias)
+	}
+	return merged
+}
@@ -0,0 +1,112 @@
+package books_handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+type bookChange struct {
+	bookId string
+	before *models.Book
+	after  *models.Book
+}
+
+// bookEventTypes maps audit actions to the published events. Purged books
+// were announced as deleted when they went to the trash.
+var bookEventTypes = map[string]string{
+	consts.AuditActionCreate:  consts.EventBookCreated,
+	consts.AuditActionUpdate:  consts.EventBookUpdated,
+	consts.AuditActionRestore: consts.EventBookUpdated,
+	consts.AuditActionDelete:  consts.EventBookDeleted,
+}
+
+// audit records an entry per changed book and publishes the matching events.
+// The changes are already applied by then, so failing to write the trail or
+// publish the events is logged rather than returned.
+func (b *BooksHandler) audit(action string, info models.RequestInfo, changes ...bookChange) {
+	timestamp := time.Now().UTC()
+	entries := make([]models.AuditEntry, 0, len(changes))
+	events := make([]models.BookEvent, 0, len(changes))
+	eventType, hasEvent := bookEventTypes[action]
+	for _, change := range changes {
+		fieldChanges := models.DiffBooks(change.before, change.after)
+		if len(fieldChanges) == 0 {
+			continue
+		}
+		entries = append(entries, models.AuditEntry{
+			BookId:    change.bookId,
+			Action:    action,
+			Actor:     info.Username,
+			RequestId: info.RequestId,
+			Timestamp: timestamp,
+			Changes:   fieldChanges,
+		})
+		if hasEvent && change.after != nil {
+			// The version of the book after the change is not known here
+			book := *change.after
+			book.Version = nil
+			events = append(events, models.BookEvent{
+				Id:        newEventId(),
+				Type:      eventType,
+				BookId:    change.bookId,
+				Book:      &book,
+				Actor:     info.Username,
+				RequestId: info.RequestId,
+				Timestamp: timestamp,
+			})
+		}
+	}
+
+	if err := b.auditRepository.Save(entries); err != nil {
+		log.Printf("failed to save %d audit entries for %s: %s", len(entries), action, err.Error())
+	}
+	if err := b.eventPublisher.Publish(events); err != nil {
+		log.Printf("failed to publish %d %s events: %s", len(events), eventType, err.Error())
+	}
+}
+
+// notifyArrivals sends the notifications for newly created books. Like the
+// audit trail, it is best effort once the books are stored.
+func (b *BooksHandler) notifyArrivals(books ...models.Book) {
+	if len(books) == 0 {
+		return
+	}
+	if err := b.arrivalsNotifier.NotifyArrivals(books); err != nil {
+		log.Printf("failed to notify arrival of %d books: %s", len(books), err.Error())
+	}
+}
+
+// getBooksById fetches the current state of books about to change, keyed by
+// id. Books that are missing or in the trash are left out.
+func (b *BooksHandler) getBooksById(ids []string, deleted bool) (map[string]models.Book, error) {
+	books := make(map[string]models.Book)
+	if len(ids) == 0 {
+		return books, nil
+	}
+
+	found, err := b.booksRepository.Get(models.BookFilters{Ids: ids, Deleted: deleted})
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range *found {
+		books[book.Id] = book
+	}
+	return books, nil
+}
+
+func newEventId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func trashed(book models.Book, deletedBy string) models.Book {
+	deletedAt := time.Now().UTC()
+	book.DeletedAt = &deletedAt
+	book.DeletedBy = deletedBy
+	return book
+}
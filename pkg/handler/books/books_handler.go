@@ -1,62 +1,91 @@
 package books_handler
 
 import (
-	"errors"
+	"fmt"
+	"pkg/service/pkg/consts"
 	"pkg/service/pkg/interfaces"
 	"pkg/service/pkg/models"
 	"pkg/service/pkg/models/request"
 	"pkg/service/pkg/models/response"
+	"strings"
+	"time"
 )
 
 var _ interfaces.BooksHandler = &BooksHandler{}
 
 type BooksHandler struct {
-	booksRepository interfaces.BooksRepository
+	booksRepository   interfaces.BooksRepository
+	copiesRepository  interfaces.CopiesRepository
+	authorsRepository interfaces.AuthorsRepository
+	importsRepository interfaces.ImportsRepository
+	auditRepository   interfaces.AuditRepository
+	arrivalsNotifier  interfaces.ArrivalsNotifier
+	eventPublisher    interfaces.EventPublisher
 }
 
-func NewBooksHandler(booksRepository interfaces.BooksRepository) interfaces.BooksHandler {
+func NewBooksHandler(booksRepository interfaces.BooksRepository, copiesRepository interfaces.CopiesRepository, authorsRepository interfaces.AuthorsRepository, importsRepository interfaces.ImportsRepository, auditRepository interfaces.AuditRepository, arrivalsNotifier interfaces.ArrivalsNotifier, eventPublisher interfaces.EventPublisher) interfaces.BooksHandler {
 	return &BooksHandler{
-		booksRepository: booksRepository,
+		booksRepository:   booksRepository,
+		copiesRepository:  copiesRepository,
+		authorsRepository: authorsRepository,
+		importsRepository: importsRepository,
+		auditRepository:   auditRepository,
+		arrivalsNotifier:  arrivalsNotifier,
+		eventPublisher:    eventPublisher,
 	}
 }
 
-func (b *BooksHandler) CreateBook(req request.CreateBook) (string, error) {
-	bookSource := models.BookSource{
-		Title:          req.Title,
-		AuthorName:     req.AuthorName,
-		Price:          req.Price,
-		EbookAvailable: req.EbookAvailable,
-		PublishDate:    req.PublishDate,
+func (b *BooksHandler) CreateBook(req request.CreateBook, allowDuplicate bool, info models.RequestInfo) (string, error) {
+	authors, err := b.findAuthors(req.AuthorNames, nil)
+	if err != nil {
+		return "", err
+	}
+
+	bookSource, err := newBookSource(req, authors)
+	if err != nil {
+		return "", err
+	}
+
+	duplicateIds, err := b.booksRepository.FindDuplicates([]models.BookSource{bookSource})
+	if err != nil {
+		return "", err
+	}
+	if err = duplicateError(bookSource, duplicateIds[0], allowDuplicate); err != nil {
+		return "", err
+	}
+
+	if err = b.createAuthors(authors, nil); err != nil {
+		return "", err
 	}
+	bookSource = withAuthors(bookSource, authors)
 
 	bookId, err := b.booksRepository.Create(bookSource)
 	if err != nil {
 		return "", err
 	}
 
+	created := models.NewBook(bookId, bookSource)
+	b.audit(consts.AuditActionCreate, info, bookChange{bookId: bookId, after: &created})
+	b.notifyArrivals(created)
 	return bookId, nil
 }
 
 func (b *BooksHandler) GetBooks(req request.GetBooks) (*response.GetBooks, error) {
-	filters := models.BookFilters{
-		Title:      req.Title,
-		AuthorName: req.AuthorName,
+	filters, err := b.newBookFilters(req)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.MinPrice != nil {
-		if *req.MinPrice <= 0 {
-			return nil, errors.New("min price must be greater than 0")
+	if req.Facets != "" {
+		facets, err := parseFacets(req.Facets)
+		if err != nil {
+			return nil, err
 		}
-		filters.MinPrice = *req.MinPrice
-	}
-	if req.MaxPrice != nil {
-		if *req.MaxPrice <= 0 {
-			return nil, errors.New("max price must be greater than 0")
+		res, err := b.booksRepository.GetFaceted(filters, facets)
+		if err != nil {
+			return nil, err
 		}
-		filters.MaxPrice = *req.MaxPrice
-	}
-	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
-		return nil, errors.New("min price must be less than or equal to max price")
+		return &response.GetBooks{Books: res.Books, Facets: res.Facets}, nil
 	}
 
 	books, err := b.booksRepository.Get(filters)
@@ -67,6 +96,45 @@ func (b *BooksHandler) GetBooks(req request.GetBooks) (*response.GetBooks, error
 	return &response.GetBooks{Books: *books}, nil
 }
 
+func (b *BooksHandler) ExportBooks(req request.GetBooks, writer interfaces.BookWriter) error {
+	filters, err := b.newBookFilters(req)
+	if err != nil {
+		return err
+	}
+
+	if err = b.booksRepository.Scroll(filters, writer.Write); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// SuggestBooks completes a prefix typed in a search box with titles and author
+// names, both weighted 1 unless the request says otherwise.
+func (b *BooksHandler) SuggestBooks(req request.SuggestBooks) (*response.SuggestBooks, error) {
+	weights := models.SuggestWeights{Title: 1, Author: 1}
+	if req.TitleWeight != nil {
+		weights.Title = *req.TitleWeight
+	}
+	if req.AuthorWeight != nil {
+		weights.Author = *req.AuthorWeight
+	}
+	if weights.Title == 0 && weights.Author == 0 {
+		return nil, &models.ValidationError{Message: "title weight or author weight must be greater than 0"}
+	}
+	size := req.Size
+	if size == 0 {
+		size = consts.SuggestSize
+	}
+
+	suggestions, err := b.booksRepository.Suggest(req.Prefix, weights, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.SuggestBooks{Suggestions: suggestions}, nil
+}
+
 func (b *BooksHandler) GetBookById(bookId string) (*response.GetBookById, error) {
 	book, err := b.booksRepository.GetById(bookId)
 	if err != nil {
@@ -76,22 +144,379 @@ func (b *BooksHandler) GetBookById(bookId string) (*response.GetBookById, error)
 	return &response.GetBookById{Book: *book}, nil
 }
 
-func (b *BooksHandler) UpdateBookTitle(bookId string, req request.UpdateBookTitle) error {
-	return b.booksRepository.UpdateTitle(bookId, req.Title)
+// UpdateBookTitle only applies the update when the book is still at the given
+// version. A nil version updates whatever the current version is.
+func (b *BooksHandler) UpdateBookTitle(bookId string, version *models.BookVersion, req request.UpdateBookTitle, info models.RequestInfo) (*models.BookVersion, error) {
+	before, err := b.booksRepository.GetById(bookId)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := b.booksRepository.UpdateTitle(bookId, req.Title, version)
+	if err != nil {
+		return nil, err
+	}
+
+	after := *before
+	after.Title = req.Title
+	b.audit(consts.AuditActionUpdate, info, bookChange{bookId: bookId, before: before, after: &after})
+	return updated, nil
+}
+
+// DeleteBook moves the book to the trash, from where it can be restored until
+// it is purged.
+func (b *BooksHandler) DeleteBook(bookId string, version *models.BookVersion, info models.RequestInfo) error {
+	before, err := b.booksRepository.GetById(bookId)
+	if err != nil {
+		return err
+	}
+
+	if err = b.booksRepository.Delete(bookId, version, info.Username); err != nil {
+		return err
+	}
+
+	after := trashed(*before, info.Username)
+	b.audit(consts.AuditActionDelete, info, bookChange{bookId: bookId, before: before, after: &after})
+	return nil
+}
+
+func (b *BooksHandler) GetTrash() (*response.GetBooks, error) {
+	books, err := b.booksRepository.Get(models.BookFilters{Deleted: true, Sort: "-deleted_at"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetBooks{Books: *books}, nil
+}
+
+// RestoreBook takes a book out of the trash, unless a book with the same
+// ISBN, or one that looks the same, was created while it was there.
+func (b *BooksHandler) RestoreBook(bookId string, allowDuplicate bool, info models.RequestInfo) error {
+	trash, err := b.getBooksById([]string{bookId}, true)
+	if err != nil {
+		return err
+	}
+
+	if book, found := trash[bookId]; found {
+		bookSource := models.BookSource{
+			Isbn13:   book.Isbn13,
+			DedupKey: models.DedupKey(book.Title, book.AuthorIds, book.PublishDate.String()),
+		}
+		duplicateIds, err := b.booksRepository.FindDuplicates([]models.BookSource{bookSource})
+		if err != nil {
+			return err
+		}
+		if err = duplicateError(bookSource, duplicateIds[0], allowDuplicate); err != nil {
+			return err
+		}
+	}
+
+	if err = b.booksRepository.Restore(bookId); err != nil {
+		return err
+	}
+
+	if before, found := trash[bookId]; found {
+		after := before
+		after.DeletedAt = nil
+		after.DeletedBy = ""
+		b.audit(consts.AuditActionRestore, info, bookChange{bookId: bookId, before: &before, after: &after})
+	}
+	return nil
+}
+
+func (b *BooksHandler) PurgeBook(bookId string, info models.RequestInfo) error {
+	trash, err := b.getBooksById([]string{bookId}, true)
+	if err != nil {
+		return err
+	}
+
+	if err = b.booksRepository.Purge(bookId); err != nil {
+		return err
+	}
+
+	if before, found := trash[bookId]; found {
+		b.audit(consts.AuditActionPurge, info, bookChange{bookId: bookId, before: &before})
+	}
+	return nil
 }
 
-func (b *BooksHandler) DeleteBook(bookId string) error {
-	return b.booksRepository.Delete(bookId)
+// PurgeTrash permanently deletes the books that were moved to the trash more
+// than olderThan ago.
+func (b *BooksHandler) PurgeTrash(olderThan time.Duration) (int, error) {
+	filters := models.BookFilters{Deleted: true, DeletedBefore: time.Now().Add(-olderThan)}
+
+	changes := make([]bookChange, 0)
+	err := b.booksRepository.Scroll(filters, func(book models.Book) error {
+		changes = append(changes, bookChange{bookId: book.Id, before: &book})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := b.booksRepository.PurgeDeleted(filters.DeletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	b.audit(consts.AuditActionPurge, models.RequestInfo{Username: consts.SystemActor}, changes...)
+	return purged, nil
 }
 
-func (b *BooksHandler) GetStoreInventory() (*response.GetBooksInventory, error) {
-	res, err := b.booksRepository.GetStoreInventory()
+func (b *BooksHandler) GetStoreInventory(req request.GetStoreInventory) (*response.GetBooksInventory, error) {
+	filters, err := b.newBookFilters(req.GetBooks)
+	if err != nil {
+		return nil, err
+	}
+	topAuthors := req.TopAuthors
+	if topAuthors == 0 {
+		topAuthors = consts.InventoryTopAuthors
+	}
+
+	res, err := b.booksRepository.GetStoreInventory(filters, topAuthors)
 	if err != nil {
 		return nil, err
 	}
 
 	return &response.GetBooksInventory{
-		Books:   res.TotalBooks,
-		Authors: res.UniqueAuthors,
+		Books:            res.TotalBooks,
+		Authors:          res.UniqueAuthors,
+		Ebooks:           res.EbookBooks,
+		PrintOnly:        res.PrintOnlyBooks,
+		TotalValue:       res.TotalValue,
+		Price:            res.Price,
+		TopAuthors:       res.TopAuthors,
+		PublicationYears: res.PublicationYears,
 	}, nil
 }
+
+// findAuthors maps author names to author entities. Names without an author
+// yet come back without an id, and are only created by createAuthors once
+// the book is known to be stored, so rejected books leave no authors behind.
+// The cache lets bulk callers avoid looking up a name twice.
+func (b *BooksHandler) findAuthors(names []string, cache map[string]models.Author) ([]models.Author, error) {
+	if cache == nil {
+		cache = make(map[string]models.Author)
+	}
+
+	authors := make([]models.Author, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		author, found := cache[name]
+		if !found {
+			existing, err := b.authorsRepository.FindByName(name)
+			if err != nil {
+				return nil, err
+			}
+			if existing == nil {
+				author = models.Author{Name: name}
+			} else {
+				author = *existing
+				cache[name] = author
+			}
+		}
+		key := author.Id
+		if key == "" {
+			key = newAuthorKey(name)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
+
+// createAuthors creates the authors findAuthors did not find, filling in
+// their ids. Authors created for an earlier book of the request are reused.
+func (b *BooksHandler) createAuthors(authors []models.Author, cache map[string]models.Author) error {
+	if cache == nil {
+		cache = make(map[string]models.Author)
+	}
+
+	for i := range authors {
+		if authors[i].Id != "" {
+			continue
+		}
+		if author, found := cache[authors[i].Name]; found {
+			authors[i] = author
+			continue
+		}
+		authorId, err := b.authorsRepository.Create(models.AuthorSource{Name: authors[i].Name, Aliases: []string{}})
+		if err != nil {
+			return err
+		}
+		authors[i].Id = authorId
+		cache[authors[i].Name] = authors[i]
+	}
+	return nil
+}
+
+// newAuthorKey stands in for the id of an author that does not exist yet. It
+// cannot collide with a stored author id, so books by new authors are only
+// duplicates of each other.
+func newAuthorKey(name string) string {
+	return "new:" + strings.ToLower(name)
+}
+
+func authorIds(authors []models.Author) []string {
+	ids := make([]string, 0, len(authors))
+	for _, author := range authors {
+		if author.Id == "" {
+			ids = append(ids, newAuthorKey(author.Name))
+			continue
+		}
+		ids = append(ids, author.Id)
+	}
+	return ids
+}
+
+func authorNames(authors []models.Author) []string {
+	names := make([]string, 0, len(authors))
+	for _, author := range authors {
+		names = append(names, author.Name)
+	}
+	return names
+}
+
+// newBookSource builds the stored book, with its ISBNs normalized and the
+// missing form filled in. Authors that do not exist yet are left as
+// placeholders until withAuthors is called again after createAuthors.
+func newBookSource(req request.CreateBook, authors []models.Author) (models.BookSource, error) {
+	if err := req.PublishDate.Validate(); err != nil {
+		return models.BookSource{}, &models.ValidationError{Message: err.Error()}
+	}
+	isbn10, isbn13, err := models.CompleteIsbns(models.NormalizeIsbn(req.Isbn10), models.NormalizeIsbn(req.Isbn13))
+	if err != nil {
+		return models.BookSource{}, err
+	}
+	bookSource := models.BookSource{
+		Title:          req.Title,
+		Price:          req.Price,
+		EbookAvailable: req.EbookAvailable,
+		PublishDate:    *req.PublishDate,
+		Isbn10:         isbn10,
+		Isbn13:         isbn13,
+		Publisher:      req.Publisher,
+		Genres:         req.Genres,
+		Language:       req.Language,
+		PageCount:      req.PageCount,
+		Edition:        req.Edition,
+		Description:    req.Description,
+		CoverImageUrl:  req.CoverImageUrl,
+	}
+	return withAuthors(bookSource, authors), nil
+}
+
+func withAuthors(bookSource models.BookSource, authors []models.Author) models.BookSource {
+	bookSource.AuthorIds = authorIds(authors)
+	bookSource.AuthorNames = authorNames(authors)
+	bookSource.DedupKey = models.DedupKey(bookSource.Title, bookSource.AuthorIds, bookSource.PublishDate.String())
+	return bookSource
+}
+
+func duplicateError(bookSource models.BookSource, duplicateId string, allowDuplicate bool) error {
+	if duplicateId == "" {
+		return nil
+	}
+	if bookSource.Isbn13 != "" {
+		return &models.DuplicateBookError{ExistingId: duplicateId, Field: "isbn"}
+	}
+	if !allowDuplicate {
+		return &models.DuplicateBookError{ExistingId: duplicateId, Field: "title, authors and publish date"}
+	}
+	return nil
+}
+
+func (b *BooksHandler) newBookFilters(req request.GetBooks) (models.BookFilters, error) {
+	filters := models.BookFilters{
+		Query:      req.Query,
+		Title:      req.Title,
+		AuthorId:   req.AuthorId,
+		AuthorName: req.AuthorName,
+		Isbn:       models.NormalizeIsbn(req.Isbn),
+		Publisher:  req.Publisher,
+		Genre:      req.Genre,
+		Language:   req.Language,
+		Ebook:      req.Ebook,
+		Sort:       req.Sort,
+	}
+
+	if req.MinPrice != nil {
+		if *req.MinPrice <= 0 {
+			return filters, &models.ValidationError{Message: "min price must be greater than 0"}
+		}
+		filters.MinPrice = *req.MinPrice
+	}
+	if req.MaxPrice != nil {
+		if *req.MaxPrice <= 0 {
+			return filters, &models.ValidationError{Message: "max price must be greater than 0"}
+		}
+		filters.MaxPrice = *req.MaxPrice
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return filters, &models.ValidationError{Message: "min price must be less than or equal to max price"}
+	}
+	if req.MinPages != nil {
+		if *req.MinPages <= 0 {
+			return filters, &models.ValidationError{Message: "min pages must be greater than 0"}
+		}
+		filters.MinPages = *req.MinPages
+	}
+	if req.MaxPages != nil {
+		if *req.MaxPages <= 0 {
+			return filters, &models.ValidationError{Message: "max pages must be greater than 0"}
+		}
+		filters.MaxPages = *req.MaxPages
+	}
+	if req.MinPages != nil && req.MaxPages != nil && *req.MinPages > *req.MaxPages {
+		return filters, &models.ValidationError{Message: "min pages must be less than or equal to max pages"}
+	}
+	if req.PublishedAfter != "" {
+		publishedAfter, err := models.ParsePublishDate(req.PublishedAfter)
+		if err != nil {
+			return filters, &models.ValidationError{Message: "published after: " + err.Error()}
+		}
+		filters.PublishedAfter = publishedAfter.Start()
+	}
+	if req.PublishedBefore != "" {
+		publishedBefore, err := models.ParsePublishDate(req.PublishedBefore)
+		if err != nil {
+			return filters, &models.ValidationError{Message: "published before: " + err.Error()}
+		}
+		filters.PublishedBefore = publishedBefore.End()
+	}
+	if !filters.PublishedAfter.IsZero() && !filters.PublishedBefore.IsZero() && filters.PublishedAfter.After(filters.PublishedBefore) {
+		return filters, &models.ValidationError{Message: "published after must be before published before"}
+	}
+	if req.Decade != nil {
+		if *req.Decade <= 0 || *req.Decade%10 != 0 {
+			return filters, &models.ValidationError{Message: "decade must be a year ending in 0"}
+		}
+		filters.Decade = *req.Decade
+	}
+	if req.BranchId != "" {
+		bookIds, err := b.copiesRepository.GetAvailableBookIds(req.BranchId)
+		if err != nil {
+			return filters, err
+		}
+		filters.Ids = bookIds
+	}
+
+	return filters, nil
+}
+
+func parseFacets(value string) ([]string, error) {
+	facets := make([]string, 0)
+	for _, facet := range strings.Split(value, ",") {
+		facet = strings.TrimSpace(facet)
+		switch facet {
+		case consts.FacetAuthors, consts.FacetPrice, consts.FacetEbook, consts.FacetDecade:
+			facets = append(facets, facet)
+		default:
+			return nil, &models.ValidationError{Message: fmt.Sprintf("unknown facet %q", facet)}
+		}
+	}
+	return facets, nil
+}
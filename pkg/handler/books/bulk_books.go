@@ -0,0 +1,303 @@
+package books_handler
+
+import (
+	"fmt"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+// BulkBooks runs the operations in a single bulk request. Creates are checked
+// for duplicates first, of existing books and of earlier creates in the same
+// request, and the ones rejected never reach the repository, but still get
+// an item in the response so items line up with operations.
+// Updates and deletes only apply to books outside the trash, answering 404 for
+// the rest, and are conditioned on the version the book was read at, so they
+// fail with a conflict when it changed in between and are audited otherwise.
+// Deletes move books to the trash on behalf of the requesting user.
+func (b *BooksHandler) BulkBooks(req request.BulkBooks, allowDuplicate bool, info models.RequestInfo) (*response.BulkBooks, error) {
+	res := &response.BulkBooks{Items: make([]response.BulkBookItem, len(req.Operations))}
+	operations := make([]models.BulkOperation, 0, len(req.Operations))
+	positions := make([]int, 0, len(req.Operations))
+	authorsCache := make(map[string]models.Author)
+
+	changedIds := make([]string, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		if op.Op != consts.BulkActionCreate {
+			changedIds = append(changedIds, op.Id)
+		}
+	}
+	before, err := b.getBooksById(changedIds, false)
+	if err != nil {
+		return nil, err
+	}
+
+	createPositions := make([]int, 0)
+	createSources := make([]models.BookSource, 0)
+	createAuthors := make([][]models.Author, 0)
+	for i, op := range req.Operations {
+		res.Items[i] = response.BulkBookItem{Op: op.Op, Id: op.Id}
+		book, found := before[op.Id]
+		if op.Op != consts.BulkActionCreate && !found {
+			res.Items[i].Status = http.StatusNotFound
+			res.Items[i].Error = (&models.NotFoundError{Resource: "book", Id: op.Id}).Error()
+			res.Errors = true
+			continue
+		}
+		switch op.Op {
+		case consts.BulkActionCreate:
+			authors, err := b.findAuthors(op.Book.AuthorNames, authorsCache)
+			if err != nil {
+				return nil, err
+			}
+			bookSource, err := newBookSource(*op.Book, authors)
+			if err != nil {
+				return nil, err
+			}
+			createPositions = append(createPositions, i)
+			createSources = append(createSources, bookSource)
+			createAuthors = append(createAuthors, authors)
+		case consts.BulkActionUpdate:
+			positions = append(positions, i)
+			operations = append(operations, models.BulkOperation{Action: op.Op, Id: op.Id, Fields: bookUpdateFields(*op.Fields), Version: book.Version})
+		case consts.BulkActionDelete:
+			positions = append(positions, i)
+			operations = append(operations, models.BulkOperation{Action: op.Op, Id: op.Id, DeletedBy: info.Username, Version: book.Version})
+		}
+	}
+
+	if len(createSources) > 0 {
+		duplicateIds, err := b.booksRepository.FindDuplicates(createSources)
+		if err != nil {
+			return nil, err
+		}
+		requestKeys := make(map[string]int)
+		for j, i := range createPositions {
+			if err = duplicateError(createSources[j], duplicateIds[j], allowDuplicate); err != nil {
+				res.Items[i].Status = http.StatusConflict
+				res.Items[i].Error = err.Error()
+				res.Items[i].ExistingId = duplicateIds[j]
+				res.Errors = true
+				continue
+			}
+			key := createSources[j].DedupKey
+			if createSources[j].Isbn13 != "" {
+				key = createSources[j].Isbn13
+			}
+			if first, found := requestKeys[key]; found && (createSources[j].Isbn13 != "" || !allowDuplicate) {
+				res.Items[i].Status = http.StatusConflict
+				res.Items[i].Error = fmt.Sprintf("duplicate of operation %d", first)
+				res.Errors = true
+				continue
+			}
+			requestKeys[key] = i
+			if err = b.createAuthors(createAuthors[j], authorsCache); err != nil {
+				return nil, err
+			}
+			createSources[j] = withAuthors(createSources[j], createAuthors[j])
+			positions = append(positions, i)
+			operations = append(operations, models.BulkOperation{Action: consts.BulkActionCreate, Book: &createSources[j]})
+		}
+	}
+
+	if len(operations) == 0 {
+		return res, nil
+	}
+
+	results, err := b.booksRepository.Bulk(operations)
+	if err != nil {
+		return nil, err
+	}
+	b.auditBulk(operations, results, before, info)
+
+	for j, result := range results {
+		item := &res.Items[positions[j]]
+		item.Id = result.Id
+		item.Status = result.Status
+		item.Error = result.Error
+		if operations[j].Action == consts.BulkActionCreate && result.Status == http.StatusConflict {
+			// Another request created a book with the same ISBN in the meantime
+			item.Error = (&models.DuplicateBookError{ExistingId: result.Id, Field: "isbn", Trashed: result.Trashed}).Error()
+			item.ExistingId = result.Id
+		}
+		if result.Status >= http.StatusBadRequest {
+			res.Errors = true
+		}
+	}
+
+	return res, nil
+}
+
+// BulkBooksByQuery applies the update or delete to every book matching the
+// filters. Matching books are read and rewritten in batches, each write
+// conditioned on the version the book was read at, so only the books that
+// were actually changed are audited. Books changed by someone else in between
+// are skipped.
+func (b *BooksHandler) BulkBooksByQuery(filtersReq request.GetBooks, req request.BulkBooksByQuery, info models.RequestInfo) (*response.BulkBooksByQuery, error) {
+	filters, err := b.newBookFilters(filtersReq)
+	if err != nil {
+		return nil, err
+	}
+	if filters.IsEmpty() {
+		return nil, &models.ValidationError{Message: "at least one filter is required"}
+	}
+
+	var fields map[string]interface{}
+	if req.Action == consts.BulkActionUpdate {
+		fields = bookUpdateFields(*req.Fields)
+		if len(fields) == 0 {
+			return nil, &models.ValidationError{Message: "at least one field to update is required"}
+		}
+	}
+
+	matched, err := b.booksRepository.Count(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.BulkBooksByQuery{Action: req.Action, DryRun: req.DryRun, Matched: matched}
+	if req.DryRun || matched == 0 {
+		return res, nil
+	}
+
+	batch := make([]models.Book, 0, consts.BooksScrollSize)
+	flush := func() error {
+		err := b.bulkBatchByQuery(batch, req.Action, fields, info, res)
+		batch = batch[:0]
+		return err
+	}
+	err = b.booksRepository.Scroll(filters, func(book models.Book) error {
+		batch = append(batch, book)
+		if len(batch) == cap(batch) {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (b *BooksHandler) bulkBatchByQuery(books []models.Book, action string, fields map[string]interface{}, info models.RequestInfo, res *response.BulkBooksByQuery) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	operations := make([]models.BulkOperation, 0, len(books))
+	before := make(map[string]models.Book, len(books))
+	for _, book := range books {
+		operation := models.BulkOperation{Action: action, Id: book.Id, Version: book.Version}
+		if action == consts.BulkActionUpdate {
+			operation.Fields = fields
+		} else {
+			operation.DeletedBy = info.Username
+		}
+		operations = append(operations, operation)
+		before[book.Id] = book
+	}
+
+	results, err := b.booksRepository.Bulk(operations)
+	if err != nil {
+		return err
+	}
+	b.auditBulk(operations, results, before, info)
+
+	for _, result := range results {
+		switch {
+		case result.Status < http.StatusBadRequest:
+			res.Affected++
+		case result.Status == http.StatusConflict:
+			res.Skipped++
+		default:
+			res.Failed++
+		}
+	}
+	return nil
+}
+
+// auditBulk records the bulk operations that succeeded, grouped by action,
+// and tells users about the books that were created.
+func (b *BooksHandler) auditBulk(operations []models.BulkOperation, results []models.BulkItemResult, before map[string]models.Book, info models.RequestInfo) {
+	changes := make(map[string][]bookChange)
+	for j, result := range results {
+		if result.Status >= http.StatusBadRequest {
+			continue
+		}
+
+		operation := operations[j]
+		change := bookChange{bookId: result.Id}
+		if operation.Action == consts.BulkActionCreate {
+			after := models.NewBook(result.Id, *operation.Book)
+			change.after = &after
+		} else {
+			existing, found := before[result.Id]
+			if !found {
+				continue
+			}
+			after, err := bulkResult(operation.Action, existing, operation.Fields, info.Username)
+			if err != nil {
+				continue
+			}
+			change.before = &existing
+			change.after = &after
+		}
+		changes[operation.Action] = append(changes[operation.Action], change)
+	}
+
+	for action, actionChanges := range changes {
+		b.audit(action, info, actionChanges...)
+	}
+
+	created := make([]models.Book, 0, len(changes[consts.BulkActionCreate]))
+	for _, change := range changes[consts.BulkActionCreate] {
+		created = append(created, *change.after)
+	}
+	b.notifyArrivals(created...)
+}
+
+// bulkResult works out the state of a book after a bulk update or delete.
+func bulkResult(action string, book models.Book, fields map[string]interface{}, username string) (models.Book, error) {
+	if action == consts.BulkActionDelete {
+		return trashed(book, username), nil
+	}
+	return book.WithFields(fields)
+}
+
+func bookUpdateFields(req request.UpdateBookFields) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if req.Price != nil {
+		fields["price"] = *req.Price
+	}
+	if req.EbookAvailable != nil {
+		fields["ebook_available"] = *req.EbookAvailable
+	}
+	if req.Publisher != nil {
+		fields["publisher"] = *req.Publisher
+	}
+	if req.Genres != nil {
+		fields["genres"] = req.Genres
+	}
+	if req.Language != nil {
+		fields["language"] = *req.Language
+	}
+	if req.PageCount != nil {
+		fields["page_count"] = *req.PageCount
+	}
+	if req.Edition != nil {
+		fields["edition"] = *req.Edition
+	}
+	if req.Description != nil {
+		fields["description"] = *req.Description
+	}
+	if req.CoverImageUrl != nil {
+		fields["cover_image_url"] = *req.CoverImageUrl
+	}
+	return fields
+}
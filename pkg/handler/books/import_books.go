@@ -0,0 +1,211 @@
+package books_handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+	"sort"
+)
+
+type importRow struct {
+	row        int
+	bookSource models.BookSource
+	authors    []models.Author
+}
+
+// ImportBooks validates every record with the same rules as CreateBook and
+// writes them in bulk batches. After each batch the last handled row is
+// checkpointed under the import id, so calling it again with the same id
+// after a crash continues where the previous run stopped. Imported books get
+// ids derived from their ISBN, or from the import id and row when they have
+// none, which turns a batch that was written but not checkpointed into
+// duplicates instead of new books.
+func (b *BooksHandler) ImportBooks(reader interfaces.BookReader, req request.ImportBooks, info models.RequestInfo) (*response.ImportBooks, error) {
+	importId := req.ImportId
+	if importId == "" {
+		return nil, &models.ValidationError{Message: "import id is required"}
+	}
+
+	checkpoint, err := b.importsRepository.GetCheckpoint(importId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.ImportBooks{
+		ImportId:       importId,
+		ResumedFromRow: checkpoint,
+		Rows:           make([]response.ImportRowResult, 0),
+	}
+	authorsCache := make(map[string]models.Author)
+	batch := make([]importRow, 0, consts.ImportBatchSize)
+	lastRow := checkpoint
+
+	for {
+		row, book, err := reader.Read()
+		if row == 0 {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if row <= checkpoint {
+			continue
+		}
+		lastRow = row
+
+		if err == nil {
+			err = binding.Validator.ValidateStruct(book)
+		}
+		var authors []models.Author
+		if err == nil {
+			authors, err = b.findAuthors(book.AuthorNames, authorsCache)
+		}
+		var bookSource models.BookSource
+		if err == nil {
+			bookSource, err = newBookSource(*book, authors)
+		}
+		if err != nil {
+			addImportRow(res, response.ImportRowResult{Row: row, Status: consts.ImportStatusFailed, Reason: err.Error()})
+			continue
+		}
+
+		batch = append(batch, importRow{row: row, bookSource: bookSource, authors: authors})
+		if len(batch) == consts.ImportBatchSize {
+			if err = b.flushImportBatch(importId, batch, lastRow, req.AllowDuplicate, authorsCache, info, res); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err = b.flushImportBatch(importId, batch, lastRow, req.AllowDuplicate, authorsCache, info, res); err != nil {
+		return nil, err
+	}
+	if err = b.importsRepository.DeleteCheckpoint(importId); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res.Rows, func(i, j int) bool { return res.Rows[i].Row < res.Rows[j].Row })
+	return res, nil
+}
+
+func (b *BooksHandler) flushImportBatch(importId string, batch []importRow, lastRow int, allowDuplicate bool, authorsCache map[string]models.Author, info models.RequestInfo, res *response.ImportBooks) error {
+	if len(batch) > 0 {
+		pending := make([]importRow, 0, len(batch))
+		batchRows := make(map[string]int)
+		for _, item := range batch {
+			key := item.bookSource.DedupKey
+			if item.bookSource.Isbn13 != "" {
+				key = item.bookSource.Isbn13
+			}
+			if firstRow, found := batchRows[key]; found && (item.bookSource.Isbn13 != "" || !allowDuplicate) {
+				addImportRow(res, response.ImportRowResult{
+					Row:    item.row,
+					Status: consts.ImportStatusSkipped,
+					Reason: fmt.Sprintf("duplicate of row %d", firstRow),
+				})
+				continue
+			}
+			batchRows[key] = item.row
+			pending = append(pending, item)
+		}
+
+		if err := b.createImportRows(importId, pending, allowDuplicate, authorsCache, info, res); err != nil {
+			return err
+		}
+	}
+
+	return b.importsRepository.SaveCheckpoint(importId, lastRow)
+}
+
+func (b *BooksHandler) createImportRows(importId string, pending []importRow, allowDuplicate bool, authorsCache map[string]models.Author, info models.RequestInfo, res *response.ImportBooks) error {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	bookSources := make([]models.BookSource, 0, len(pending))
+	for _, item := range pending {
+		bookSources = append(bookSources, item.bookSource)
+	}
+	duplicateIds, err := b.booksRepository.FindDuplicates(bookSources)
+	if err != nil {
+		return err
+	}
+
+	toCreate := make([]importRow, 0, len(pending))
+	for i, item := range pending {
+		if err = duplicateError(item.bookSource, duplicateIds[i], allowDuplicate); err != nil {
+			addImportRow(res, response.ImportRowResult{
+				Row:        item.row,
+				Status:     consts.ImportStatusSkipped,
+				ExistingId: duplicateIds[i],
+				Reason:     err.Error(),
+			})
+			continue
+		}
+		if err = b.createAuthors(item.authors, authorsCache); err != nil {
+			return err
+		}
+		item.bookSource = withAuthors(item.bookSource, item.authors)
+		toCreate = append(toCreate, item)
+	}
+	if len(toCreate) == 0 {
+		return nil
+	}
+
+	operations := make([]models.BulkOperation, 0, len(toCreate))
+	for i := range toCreate {
+		operation := models.BulkOperation{Action: consts.BulkActionCreate, Book: &toCreate[i].bookSource}
+		if toCreate[i].bookSource.Isbn13 == "" {
+			operation.Id = fmt.Sprintf("%s-%d", importId, toCreate[i].row)
+		}
+		operations = append(operations, operation)
+	}
+
+	results, err := b.booksRepository.Bulk(operations)
+	if err != nil {
+		return err
+	}
+	b.auditBulk(operations, results, nil, info)
+
+	for i, result := range results {
+		rowResult := response.ImportRowResult{Row: toCreate[i].row}
+		switch {
+		case result.Status == http.StatusCreated:
+			rowResult.Status = consts.ImportStatusCreated
+			rowResult.Id = result.Id
+		case result.Status == http.StatusConflict:
+			rowResult.Status = consts.ImportStatusSkipped
+			rowResult.ExistingId = result.Id
+			rowResult.Reason = "already imported"
+			if toCreate[i].bookSource.Isbn13 != "" {
+				rowResult.Reason = (&models.DuplicateBookError{ExistingId: result.Id, Field: "isbn", Trashed: result.Trashed}).Error()
+			}
+		default:
+			rowResult.Status = consts.ImportStatusFailed
+			rowResult.Reason = result.Error
+		}
+		addImportRow(res, rowResult)
+	}
+
+	return nil
+}
+
+func addImportRow(res *response.ImportBooks, rowResult response.ImportRowResult) {
+	switch rowResult.Status {
+	case consts.ImportStatusCreated:
+		res.Created++
+	case consts.ImportStatusSkipped:
+		res.Skipped++
+	default:
+		res.Failed++
+	}
+	res.Rows = append(res.Rows, rowResult)
+}
@@ -0,0 +1,99 @@
+package books_handler
+
+import (
+	"fmt"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+)
+
+var _ interfaces.AuthorRelinker = &BooksHandler{}
+
+// RelinkAuthors points the books of the source authors, including the ones
+// in the trash, at the target author. Books are rewritten in batches, each
+// conditioned on the version it was read at, and the books that changed in
+// between are read and rewritten again. It only succeeds once no book is left
+// pointing at a source author. Every relinked book is audited as an update.
+func (b *BooksHandler) RelinkAuthors(sourceIds []string, target models.Author, info models.RequestInfo) error {
+	for attempt := 0; attempt < consts.RelinkAuthorsAttempts; attempt++ {
+		conflicts := 0
+		for _, sourceId := range sourceIds {
+			for _, deleted := range []bool{false, true} {
+				batchConflicts, err := b.relinkBooks(models.BookFilters{AuthorId: sourceId, Deleted: deleted}, sourceIds, target, info)
+				if err != nil {
+					return err
+				}
+				conflicts += batchConflicts
+			}
+		}
+		if conflicts == 0 {
+			return nil
+		}
+	}
+
+	return &models.ConflictError{Message: "books of the merged authors kept changing, try the merge again"}
+}
+
+// relinkBooks relinks the books matching the filters and returns how many of
+// them changed since they were read.
+func (b *BooksHandler) relinkBooks(filters models.BookFilters, sourceIds []string, target models.Author, info models.RequestInfo) (int, error) {
+	conflicts := 0
+	batch := make([]models.Book, 0, consts.BooksScrollSize)
+	flush := func() error {
+		batchConflicts, err := b.relinkBatch(batch, sourceIds, target, info)
+		conflicts += batchConflicts
+		batch = batch[:0]
+		return err
+	}
+
+	err := b.booksRepository.Scroll(filters, func(book models.Book) error {
+		batch = append(batch, book)
+		if len(batch) == cap(batch) {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	return conflicts, err
+}
+
+func (b *BooksHandler) relinkBatch(books []models.Book, sourceIds []string, target models.Author, info models.RequestInfo) (int, error) {
+	operations := make([]models.BulkOperation, 0, len(books))
+	before := make(map[string]models.Book, len(books))
+	for _, book := range books {
+		authorIds, authorNames, relinked := models.RelinkAuthors(book.AuthorIds, book.AuthorNames, sourceIds, target)
+		if !relinked {
+			continue
+		}
+		operations = append(operations, models.BulkOperation{
+			Action:  consts.BulkActionUpdate,
+			Id:      book.Id,
+			Fields:  map[string]interface{}{"author_ids": authorIds, "author_names": authorNames},
+			Version: book.Version,
+		})
+		before[book.Id] = book
+	}
+	if len(operations) == 0 {
+		return 0, nil
+	}
+
+	results, err := b.booksRepository.Bulk(operations)
+	if err != nil {
+		return 0, err
+	}
+	b.auditBulk(operations, results, before, info)
+
+	conflicts := 0
+	for _, result := range results {
+		switch {
+		case result.Status == http.StatusConflict:
+			conflicts++
+		case result.Status >= http.StatusBadRequest:
+			return conflicts, fmt.Errorf("error relinking the authors of book %s: %s", result.Id, result.Error)
+		}
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,30 @@
+package books_handler
+
+import (
+	"context"
+	"log"
+	"pkg/service/pkg/interfaces"
+	"time"
+)
+
+// RunTrashRetention purges books that have been in the trash for longer than
+// retention, checking every interval until ctx is done.
+func RunTrashRetention(ctx context.Context, booksHandler interfaces.BooksHandler, retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := booksHandler.PurgeTrash(retention)
+		if err != nil {
+			log.Printf("failed to purge trash: %s", err.Error())
+		} else if purged > 0 {
+			log.Printf("purged %d books from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package branches_handler
+
+import (
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+var _ interfaces.BranchesHandler = &BranchesHandler{}
+
+type BranchesHandler struct {
+	branchesRepository interfaces.BranchesRepository
+	copiesRepository   interfaces.CopiesRepository
+	booksRepository    interfaces.BooksRepository
+}
+
+func NewBranchesHandler(branchesRepository interfaces.BranchesRepository, copiesRepository interfaces.CopiesRepository, booksRepository interfaces.BooksRepository) interfaces.BranchesHandler {
+	return &BranchesHandler{
+		branchesRepository: branchesRepository,
+		copiesRepository:   copiesRepository,
+		booksRepository:    booksRepository,
+	}
+}
+
+func (b *BranchesHandler) CreateBranch(req request.CreateBranch) (string, error) {
+	branchSource := models.BranchSource{
+		Name:    req.Name,
+		Address: req.Address,
+	}
+
+	return b.branchesRepository.Create(branchSource)
+}
+
+func (b *BranchesHandler) GetBranches() (*response.GetBranches, error) {
+	branches, err := b.branchesRepository.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetBranches{Branches: *branches}, nil
+}
+
+func (b *BranchesHandler) GetBranchInventory(branchId string) (*response.GetBranchInventory, error) {
+	if _, err := b.branchesRepository.GetById(branchId); err != nil {
+		return nil, err
+	}
+
+	inventory, err := b.copiesRepository.GetBranchInventory(branchId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetBranchInventory{
+		BranchId:        branchId,
+		Books:           inventory.TotalBooks,
+		Copies:          inventory.TotalCopies,
+		AvailableCopies: inventory.AvailableCopies,
+		OutgoingCopies:  inventory.OutgoingCopies,
+		IncomingCopies:  inventory.IncomingCopies,
+	}, nil
+}
+
+func (b *BranchesHandler) AddCopies(branchId string, req request.AddCopies) (*response.AddCopies, error) {
+	if _, err := b.branchesRepository.GetById(branchId); err != nil {
+		return nil, err
+	}
+	if _, err := b.booksRepository.GetById(req.BookId); err != nil {
+		return nil, err
+	}
+
+	copyIds, err := b.copiesRepository.Create(req.BookId, branchId, req.Copies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.AddCopies{CopyIds: copyIds}, nil
+}
+
+func (b *BranchesHandler) CreateTransfer(req request.CreateTransfer) (*response.Transfer, error) {
+	if _, err := b.branchesRepository.GetById(req.FromBranchId); err != nil {
+		return nil, err
+	}
+	if _, err := b.branchesRepository.GetById(req.ToBranchId); err != nil {
+		return nil, err
+	}
+
+	transfer, err := b.copiesRepository.StartTransfer(req.BookId, req.FromBranchId, req.ToBranchId, req.Copies)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTransferResponse(transfer), nil
+}
+
+func (b *BranchesHandler) CompleteTransfer(transferId string) (*response.Transfer, error) {
+	transfer, err := b.copiesRepository.CompleteTransfer(transferId)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTransferResponse(transfer), nil
+}
+
+func newTransferResponse(transfer *models.Transfer) *response.Transfer {
+	return &response.Transfer{
+		Id:           transfer.Id,
+		BookId:       transfer.BookId,
+		FromBranchId: transfer.FromBranchId,
+		ToBranchId:   transfer.ToBranchId,
+		CopyIds:      transfer.CopyIds,
+	}
+}
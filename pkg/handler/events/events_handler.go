@@ -0,0 +1,51 @@
+package events_handler
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+var _ interfaces.EventsHandler = &EventsHandler{}
+
+type EventsHandler struct {
+	stream   interfaces.EventStream
+	liveFeed interfaces.LiveFeed
+}
+
+func NewEventsHandler(stream interfaces.EventStream, liveFeed interfaces.LiveFeed) interfaces.EventsHandler {
+	return &EventsHandler{
+		stream:   stream,
+		liveFeed: liveFeed,
+	}
+}
+
+// GetConsumerEvents returns the events after the offset the consumer
+// committed. Reading does not move the offset, the consumer commits
+// NextOffset once it has handled the events.
+func (e *EventsHandler) GetConsumerEvents(consumer string, req request.GetConsumerEvents) (*response.GetConsumerEvents, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = consts.EventReadSize
+	}
+
+	offset, err := e.stream.GetOffset(consumer)
+	if err != nil {
+		return nil, err
+	}
+	events, err := e.stream.Read(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.GetConsumerEvents{Consumer: consumer, Offset: offset, Events: events}
+	if len(events) > 0 {
+		res.NextOffset = events[len(events)-1].Offset
+	}
+	return res, nil
+}
+
+func (e *EventsHandler) CommitConsumerOffset(consumer string, req request.CommitConsumerOffset) error {
+	return e.stream.CommitOffset(consumer, req.Offset)
+}
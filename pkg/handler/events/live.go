@@ -0,0 +1,38 @@
+package events_handler
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"strings"
+)
+
+var liveTopics = []string{consts.LiveTopicBooks, consts.LiveTopicActivity}
+
+// SubscribeLive subscribes to the topics listed in the request, or to every
+// topic when none are listed. The username only narrows activity events.
+func (e *EventsHandler) SubscribeLive(req request.StreamEvents, lastEventId string) (*models.LiveSubscription, error) {
+	filter := models.LiveFilter{Username: req.Username}
+	for _, topic := range strings.Split(req.Topics, ",") {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		if !isLiveTopic(topic) {
+			return nil, &models.ValidationError{Message: "unknown topic " + topic + ", expected one of " + strings.Join(liveTopics, ", ")}
+		}
+		filter.Topics = append(filter.Topics, topic)
+	}
+
+	subscription := e.liveFeed.Subscribe(filter, lastEventId)
+	return &subscription, nil
+}
+
+func isLiveTopic(topic string) bool {
+	for _, liveTopic := range liveTopics {
+		if topic == liveTopic {
+			return true
+		}
+	}
+	return false
+}
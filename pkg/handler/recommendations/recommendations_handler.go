@@ -0,0 +1,50 @@
+package recommendations_handler
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+var _ interfaces.RecommendationsHandler = &RecommendationsHandler{}
+
+type RecommendationsHandler struct {
+	booksRepository       interfaces.BooksRepository
+	similarRecommender    interfaces.Recommender
+	coActivityRecommender interfaces.Recommender
+}
+
+func NewRecommendationsHandler(booksRepository interfaces.BooksRepository, similarRecommender interfaces.Recommender, coActivityRecommender interfaces.Recommender) interfaces.RecommendationsHandler {
+	return &RecommendationsHandler{
+		booksRepository:       booksRepository,
+		similarRecommender:    similarRecommender,
+		coActivityRecommender: coActivityRecommender,
+	}
+}
+
+func (r *RecommendationsHandler) GetSimilarBooks(bookId string, req request.GetRecommendations) (*response.GetRecommendations, error) {
+	return r.recommend(r.similarRecommender, bookId, req)
+}
+
+func (r *RecommendationsHandler) GetAlsoViewedBooks(bookId string, req request.GetRecommendations) (*response.GetRecommendations, error) {
+	return r.recommend(r.coActivityRecommender, bookId, req)
+}
+
+func (r *RecommendationsHandler) recommend(recommender interfaces.Recommender, bookId string, req request.GetRecommendations) (*response.GetRecommendations, error) {
+	book, err := r.booksRepository.GetById(bookId)
+	if err != nil {
+		return nil, err
+	}
+	size := req.Size
+	if size == 0 {
+		size = consts.RecommendationsSize
+	}
+
+	recommendations, err := recommender.Recommend(*book, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetRecommendations{Books: recommendations}, nil
+}
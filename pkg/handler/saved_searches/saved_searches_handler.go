@@ -0,0 +1,110 @@
+package saved_searches_handler
+
+import (
+	"fmt"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+	"time"
+)
+
+var _ interfaces.SavedSearchesHandler = &SavedSearchesHandler{}
+
+type SavedSearchesHandler struct {
+	savedSearchesRepository interfaces.SavedSearchesRepository
+	notificationsRepository interfaces.NotificationsRepository
+}
+
+func NewSavedSearchesHandler(savedSearchesRepository interfaces.SavedSearchesRepository, notificationsRepository interfaces.NotificationsRepository) interfaces.SavedSearchesHandler {
+	return &SavedSearchesHandler{
+		savedSearchesRepository: savedSearchesRepository,
+		notificationsRepository: notificationsRepository,
+	}
+}
+
+func (s *SavedSearchesHandler) CreateSavedSearch(username string, req request.CreateSavedSearch) (string, error) {
+	filters, err := newSavedSearchFilters(req)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := s.savedSearchesRepository.Get(username)
+	if err != nil {
+		return "", err
+	}
+	if len(existing) >= consts.SavedSearchesPerUser {
+		return "", &models.ValidationError{Message: fmt.Sprintf("a user can have at most %d saved searches", consts.SavedSearchesPerUser)}
+	}
+	for _, search := range existing {
+		if search.Name == req.Name {
+			return "", &models.ValidationError{Message: fmt.Sprintf("a saved search named %q already exists", req.Name)}
+		}
+	}
+
+	return s.savedSearchesRepository.Create(models.SavedSearch{
+		Username:  username,
+		Name:      req.Name,
+		Filters:   filters,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func (s *SavedSearchesHandler) GetSavedSearches(username string) (*response.GetSavedSearches, error) {
+	searches, err := s.savedSearchesRepository.Get(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetSavedSearches{SavedSearches: searches}, nil
+}
+
+func (s *SavedSearchesHandler) DeleteSavedSearch(username string, id string) error {
+	return s.savedSearchesRepository.Delete(username, id)
+}
+
+func (s *SavedSearchesHandler) GetNotifications(username string, req request.GetNotifications) (*response.GetNotifications, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = consts.NotificationsQuerySize
+	}
+
+	notifications, err := s.notificationsRepository.Get(username, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetNotifications{Notifications: notifications}, nil
+}
+
+// newSavedSearchFilters checks the filters the way GetBooks does, and asks
+// for at least one of them so a search does not match every new book.
+func newSavedSearchFilters(req request.CreateSavedSearch) (models.SavedSearchFilters, error) {
+	filters := models.SavedSearchFilters{
+		Title:      req.Title,
+		AuthorId:   req.AuthorId,
+		AuthorName: req.AuthorName,
+	}
+
+	if req.MinPrice != nil {
+		if *req.MinPrice <= 0 {
+			return filters, &models.ValidationError{Message: "min price must be greater than 0"}
+		}
+		filters.MinPrice = *req.MinPrice
+	}
+	if req.MaxPrice != nil {
+		if *req.MaxPrice <= 0 {
+			return filters, &models.ValidationError{Message: "max price must be greater than 0"}
+		}
+		filters.MaxPrice = *req.MaxPrice
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return filters, &models.ValidationError{Message: "min price must be less than or equal to max price"}
+	}
+	if filters == (models.SavedSearchFilters{}) {
+		return filters, &models.ValidationError{Message: "a saved search needs a title, author or price filter"}
+	}
+
+	return filters, nil
+}
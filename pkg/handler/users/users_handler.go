@@ -1,6 +1,7 @@
 package users_handler
 
 import (
+	"pkg/service/pkg/consts"
 	"pkg/service/pkg/interfaces"
 	"pkg/service/pkg/models"
 	"pkg/service/pkg/models/request"
@@ -21,8 +22,17 @@ func NewUsersHandler(usersRepository interfaces.UsersRepository) interfaces.User
 
 func (u *UsersHandler) SaveUserAction(req request.CreateUserAction) error {
 	userAction := models.UserAction{
-		Username: req.Username,
-		Action:   req.Method + " " + req.Route,
+		Username:   req.Username,
+		Timestamp:  req.Timestamp.UTC(),
+		Method:     req.Method,
+		Route:      req.Route,
+		Path:       req.Path,
+		BookId:     req.BookId,
+		ResourceId: req.ResourceId,
+		Status:     req.Status,
+		ErrorCode:  req.ErrorCode,
+		LatencyMs:  float64(req.Latency.Microseconds()) / 1000,
+		ClientIp:   req.ClientIp,
 	}
 
 	err := u.usersRepository.SaveAction(userAction)
@@ -33,11 +43,28 @@ func (u *UsersHandler) SaveUserAction(req request.CreateUserAction) error {
 	return nil
 }
 
-func (u *UsersHandler) GetUserActivity(username string) (*response.GetUserActivity, error) {
-	activity, err := u.usersRepository.GetActivity(username)
+func (u *UsersHandler) GetUserActivity(username string, req request.GetUserActivity) (*response.GetUserActivity, error) {
+	if !req.From.IsZero() && !req.To.IsZero() && req.From.After(req.To) {
+		return nil, &models.ValidationError{Message: "from must be before to"}
+	}
+
+	filters := models.ActivityFilters{From: req.From, To: req.To, Offset: req.Offset, Limit: req.Limit}
+	if filters.Limit == 0 {
+		filters.Limit = consts.UserActivityQuerySize
+	}
+
+	activity, err := u.usersRepository.GetActivity(username, filters)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response.GetUserActivity{Actions: activity.Actions}, nil
+	res := &response.GetUserActivity{Events: activity.Events, Total: activity.Total}
+	if next := filters.Offset + len(activity.Events); next < activity.Total {
+		res.NextOffset = &next
+	}
+	return res, nil
+}
+
+func (u *UsersHandler) ClearUserActivity(username string) error {
+	return u.usersRepository.ClearActivity(username)
 }
@@ -0,0 +1,117 @@
+package webhooks_handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+	"time"
+)
+
+var _ interfaces.WebhooksHandler = &WebhooksHandler{}
+
+type WebhooksHandler struct {
+	webhooksRepository interfaces.WebhooksRepository
+	webhookDispatcher  interfaces.WebhookDispatcher
+}
+
+func NewWebhooksHandler(webhooksRepository interfaces.WebhooksRepository, webhookDispatcher interfaces.WebhookDispatcher) interfaces.WebhooksHandler {
+	return &WebhooksHandler{
+		webhooksRepository: webhooksRepository,
+		webhookDispatcher:  webhookDispatcher,
+	}
+}
+
+// CreateWebhook returns the subscription with its secret, which is left out
+// when the webhooks are listed later.
+func (w *WebhooksHandler) CreateWebhook(req request.CreateWebhook) (*models.WebhookSubscription, error) {
+	subscription := models.WebhookSubscription{
+		Url:        req.Url,
+		EventTypes: uniqueEventTypes(req.EventTypes),
+		Secret:     req.Secret,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if subscription.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = secret
+	}
+
+	id, err := w.webhooksRepository.CreateSubscription(subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Id = id
+	return &subscription, nil
+}
+
+func (w *WebhooksHandler) GetWebhooks() (*response.GetWebhooks, error) {
+	subscriptions, err := w.webhooksRepository.GetSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return &response.GetWebhooks{Webhooks: subscriptions}, nil
+}
+
+func (w *WebhooksHandler) DeleteWebhook(id string) error {
+	return w.webhooksRepository.DeleteSubscription(id)
+}
+
+func (w *WebhooksHandler) GetWebhookDeliveries(id string, req request.GetWebhookDeliveries) (*response.GetWebhookDeliveries, error) {
+	if _, err := w.webhooksRepository.GetSubscription(id); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = consts.WebhookDeliveriesQuerySize
+	}
+	deliveries, err := w.webhooksRepository.GetDeliveries(id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.GetWebhookDeliveries{Deliveries: deliveries}, nil
+}
+
+func (w *WebhooksHandler) ReplayWebhookDelivery(id string, deliveryId string) (*models.WebhookDelivery, error) {
+	delivery, err := w.webhooksRepository.GetDelivery(deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.SubscriptionId != id {
+		return nil, &models.NotFoundError{Resource: "webhook delivery", Id: deliveryId}
+	}
+
+	return w.webhookDispatcher.Replay(deliveryId)
+}
+
+func uniqueEventTypes(eventTypes []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	return unique
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, consts.WebhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
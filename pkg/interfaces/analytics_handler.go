@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type AnalyticsHandler interface {
+	GetTopBooks(req request.GetAnalyticsTop) (*response.GetAnalyticsTop, error)
+	GetTopUsers(req request.GetAnalyticsTop) (*response.GetAnalyticsTop, error)
+	GetTraffic(req request.GetTraffic) (*response.GetTraffic, error)
+}
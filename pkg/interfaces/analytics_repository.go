@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models"
+	"time"
+)
+
+// AnalyticsRepository counts user actions in hourly buckets per book, user
+// and route, and keeps the recent viewers of every book and the books every
+// user viewed recently.
+type AnalyticsRepository interface {
+	RecordActions(actions []models.UserAction) error
+	Top(dimension string, from time.Time, to time.Time, limit int) ([]models.AnalyticsCount, error)
+	Traffic(from time.Time, to time.Time) ([]models.TrafficBucket, error)
+	CoViewed(bookId string, size int) ([]models.AnalyticsCount, error)
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// ArrivalsNotifier tells users about newly created books that match their
+// saved searches.
+type ArrivalsNotifier interface {
+	NotifyArrivals(books []models.Book) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type AuditHandler interface {
+	GetBookHistory(bookId string) (*response.GetAudit, error)
+	GetAudit(req request.GetAudit) (*response.GetAudit, error)
+}
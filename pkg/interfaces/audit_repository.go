@@ -0,0 +1,8 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+type AuditRepository interface {
+	Save(entries []models.AuditEntry) error
+	Find(filters models.AuditFilters) ([]models.AuditEntry, error)
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// AuthorRelinker moves the books of the source authors over to the target
+// author, so the source authors can be deleted.
+type AuthorRelinker interface {
+	RelinkAuthors(sourceIds []string, target models.Author, info models.RequestInfo) error
+}
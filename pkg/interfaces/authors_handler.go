@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type AuthorsHandler interface {
+	CreateAuthor(req request.CreateAuthor) (string, error)
+	GetAuthors(req request.GetAuthors) (*response.GetAuthors, error)
+	GetAuthorById(authorId string) (*response.GetAuthorById, error)
+	MergeAuthors(authorId string, req request.MergeAuthors, info models.RequestInfo) (*response.GetAuthorById, error)
+}
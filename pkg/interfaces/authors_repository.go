@@ -0,0 +1,12 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+type AuthorsRepository interface {
+	Create(author models.AuthorSource) (string, error)
+	Get(name string) (*[]models.Author, error)
+	GetById(authorId string) (*models.Author, error)
+	FindByName(name string) (*models.Author, error)
+	Update(authorId string, author models.AuthorSource) error
+	Delete(authorId string) error
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "pkg/service/pkg/models/request"
+
+type BookReader interface {
+	// Read returns the next record and its 1-based row number. An error with
+	// a row number only concerns that row and reading may go on, while an
+	// error with row 0 (including io.EOF) ends the input.
+	Read() (int, *request.CreateBook, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+type BookWriter interface {
+	Write(book models.Book) error
+	// Close flushes buffered output and writes any trailer the format needs.
+	// It does not close the underlying writer.
+	Close() error
+}
@@ -1,15 +1,27 @@
 package interfaces
 
 import (
+	"pkg/service/pkg/models"
 	"pkg/service/pkg/models/request"
 	"pkg/service/pkg/models/response"
+	"time"
 )
 
 type BooksHandler interface {
-	CreateBook(req request.CreateBook) (string, error)
+	CreateBook(req request.CreateBook, allowDuplicate bool, info models.RequestInfo) (string, error)
 	GetBooks(req request.GetBooks) (*response.GetBooks, error)
+	ExportBooks(req request.GetBooks, writer BookWriter) error
+	SuggestBooks(req request.SuggestBooks) (*response.SuggestBooks, error)
 	GetBookById(bookId string) (*response.GetBookById, error)
-	UpdateBookTitle(bookId string, req request.UpdateBookTitle) error
-	DeleteBook(bookId string) error
-	GetStoreInventory() (*response.GetBooksInventory, error)
+	UpdateBookTitle(bookId string, version *models.BookVersion, req request.UpdateBookTitle, info models.RequestInfo) (*models.BookVersion, error)
+	DeleteBook(bookId string, version *models.BookVersion, info models.RequestInfo) error
+	GetTrash() (*response.GetBooks, error)
+	RestoreBook(bookId string, allowDuplicate bool, info models.RequestInfo) error
+	PurgeBook(bookId string, info models.RequestInfo) error
+	PurgeTrash(olderThan time.Duration) (int, error)
+	GetStoreInventory(req request.GetStoreInventory) (*response.GetBooksInventory, error)
+	BulkBooks(req request.BulkBooks, allowDuplicate bool, info models.RequestInfo) (*response.BulkBooks, error)
+	BulkBooksByQuery(filtersReq request.GetBooks, req request.BulkBooksByQuery, info models.RequestInfo) (*response.BulkBooksByQuery, error)
+	ImportBooks(reader BookReader, req request.ImportBooks, info models.RequestInfo) (*response.ImportBooks, error)
+	RelinkAuthors(sourceIds []string, target models.Author, info models.RequestInfo) error
 }
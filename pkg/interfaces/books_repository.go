@@ -2,13 +2,24 @@ package interfaces
 
 import (
 	"pkg/service/pkg/models"
+	"time"
 )
 
 type BooksRepository interface {
 	Create(book models.BookSource) (string, error)
 	Get(filters models.BookFilters) (*[]models.Book, error)
+	GetFaceted(filters models.BookFilters, facets []string) (*models.FacetedBooks, error)
+	Suggest(prefix string, weights models.SuggestWeights, size int) ([]models.Suggestion, error)
+	FindSimilar(book models.Book, size int) ([]models.Recommendation, error)
+	Scroll(filters models.BookFilters, fn func(book models.Book) error) error
 	GetById(bookId string) (*models.Book, error)
-	UpdateTitle(bookId string, title string) error
-	Delete(bookId string) error
-	GetStoreInventory() (*models.StoreInventory, error)
+	UpdateTitle(bookId string, title string, version *models.BookVersion) (*models.BookVersion, error)
+	Delete(bookId string, version *models.BookVersion, deletedBy string) error
+	Restore(bookId string) error
+	Purge(bookId string) error
+	PurgeDeleted(before time.Time) (int, error)
+	GetStoreInventory(filters models.BookFilters, topAuthors int) (*models.StoreInventory, error)
+	FindDuplicates(books []models.BookSource) ([]string, error)
+	Bulk(operations []models.BulkOperation) ([]models.BulkItemResult, error)
+	Count(filters models.BookFilters) (int, error)
 }
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type BranchesHandler interface {
+	CreateBranch(req request.CreateBranch) (string, error)
+	GetBranches() (*response.GetBranches, error)
+	GetBranchInventory(branchId string) (*response.GetBranchInventory, error)
+	AddCopies(branchId string, req request.AddCopies) (*response.AddCopies, error)
+	CreateTransfer(req request.CreateTransfer) (*response.Transfer, error)
+	CompleteTransfer(transferId string) (*response.Transfer, error)
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+type BranchesRepository interface {
+	Create(branch models.BranchSource) (string, error)
+	Get() (*[]models.Branch, error)
+	GetById(branchId string) (*models.Branch, error)
+}
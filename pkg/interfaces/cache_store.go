@@ -0,0 +1,12 @@
+package interfaces
+
+import "time"
+
+// CacheStore is one tier of a cache. A failing store only costs cache hits,
+// so callers fall through to the next tier on errors.
+type CacheStore interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+	Clear() error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+type CopiesRepository interface {
+	Create(bookId string, branchId string, copies int) ([]string, error)
+	GetAvailableBookIds(branchId string) ([]string, error)
+	GetBranchInventory(branchId string) (*models.BranchInventory, error)
+	StartTransfer(bookId string, fromBranchId string, toBranchId string, copies int) (*models.Transfer, error)
+	CompleteTransfer(transferId string) (*models.Transfer, error)
+}
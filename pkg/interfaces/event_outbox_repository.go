@@ -0,0 +1,12 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// EventOutboxRepository holds the events that are not in the event stream
+// yet, oldest first. Ack removes the events with the given ids once they are,
+// leaving alone any that were acknowledged already.
+type EventOutboxRepository interface {
+	Add(events []models.BookEvent) error
+	Pending(limit int) ([]models.BookEvent, error)
+	Ack(eventIds []string) error
+}
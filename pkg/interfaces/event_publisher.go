@@ -0,0 +1,7 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+type EventPublisher interface {
+	Publish(events []models.BookEvent) error
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// EventStream is the durable log of book events. Consumers read the events
+// after an offset and commit the offset they got to under their name.
+// LastOffset is the offset of the newest event, for readers that only follow
+// new events.
+type EventStream interface {
+	Append(events []models.BookEvent) error
+	Read(after string, limit int) ([]models.StreamEvent, error)
+	LastOffset() (string, error)
+	GetOffset(consumer string) (string, error)
+	CommitOffset(consumer string, offset string) error
+}
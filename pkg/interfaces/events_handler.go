@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type EventsHandler interface {
+	GetConsumerEvents(consumer string, req request.GetConsumerEvents) (*response.GetConsumerEvents, error)
+	CommitConsumerOffset(consumer string, req request.CommitConsumerOffset) error
+	SubscribeLive(req request.StreamEvents, lastEventId string) (*models.LiveSubscription, error)
+}
@@ -0,0 +1,7 @@
+package interfaces
+
+type ImportsRepository interface {
+	GetCheckpoint(importId string) (int, error)
+	SaveCheckpoint(importId string, row int) error
+	DeleteCheckpoint(importId string) error
+}
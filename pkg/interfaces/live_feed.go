@@ -0,0 +1,10 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// LiveFeed fans events out to the subscribers connected to this instance.
+// Publishing never waits for subscribers.
+type LiveFeed interface {
+	Publish(topic string, eventType string, username string, data interface{})
+	Subscribe(filter models.LiveFilter, lastEventId string) models.LiveSubscription
+}
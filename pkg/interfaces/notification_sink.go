@@ -0,0 +1,9 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// NotificationSink delivers notifications, by storing them in an outbox,
+// logging them or posting them to a webhook.
+type NotificationSink interface {
+	Send(notifications []models.Notification) error
+}
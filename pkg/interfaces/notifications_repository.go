@@ -0,0 +1,8 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+type NotificationsRepository interface {
+	Save(notifications []models.Notification) error
+	Get(username string, limit int) ([]models.Notification, error)
+}
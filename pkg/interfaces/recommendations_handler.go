@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type RecommendationsHandler interface {
+	GetSimilarBooks(bookId string, req request.GetRecommendations) (*response.GetRecommendations, error)
+	GetAlsoViewedBooks(bookId string, req request.GetRecommendations) (*response.GetRecommendations, error)
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// Recommender finds books related to a book, best first.
+type Recommender interface {
+	Recommend(book models.Book, size int) ([]models.Recommendation, error)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type SavedSearchesHandler interface {
+	CreateSavedSearch(username string, req request.CreateSavedSearch) (string, error)
+	GetSavedSearches(username string) (*response.GetSavedSearches, error)
+	DeleteSavedSearch(username string, id string) error
+	GetNotifications(username string, req request.GetNotifications) (*response.GetNotifications, error)
+}
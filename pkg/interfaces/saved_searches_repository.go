@@ -0,0 +1,12 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// SavedSearchesRepository keeps the saved searches of every user and finds
+// the ones a book matches.
+type SavedSearchesRepository interface {
+	Create(search models.SavedSearch) (string, error)
+	Get(username string) ([]models.SavedSearch, error)
+	Delete(username string, id string) error
+	Match(book models.Book) ([]models.SavedSearch, error)
+}
@@ -7,5 +7,6 @@ import (
 
 type UsersHandler interface {
 	SaveUserAction(req request.CreateUserAction) error
-	GetUserActivity(username string) (*response.GetUserActivity, error)
+	GetUserActivity(username string, req request.GetUserActivity) (*response.GetUserActivity, error)
+	ClearUserActivity(username string) error
 }
@@ -4,5 +4,7 @@ import "pkg/service/pkg/models"
 
 type UsersRepository interface {
 	SaveAction(ua models.UserAction) error
-	GetActivity(username string) (*models.UserActivity, error)
+	SaveActions(actions []models.UserAction) error
+	GetActivity(username string, filters models.ActivityFilters) (*models.UserActivity, error)
+	ClearActivity(username string) error
 }
@@ -0,0 +1,14 @@
+package interfaces
+
+import "time"
+
+// VersionedCacheStore is a cache tier shared between instances. Deleting an
+// entry bumps the version of its key, and SetIfVersion only stores a value
+// while the key is still at the version read before the value was loaded, so
+// a load racing a write on another instance does not cache what it read
+// before the write.
+type VersionedCacheStore interface {
+	CacheStore
+	Version(key string) (string, error)
+	SetIfVersion(key string, value []byte, ttl time.Duration, version string) error
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "pkg/service/pkg/models"
+
+// WebhookDispatcher queues book events for the subscribers of their type and
+// delivers them in the background.
+type WebhookDispatcher interface {
+	Dispatch(events []models.BookEvent) error
+	Replay(deliveryId string) (*models.WebhookDelivery, error)
+}
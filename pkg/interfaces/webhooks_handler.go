@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models"
+	"pkg/service/pkg/models/request"
+	"pkg/service/pkg/models/response"
+)
+
+type WebhooksHandler interface {
+	CreateWebhook(req request.CreateWebhook) (*models.WebhookSubscription, error)
+	GetWebhooks() (*response.GetWebhooks, error)
+	DeleteWebhook(id string) error
+	GetWebhookDeliveries(id string, req request.GetWebhookDeliveries) (*response.GetWebhookDeliveries, error)
+	ReplayWebhookDelivery(id string, deliveryId string) (*models.WebhookDelivery, error)
+}
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"pkg/service/pkg/models"
+	"time"
+)
+
+// WebhooksRepository keeps the webhook subscriptions and the recent
+// deliveries of each of them. Deliveries still pending are due once their
+// next attempt time has passed. Claiming a due delivery leases it to the
+// caller, so no one else can claim it until it is updated or the lease runs
+// out, and only the holder of the latest claim can update it.
+type WebhooksRepository interface {
+	CreateSubscription(subscription models.WebhookSubscription) (string, error)
+	GetSubscriptions() ([]models.WebhookSubscription, error)
+	GetSubscription(id string) (*models.WebhookSubscription, error)
+	DeleteSubscription(id string) error
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	UpdateDelivery(delivery models.WebhookDelivery, claim string) error
+	GetDelivery(id string) (*models.WebhookDelivery, error)
+	GetDeliveries(subscriptionId string, limit int) ([]models.WebhookDelivery, error)
+	DueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	ClaimDelivery(id string, now time.Time, lease time.Duration) (*models.WebhookDelivery, string, error)
+}
@@ -0,0 +1,27 @@
+package user_activity_middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"pkg/service/pkg/consts"
+)
+
+// RequestId tags every request with the X-Request-Id sent by the client, or a
+// new random id, and echoes it back so changes in the audit trail can be
+// traced to the request that made them.
+func RequestId() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestId := ctx.GetHeader(consts.RequestIdHeader)
+		if requestId == "" {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err == nil {
+				requestId = hex.EncodeToString(b)
+			}
+		}
+
+		ctx.Set(consts.RequestIdContextKey, requestId)
+		ctx.Header(consts.RequestIdHeader, requestId)
+		ctx.Next()
+	}
+}
@@ -9,45 +9,112 @@ import (
 	"pkg/service/pkg/consts"
 	"pkg/service/pkg/interfaces"
 	"pkg/service/pkg/models/request"
+	"strings"
+	"time"
 )
 
 func Middleware(usersHandler interfaces.UsersHandler) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// Skip to the next handler if the path is the user activity endpoint
-		if ctx.FullPath() == consts.GetUserActivityUrlPath {
+		// Skip to the next handler if the path is the user activity, metrics or
+		// live events endpoint. Browsers cannot set headers on an event stream.
+		if ctx.FullPath() == consts.GetUserActivityUrlPath || ctx.FullPath() == consts.DebugVarsUrlPath || ctx.FullPath() == consts.StreamEventsUrlPath {
 			ctx.Next()
 			return
 		}
 
-		if ctx.Request.Body == nil || ctx.Request.Body == http.NoBody {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "no request body"})
-			return
+		// Imports send the file as the body, so they identify the user by header
+		username := ""
+		if ctx.FullPath() == consts.ImportBooksUrlPath {
+			username = ctx.GetHeader(consts.UsernameHeader)
 		}
+		if username == "" {
+			if ctx.Request.Body == nil || ctx.Request.Body == http.NoBody {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "no request body"})
+				return
+			}
 
-		origBody, err := io.ReadAll(ctx.Request.Body)
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(origBody)) // Return the original body for the next read
+			origBody, err := io.ReadAll(ctx.Request.Body)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(origBody)) // Return the original body for the next read
 
-		req := request.Common{}
-		if err = ctx.ShouldBindJSON(&req); err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
+			req := request.Common{}
+			if err = ctx.ShouldBindJSON(&req); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(origBody)) // Return the original body for the next read
+			username = req.Username
 		}
-		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(origBody)) // Return the original body for the next read
 
-		userAction := request.CreateUserAction{
-			Username: req.Username,
-			Method:   ctx.Request.Method,
-			Route:    ctx.FullPath(),
-		}
+		ctx.Set(consts.UsernameContextKey, username)
+
+		start := time.Now()
+		ctx.Next()
+
+		// Recorded after the handler so the outcome of the request is known
+		saveUserAction(usersHandler, newUserAction(ctx, username, start))
+	}
+}
+
+func newUserAction(ctx *gin.Context, username string, start time.Time) request.CreateUserAction {
+	resourceId := ctx.GetString(consts.ResourceIdContextKey)
+	if resourceId == "" {
+		resourceId = ctx.Param("id")
+	}
+
+	userAction := request.CreateUserAction{
+		Username:   username,
+		Timestamp:  start,
+		Method:     ctx.Request.Method,
+		Route:      ctx.FullPath(),
+		Path:       ctx.Request.URL.Path,
+		ResourceId: resourceId,
+		Status:     ctx.Writer.Status(),
+		ErrorCode:  actionErrorCode(ctx),
+		Latency:    time.Since(start),
+		ClientIp:   ctx.ClientIP(),
+	}
+	if strings.HasPrefix(userAction.Route, consts.GetBooksUrlPath+"/") || userAction.Route == consts.CreateBookUrlPath {
+		userAction.BookId = resourceId
+	}
+	return userAction
+}
 
-		if err = usersHandler.SaveUserAction(userAction); err != nil {
-			log.Printf("failed to save user action: %s", err.Error())
+// saveUserAction never lets a failure to record the action, not even a
+// panic, reach the response that was already written.
+func saveUserAction(usersHandler interfaces.UsersHandler, userAction request.CreateUserAction) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("failed to save user action: %v", r)
 		}
+	}()
 
-		ctx.Next()
+	if err := usersHandler.SaveUserAction(userAction); err != nil {
+		log.Printf("failed to save user action: %s", err.Error())
+	}
+}
+
+// actionErrorCode is the error code set by the controller, or one derived
+// from the status for errors it did not classify, such as binding failures.
+func actionErrorCode(ctx *gin.Context) string {
+	if errorCode := ctx.GetString(consts.ErrorCodeContextKey); errorCode != "" {
+		return errorCode
+	}
+
+	status := ctx.Writer.Status()
+	switch {
+	case status < http.StatusBadRequest:
+		return ""
+	case status == http.StatusNotFound:
+		return consts.ErrorCodeNotFound
+	case status == http.StatusConflict:
+		return consts.ErrorCodeConflict
+	case status < http.StatusInternalServerError:
+		return consts.ErrorCodeBadRequest
+	default:
+		return consts.ErrorCodeInternal
 	}
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type ActivityFilters struct {
+	From   time.Time
+	To     time.Time
+	Offset int
+	Limit  int
+}
+
+func (f ActivityFilters) Matches(action UserAction) bool {
+	if !f.From.IsZero() && action.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && action.Timestamp.After(f.To) {
+		return false
+	}
+	return true
+}
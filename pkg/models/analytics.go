@@ -0,0 +1,55 @@
+package models
+
+import (
+	"net/http"
+	"pkg/service/pkg/consts"
+	"sort"
+	"time"
+)
+
+// AnalyticsCount is how many requests a book, user or route had in a period.
+type AnalyticsCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+type TrafficBucket struct {
+	Start    time.Time        `json:"start"`
+	Requests int              `json:"requests"`
+	Routes   []AnalyticsCount `json:"routes"`
+}
+
+// AnalyticsKeys are what an action is counted under, per dimension. Only
+// successful reads of a book itself count as a view of it.
+func (ua UserAction) AnalyticsKeys() map[string]string {
+	keys := map[string]string{
+		consts.AnalyticsDimensionUsers:  ua.Username,
+		consts.AnalyticsDimensionRoutes: ua.Method + " " + ua.Route,
+	}
+	if ua.IsBookView() {
+		keys[consts.AnalyticsDimensionBooks] = ua.BookId
+	}
+	return keys
+}
+
+func (ua UserAction) IsBookView() bool {
+	return ua.BookId != "" && ua.Method == http.MethodGet && ua.Route == consts.GetBookUrlPath && ua.Status < http.StatusBadRequest
+}
+
+// AnalyticsHours are the starts of the hourly buckets between from and to.
+func AnalyticsHours(from time.Time, to time.Time) []time.Time {
+	hours := make([]time.Time, 0)
+	for hour := from.UTC().Truncate(time.Hour); !hour.After(to); hour = hour.Add(time.Hour) {
+		hours = append(hours, hour)
+	}
+	return hours
+}
+
+func SortAnalyticsCounts(counts []AnalyticsCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+}
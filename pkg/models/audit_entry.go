@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type AuditEntry struct {
+	Id        string        `json:"id,omitempty"`
+	BookId    string        `json:"book_id"`
+	Action    string        `json:"action"`
+	Actor     string        `json:"actor"`
+	RequestId string        `json:"request_id,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
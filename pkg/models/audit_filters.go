@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type AuditFilters struct {
+	BookId string
+	Actor  string
+	Action string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+func (f AuditFilters) Matches(entry AuditEntry) bool {
+	if f.BookId != "" && entry.BookId != f.BookId {
+		return false
+	}
+	if f.Actor != "" && entry.Actor != f.Actor {
+		return false
+	}
+	if f.Action != "" && entry.Action != f.Action {
+		return false
+	}
+	if !f.From.IsZero() && entry.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && entry.Timestamp.After(f.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,14 @@
+package models
+
+type Author struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Bio     string   `json:"bio,omitempty"`
+}
+
+type AuthorSource struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Bio     string   `json:"bio,omitempty"`
+}
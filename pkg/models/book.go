@@ -1,18 +1,69 @@
 package models
 
+import "time"
+
 type Book struct {
-	Id             string  `json:"id"`
-	Title          string  `json:"title"`
-	AuthorName     string  `json:"author_name"`
-	Price          float64 `json:"price"`
-	EbookAvailable bool    `json:"ebook_available"`
-	PublishDate    string  `json:"publish_date"`
+	Id             string       `json:"id"`
+	Version        *BookVersion `json:"version,omitempty"`
+	Title          string       `json:"title"`
+	AuthorIds      []string     `json:"author_ids"`
+	AuthorNames    []string     `json:"author_names"`
+	Price          float64      `json:"price"`
+	EbookAvailable bool         `json:"ebook_available"`
+	PublishDate    PublishDate  `json:"publish_date"`
+	Isbn10         string       `json:"isbn_10,omitempty"`
+	Isbn13         string       `json:"isbn_13,omitempty"`
+	Publisher      string       `json:"publisher,omitempty"`
+	Genres         []string     `json:"genres,omitempty"`
+	Language       string       `json:"language,omitempty"`
+	PageCount      int          `json:"page_count,omitempty"`
+	Edition        string       `json:"edition,omitempty"`
+	Description    string       `json:"description,omitempty"`
+	CoverImageUrl  string       `json:"cover_image_url,omitempty"`
+	DeletedAt      *time.Time   `json:"deleted_at,omitempty"`
+	DeletedBy      string       `json:"deleted_by,omitempty"`
 }
 
 type BookSource struct {
-	Title          string  `json:"title"`
-	AuthorName     string  `json:"author_name"`
-	Price          float64 `json:"price"`
-	EbookAvailable bool    `json:"ebook_available"`
-	PublishDate    string  `json:"publish_date"`
+	Title          string      `json:"title"`
+	AuthorIds      []string    `json:"author_ids"`
+	AuthorNames    []string    `json:"author_names"`
+	Price          float64     `json:"price"`
+	EbookAvailable bool        `json:"ebook_available"`
+	PublishDate    PublishDate `json:"publish_date"`
+	Isbn10         string      `json:"isbn_10,omitempty"`
+	Isbn13         string      `json:"isbn_13,omitempty"`
+	Publisher      string      `json:"publisher,omitempty"`
+	Genres         []string    `json:"genres,omitempty"`
+	Language       string      `json:"language,omitempty"`
+	PageCount      int         `json:"page_count,omitempty"`
+	Edition        string      `json:"edition,omitempty"`
+	Description    string      `json:"description,omitempty"`
+	CoverImageUrl  string      `json:"cover_image_url,omitempty"`
+	DedupKey       string      `json:"dedup_key"`
+	DeletedAt      *time.Time  `json:"deleted_at,omitempty"`
+	DeletedBy      string      `json:"deleted_by,omitempty"`
+}
+
+func NewBook(id string, source BookSource) Book {
+	return Book{
+		Id:             id,
+		Title:          source.Title,
+		AuthorIds:      source.AuthorIds,
+		AuthorNames:    source.AuthorNames,
+		Price:          source.Price,
+		EbookAvailable: source.EbookAvailable,
+		PublishDate:    source.PublishDate,
+		Isbn10:         source.Isbn10,
+		Isbn13:         source.Isbn13,
+		Publisher:      source.Publisher,
+		Genres:         source.Genres,
+		Language:       source.Language,
+		PageCount:      source.PageCount,
+		Edition:        source.Edition,
+		Description:    source.Description,
+		CoverImageUrl:  source.CoverImageUrl,
+		DeletedAt:      source.DeletedAt,
+		DeletedBy:      source.DeletedBy,
+	}
 }
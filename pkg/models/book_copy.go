@@ -0,0 +1,18 @@
+package models
+
+type BookCopy struct {
+	Id                  string `json:"id"`
+	BookId              string `json:"book_id"`
+	BranchId            string `json:"branch_id"`
+	Status              string `json:"status"`
+	DestinationBranchId string `json:"destination_branch_id,omitempty"`
+	TransferId          string `json:"transfer_id,omitempty"`
+}
+
+type BookCopySource struct {
+	BookId              string `json:"book_id"`
+	BranchId            string `json:"branch_id"`
+	Status              string `json:"status"`
+	DestinationBranchId string `json:"destination_branch_id,omitempty"`
+	TransferId          string `json:"transfer_id,omitempty"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// DiffBooks lists the fields that differ between two revisions of a book in
+// their JSON form. A nil before or after stands for a book that did not exist.
+func DiffBooks(before *Book, after *Book) []FieldChange {
+	beforeFields := bookFields(before)
+	afterFields := bookFields(after)
+
+	names := make([]string, 0, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, found := beforeFields[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]FieldChange, 0)
+	for _, name := range names {
+		if !reflect.DeepEqual(beforeFields[name], afterFields[name]) {
+			changes = append(changes, FieldChange{Field: name, Before: beforeFields[name], After: afterFields[name]})
+		}
+	}
+	return changes
+}
+
+func bookFields(book *Book) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if book == nil {
+		return fields
+	}
+	data, err := json.Marshal(book)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	delete(fields, "id")
+	delete(fields, "version")
+	return fields
+}
+
+// WithFields returns a copy of the book with the fields overlaid on its JSON
+// form, the way a partial document update applies them.
+func (b Book) WithFields(fields map[string]interface{}) (Book, error) {
+	return withFields(b, fields)
+}
+
+func (s BookSource) WithFields(fields map[string]interface{}) (BookSource, error) {
+	return withFields(s, fields)
+}
+
+func withFields[T any](value T, fields map[string]interface{}) (T, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value, err
+	}
+	document := make(map[string]interface{})
+	if err = json.Unmarshal(data, &document); err != nil {
+		return value, err
+	}
+	for field, fieldValue := range fields {
+		document[field] = fieldValue
+	}
+	if data, err = json.Marshal(document); err != nil {
+		return value, err
+	}
+
+	var updated T
+	if err = json.Unmarshal(data, &updated); err != nil {
+		return value, err
+	}
+	return updated, nil
+}
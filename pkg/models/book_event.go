@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// BookEvent announces a change to the catalog. Book is the state after the
+// change, and for deleted books it is the book as it was moved to the trash.
+type BookEvent struct {
+	Id        string    `json:"id"`
+	Type      string    `json:"type"`
+	BookId    string    `json:"book_id"`
+	Book      *Book     `json:"book,omitempty"`
+	Actor     string    `json:"actor"`
+	RequestId string    `json:"request_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"pkg/service/pkg/consts"
+	"strconv"
+)
+
+type FacetBucket struct {
+	Key      string `json:"key"`
+	Label    string `json:"label,omitempty"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected,omitempty"`
+}
+
+type FacetedBooks struct {
+	Books  []Book
+	Facets map[string][]FacetBucket
+}
+
+// PriceBand is a price facet bucket, from inclusive to exclusive. A zero To
+// leaves the band open ended.
+type PriceBand struct {
+	From float64
+	To   float64
+}
+
+var PriceFacetBands = []PriceBand{{0, 10}, {10, 25}, {25, 50}, {50, 100}, {100, 0}}
+
+func (b PriceBand) Key() string {
+	if b.To == 0 {
+		return fmt.Sprintf("%g-", b.From)
+	}
+	return fmt.Sprintf("%g-%g", b.From, b.To)
+}
+
+func (b PriceBand) Contains(price float64) bool {
+	return price >= b.From && (b.To == 0 || price < b.To)
+}
+
+func Decade(year int) int {
+	return year / 10 * 10
+}
+
+// HasFacet reports whether the filters select a bucket of the facet.
+func (f BookFilters) HasFacet(facet string) bool {
+	switch facet {
+	case consts.FacetAuthors:
+		return f.AuthorId != ""
+	case consts.FacetPrice:
+		return f.MinPrice > 0 || f.MaxPrice > 0
+	case consts.FacetEbook:
+		return f.Ebook != nil
+	case consts.FacetDecade:
+		return f.Decade != 0
+	}
+	return false
+}
+
+// WithoutFacet drops the selection of the facet, which is what the counts of
+// its buckets are computed over.
+func (f BookFilters) WithoutFacet(facet string) BookFilters {
+	switch facet {
+	case consts.FacetAuthors:
+		f.AuthorId = ""
+	case consts.FacetPrice:
+		f.MinPrice, f.MaxPrice = 0, 0
+	case consts.FacetEbook:
+		f.Ebook = nil
+	case consts.FacetDecade:
+		f.Decade = 0
+	}
+	return f
+}
+
+func (f BookFilters) FacetSelected(facet string, key string) bool {
+	switch facet {
+	case consts.FacetAuthors:
+		return f.AuthorId == key
+	case consts.FacetPrice:
+		band, selected := f.SelectedPriceBand()
+		return selected && band.Key() == key
+	case consts.FacetEbook:
+		return f.Ebook != nil && strconv.FormatBool(*f.Ebook) == key
+	case consts.FacetDecade:
+		return f.Decade != 0 && strconv.Itoa(f.Decade) == key
+	}
+	return false
+}
+
+// SelectedPriceBand returns the price band the price filters select, if they
+// are exactly one of PriceFacetBands.
+func (f BookFilters) SelectedPriceBand() (PriceBand, bool) {
+	if !f.HasFacet(consts.FacetPrice) {
+		return PriceBand{}, false
+	}
+	for _, band := range PriceFacetBands {
+		if f.MinPrice == band.From && f.MaxPrice == band.To {
+			return band, true
+		}
+	}
+	return PriceBand{}, false
+}
@@ -1,8 +1,115 @@
 package models
 
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
 type BookFilters struct {
-	Title      string
-	AuthorName string
-	MinPrice   float64
-	MaxPrice   float64
+	Ids             []string
+	Query           string
+	Title           string
+	AuthorId        string
+	AuthorName      string
+	MinPrice        float64
+	MaxPrice        float64
+	Isbn            string
+	Publisher       string
+	Genre           string
+	Language        string
+	MinPages        int
+	MaxPages        int
+	PublishedAfter  time.Time
+	PublishedBefore time.Time
+	Ebook           *bool
+	Decade          int
+	Deleted         bool
+	DeletedBefore   time.Time
+	Sort            string
+}
+
+// IsEmpty reports whether the filters match the whole catalog.
+func (f BookFilters) IsEmpty() bool {
+	f.Sort = ""
+	return reflect.ValueOf(f).IsZero()
+}
+
+// Matches applies the filters to a single book the way the Elastic query
+// does, for repositories that filter in memory.
+func (f BookFilters) Matches(book Book) bool {
+	if f.Deleted != (book.DeletedAt != nil) {
+		return false
+	}
+	if !f.DeletedBefore.IsZero() && (book.DeletedAt == nil || !book.DeletedAt.Before(f.DeletedBefore)) {
+		return false
+	}
+	if f.Ids != nil && !containsString(f.Ids, book.Id) {
+		return false
+	}
+	if f.Query != "" && !matchesQuery(book, f.Query) {
+		return false
+	}
+	if f.Title != "" && book.Title != f.Title {
+		return false
+	}
+	if f.AuthorId != "" && !containsString(book.AuthorIds, f.AuthorId) {
+		return false
+	}
+	if f.AuthorName != "" && !containsString(book.AuthorNames, f.AuthorName) {
+		return false
+	}
+	if (f.MinPrice > 0 && book.Price < f.MinPrice) || (f.MaxPrice > 0 && book.Price > f.MaxPrice) {
+		return false
+	}
+	if !f.PublishedAfter.IsZero() && (book.PublishDate.IsZero() || book.PublishDate.Start().Before(f.PublishedAfter)) {
+		return false
+	}
+	if !f.PublishedBefore.IsZero() && (book.PublishDate.IsZero() || book.PublishDate.Start().After(f.PublishedBefore)) {
+		return false
+	}
+	if f.Ebook != nil && book.EbookAvailable != *f.Ebook {
+		return false
+	}
+	if f.Decade != 0 && (book.PublishDate.IsZero() || Decade(book.PublishDate.Start().Year()) != f.Decade) {
+		return false
+	}
+	if f.Isbn != "" && book.Isbn10 != f.Isbn && book.Isbn13 != f.Isbn {
+		return false
+	}
+	if f.Publisher != "" && book.Publisher != f.Publisher {
+		return false
+	}
+	if f.Genre != "" && !containsString(book.Genres, f.Genre) {
+		return false
+	}
+	if f.Language != "" && book.Language != f.Language {
+		return false
+	}
+	if (f.MinPages > 0 && book.PageCount < f.MinPages) || (f.MaxPages > 0 && book.PageCount > f.MaxPages) {
+		return false
+	}
+	return true
+}
+
+func matchesQuery(book Book, query string) bool {
+	texts := append([]string{book.Title, book.Publisher, book.Description}, book.AuthorNames...)
+	texts = append(texts, book.Genres...)
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		for _, text := range texts {
+			if strings.Contains(strings.ToLower(text), word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BookVersion identifies a revision of a book by the Elastic _seq_no and
+// _primary_term of its document.
+type BookVersion struct {
+	SeqNo       int64
+	PrimaryTerm int64
+}
+
+func NewBookVersion(seqNo *int64, primaryTerm *int64) *BookVersion {
+	if seqNo == nil || primaryTerm == nil {
+		return nil
+	}
+	return &BookVersion{SeqNo: *seqNo, PrimaryTerm: *primaryTerm}
+}
+
+func ParseBookVersion(value string) (BookVersion, error) {
+	v := BookVersion{}
+	if _, err := fmt.Sscanf(value, "%d-%d", &v.SeqNo, &v.PrimaryTerm); err != nil || v.String() != value {
+		return v, errors.New("invalid book version")
+	}
+	return v, nil
+}
+
+// ParseETag reads a version from an If-Match or ETag header value.
+func ParseETag(value string) (BookVersion, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	return ParseBookVersion(strings.Trim(value, `"`))
+}
+
+func (v BookVersion) String() string {
+	return fmt.Sprintf("%d-%d", v.SeqNo, v.PrimaryTerm)
+}
+
+func (v BookVersion) ETag() string {
+	return `"` + v.String() + `"`
+}
+
+func (v BookVersion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+func (v *BookVersion) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := ParseBookVersion(value)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
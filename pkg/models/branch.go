@@ -0,0 +1,12 @@
+package models
+
+type Branch struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type BranchSource struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
@@ -0,0 +1,9 @@
+package models
+
+type BranchInventory struct {
+	TotalBooks      int
+	TotalCopies     int
+	AvailableCopies int
+	OutgoingCopies  int
+	IncomingCopies  int
+}
@@ -0,0 +1,9 @@
+package models
+
+type BulkItemResult struct {
+	Id     string
+	Status int
+	Error  string
+	// Trashed reports that a create conflicts with a book in the trash
+	Trashed bool
+}
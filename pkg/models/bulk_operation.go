@@ -0,0 +1,13 @@
+package models
+
+type BulkOperation struct {
+	Action string
+	Id     string
+	Book   *BookSource
+	Fields map[string]interface{}
+	// DeletedBy records who moved the book to the trash on delete operations
+	DeletedBy string
+	// Version makes updates and deletes fail with a conflict when the book
+	// changed since it was read
+	Version *BookVersion
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DedupKey identifies near-duplicate books that have no ISBN. Titles are
+// compared ignoring case, punctuation and spacing, and authors by their
+// resolved ids so that spelling variants collapse to the same key.
+func DedupKey(title string, authorIds []string, publishDate string) string {
+	ids := append([]string{}, authorIds...)
+	sort.Strings(ids)
+	return strings.Join([]string{normalizeText(title), strings.Join(ids, ","), normalizeText(publishDate)}, "|")
+}
+
+func normalizeText(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
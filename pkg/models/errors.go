@@ -0,0 +1,53 @@
+package models
+
+import "fmt"
+
+// DuplicateBookError is a book that already exists. A Trashed one is in the
+// trash, and has to be restored or purged before it can be created again.
+type DuplicateBookError struct {
+	ExistingId string
+	Field      string
+	Trashed    bool
+}
+
+func (e *DuplicateBookError) Error() string {
+	if e.Trashed {
+		return fmt.Sprintf("a book with the same %s is in the trash, restore or purge %s", e.Field, e.ExistingId)
+	}
+	return fmt.Sprintf("a book with the same %s already exists", e.Field)
+}
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+type VersionConflictError struct {
+	BookId string
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("book %s was modified since the given version", e.BookId)
+}
+
+// ConflictError is a write that cannot apply to the current state of the
+// resource, as opposed to a stale version of it.
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+type NotFoundError struct {
+	Resource string
+	Id       string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.Resource)
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NormalizeIsbn drops the hyphens and spaces ISBNs are often written with,
+// so the same ISBN is always stored and searched the same way.
+func NormalizeIsbn(isbn string) string {
+	isbn = strings.ReplaceAll(isbn, "-", "")
+	isbn = strings.ReplaceAll(isbn, " ", "")
+	return strings.ToUpper(isbn)
+}
+
+// IsbnBookId is the id of the book with the given ISBN-13. Books with an
+// ISBN are created under it only if it is not taken, so two concurrent
+// creates of the same ISBN cannot both succeed.
+func IsbnBookId(isbn13 string) string {
+	return "isbn-" + isbn13
+}
+
+// CompleteIsbns fills in whichever ISBN form is missing. Both inputs are
+// expected to be normalized and check-digit valid already; an ISBN-13 only
+// has an ISBN-10 equivalent when it carries the 978 prefix. When both are
+// given they have to be the same book.
+func CompleteIsbns(isbn10 string, isbn13 string) (string, string, error) {
+	if isbn10 != "" && isbn13 != "" && isbn10To13(isbn10) != isbn13 {
+		return "", "", &ValidationError{Message: "isbn_10 and isbn_13 are not the same book"}
+	}
+	if isbn13 == "" && isbn10 != "" {
+		isbn13 = isbn10To13(isbn10)
+	}
+	if isbn10 == "" && strings.HasPrefix(isbn13, "978") {
+		isbn10 = isbn13To10(isbn13)
+	}
+	return isbn10, isbn13, nil
+}
+
+func isbn10To13(isbn10 string) string {
+	body := "978" + isbn10[:9]
+	sum := 0
+	for i, r := range body {
+		digit := int(r - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return body + strconv.Itoa((10-sum%10)%10)
+}
+
+func isbn13To10(isbn13 string) string {
+	body := isbn13[3:12]
+	sum := 0
+	for i, r := range body {
+		sum += (10 - i) * int(r-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X"
+	}
+	return body + strconv.Itoa(check)
+}
@@ -0,0 +1,36 @@
+package models
+
+import "encoding/json"
+
+// LiveEvent is pushed to the subscribers of the live feed. Username is set on
+// activity events so subscribers can follow a single user.
+type LiveEvent struct {
+	Id       string
+	Topic    string
+	Type     string
+	Username string
+	Data     json.RawMessage
+}
+
+type LiveFilter struct {
+	Topics   []string
+	Username string
+}
+
+func (f LiveFilter) Matches(event LiveEvent) bool {
+	if len(f.Topics) > 0 && !containsString(f.Topics, event.Topic) {
+		return false
+	}
+	return f.Username == "" || event.Username == "" || event.Username == f.Username
+}
+
+// LiveSubscription holds the events missed since the Last-Event-ID the
+// subscriber gave, and the channel of the events that follow. Resumed is
+// false when those missed events are no longer known. Events is closed when
+// the subscriber falls too far behind or the feed is closed.
+type LiveSubscription struct {
+	Backlog []LiveEvent
+	Resumed bool
+	Events  <-chan LiveEvent
+	Cancel  func()
+}
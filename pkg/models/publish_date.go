@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// PublishDate keeps the precision it was given with, so a book published
+// "2020" is not reported as published on the first of January. Values stored
+// before publish dates were validated that cannot be parsed are kept as raw
+// text and otherwise treated as an empty date.
+type PublishDate struct {
+	time    time.Time
+	layout  string
+	raw     string
+	invalid bool
+}
+
+var publishDateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+var errInvalidPublishDate = errors.New("publish date must be formatted as YYYY-MM-DD, YYYY-MM or YYYY")
+
+// legacyPublishDateLayouts are the other forms found in old books, each with
+// the layout it is normalized to.
+var legacyPublishDateLayouts = map[string]string{
+	time.RFC3339:          "2006-01-02",
+	"2006-01-02T15:04:05": "2006-01-02",
+	"2006-01-02 15:04:05": "2006-01-02",
+	"2006/01/02":          "2006-01-02",
+	"2006-1-2":            "2006-01-02",
+	"2 January 2006":      "2006-01-02",
+	"January 2, 2006":     "2006-01-02",
+	"Jan 2, 2006":         "2006-01-02",
+	"2006/01":             "2006-01",
+	"2006-1":              "2006-01",
+	"January 2006":        "2006-01",
+	"Jan 2006":            "2006-01",
+}
+
+func ParsePublishDate(value string) (PublishDate, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range publishDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return PublishDate{time: parsed, layout: layout}, nil
+		}
+	}
+	return PublishDate{}, errInvalidPublishDate
+}
+
+// ParseLegacyPublishDate also accepts the forms old books were stored with,
+// such as timestamps or slashes, reducing them to the supported precision.
+func ParseLegacyPublishDate(value string) (PublishDate, bool) {
+	if parsed, err := ParsePublishDate(value); err == nil {
+		return parsed, true
+	}
+	value = strings.TrimSpace(value)
+	for layout, normalized := range legacyPublishDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			parsed, _ = time.Parse(normalized, parsed.Format(normalized))
+			return PublishDate{time: parsed, layout: normalized}, true
+		}
+	}
+	return PublishDate{}, false
+}
+
+// Validate reports a raw value that could not be parsed, for dates that come
+// from a request rather than a stored book.
+func (d PublishDate) Validate() error {
+	if d.invalid {
+		return errInvalidPublishDate
+	}
+	return nil
+}
+
+// Raw returns the value that could not be parsed, if any.
+func (d PublishDate) Raw() string {
+	return d.raw
+}
+
+func (d PublishDate) IsZero() bool {
+	return d.layout == ""
+}
+
+func (d PublishDate) Start() time.Time {
+	return d.time
+}
+
+// End returns the last day covered by the date, e.g. 2020-12-31 for "2020".
+func (d PublishDate) End() time.Time {
+	switch d.layout {
+	case "2006":
+		return d.time.AddDate(1, 0, -1)
+	case "2006-01":
+		return d.time.AddDate(0, 1, -1)
+	default:
+		return d.time
+	}
+}
+
+func (d PublishDate) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.time.Format(d.layout)
+}
+
+func (d PublishDate) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *PublishDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = PublishDate{}
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		// Old books may have the year stored as a number
+		*d = PublishDate{raw: string(data), invalid: true}
+		return nil
+	}
+
+	parsed, err := ParsePublishDate(value)
+	if err != nil {
+		*d = PublishDate{raw: value, invalid: true}
+		return nil
+	}
+
+	*d = parsed
+	return nil
+}
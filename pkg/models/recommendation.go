@@ -0,0 +1,6 @@
+package models
+
+type Recommendation struct {
+	Book  Book    `json:"book"`
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// RelinkAuthors replaces the source authors of a book with the target,
+// keeping the order of the authors and dropping repeats. It reports whether
+// any author was replaced.
+func RelinkAuthors(authorIds []string, authorNames []string, sourceIds []string, target Author) ([]string, []string, bool) {
+	relinked := false
+	ids := make([]string, 0, len(authorIds))
+	names := make([]string, 0, len(authorNames))
+	for i, authorId := range authorIds {
+		name := ""
+		if i < len(authorNames) {
+			name = authorNames[i]
+		}
+		if containsString(sourceIds, authorId) {
+			authorId, name = target.Id, target.Name
+			relinked = true
+		}
+		if containsString(ids, authorId) {
+			continue
+		}
+		ids = append(ids, authorId)
+		names = append(names, name)
+	}
+	return ids, names, relinked
+}
@@ -0,0 +1,6 @@
+package request
+
+type AddCopies struct {
+	BookId string `json:"book_id" binding:"required"`
+	Copies int    `json:"copies" binding:"required,gt=0,max=1000"`
+}
@@ -0,0 +1,12 @@
+package request
+
+type BulkBooks struct {
+	Operations []BulkBookOperation `json:"operations" binding:"required,min=1,max=1000,dive"`
+}
+
+type BulkBookOperation struct {
+	Op     string            `json:"op" binding:"required,oneof=create update delete"`
+	Id     string            `json:"id" binding:"required_unless=Op create"`
+	Book   *CreateBook       `json:"book" binding:"required_if=Op create,omitempty"`
+	Fields *UpdateBookFields `json:"fields" binding:"required_if=Op update,omitempty"`
+}
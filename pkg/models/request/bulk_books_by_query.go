@@ -0,0 +1,7 @@
+package request
+
+type BulkBooksByQuery struct {
+	Action string            `json:"action" binding:"required,oneof=update delete"`
+	Fields *UpdateBookFields `json:"fields" binding:"required_if=Action update,omitempty"`
+	DryRun bool              `json:"dry_run"`
+}
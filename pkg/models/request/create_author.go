@@ -0,0 +1,7 @@
+package request
+
+type CreateAuthor struct {
+	Name    string   `json:"name" binding:"required"`
+	Aliases []string `json:"aliases" binding:"omitempty,dive,required"`
+	Bio     string   `json:"bio"`
+}
@@ -1,9 +1,20 @@
 package request
 
+import "pkg/service/pkg/models"
+
 type CreateBook struct {
-	Title          string  `json:"title" binding:"required"`
-	AuthorName     string  `json:"author_name" binding:"required"`
-	Price          float64 `json:"price" binding:"required"`
-	EbookAvailable bool    `json:"ebook_available" binding:"required"`
-	PublishDate    string  `json:"publish_date" binding:"required"`
+	Title          string              `json:"title" binding:"required"`
+	AuthorNames    []string            `json:"author_names" binding:"required,min=1,dive,required"`
+	Price          float64             `json:"price" binding:"required"`
+	EbookAvailable bool                `json:"ebook_available" binding:"required"`
+	PublishDate    *models.PublishDate `json:"publish_date" binding:"required"`
+	Isbn10         string              `json:"isbn_10" binding:"omitempty,isbn10"`
+	Isbn13         string              `json:"isbn_13" binding:"omitempty,isbn13"`
+	Publisher      string              `json:"publisher"`
+	Genres         []string            `json:"genres" binding:"omitempty,dive,required"`
+	Language       string              `json:"language" binding:"omitempty,bcp47_language_tag"`
+	PageCount      int                 `json:"page_count" binding:"omitempty,gt=0"`
+	Edition        string              `json:"edition"`
+	Description    string              `json:"description"`
+	CoverImageUrl  string              `json:"cover_image_url" binding:"omitempty,url"`
 }
@@ -0,0 +1,6 @@
+package request
+
+type CreateBranch struct {
+	Name    string `json:"name" binding:"required"`
+	Address string `json:"address" binding:"required"`
+}
@@ -0,0 +1,10 @@
+package request
+
+type CreateSavedSearch struct {
+	Name       string   `json:"name" binding:"required"`
+	Title      string   `json:"title"`
+	AuthorId   string   `json:"author_id"`
+	AuthorName string   `json:"author_name"`
+	MinPrice   *float64 `json:"min_price"`
+	MaxPrice   *float64 `json:"max_price"`
+}
@@ -0,0 +1,8 @@
+package request
+
+type CreateTransfer struct {
+	BookId       string `json:"book_id" binding:"required"`
+	FromBranchId string `json:"from_branch_id" binding:"required"`
+	ToBranchId   string `json:"to_branch_id" binding:"required,nefield=FromBranchId"`
+	Copies       int    `json:"copies" binding:"required,gt=0,max=1000"`
+}
@@ -1,7 +1,17 @@
 package request
 
+import "time"
+
 type CreateUserAction struct {
-	Username string
-	Method   string
-	Route    string
+	Username   string
+	Timestamp  time.Time
+	Method     string
+	Route      string
+	Path       string
+	BookId     string
+	ResourceId string
+	Status     int
+	ErrorCode  string
+	Latency    time.Duration
+	ClientIp   string
 }
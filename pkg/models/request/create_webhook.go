@@ -0,0 +1,8 @@
+package request
+
+type CreateWebhook struct {
+	Url        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=book.created book.updated book.deleted"`
+	// Secret signs the deliveries, one is generated when it is left out
+	Secret string `json:"secret" binding:"omitempty,min=16"`
+}
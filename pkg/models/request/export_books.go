@@ -0,0 +1,7 @@
+package request
+
+type ExportBooks struct {
+	GetBooks
+	Format string `form:"format" binding:"required,oneof=ndjson csv marcxml"`
+	Gzip   bool   `form:"gzip"`
+}
@@ -0,0 +1,11 @@
+package request
+
+type GetAnalyticsTop struct {
+	Window string `form:"window" binding:"omitempty,oneof=hour day week"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type GetTraffic struct {
+	Window   string `form:"window" binding:"omitempty,oneof=hour day week"`
+	Interval string `form:"interval" binding:"omitempty,oneof=hour day"`
+}
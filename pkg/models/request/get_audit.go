@@ -0,0 +1,12 @@
+package request
+
+import "time"
+
+type GetAudit struct {
+	BookId string    `form:"book_id"`
+	Actor  string    `form:"actor"`
+	Action string    `form:"action" binding:"omitempty,oneof=create update delete restore purge"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int       `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
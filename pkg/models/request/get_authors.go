@@ -0,0 +1,5 @@
+package request
+
+type GetAuthors struct {
+	Name string `form:"name"`
+}
@@ -1,8 +1,24 @@
 package request
 
 type GetBooks struct {
-	Title      string   `form:"title"`
-	AuthorName string   `form:"author_name"`
-	MinPrice   *float64 `form:"min_price"`
-	MaxPrice   *float64 `form:"max_price"`
+	Query           string   `form:"q"`
+	Title           string   `form:"title"`
+	AuthorId        string   `form:"author_id"`
+	AuthorName      string   `form:"author_name"`
+	MinPrice        *float64 `form:"min_price"`
+	MaxPrice        *float64 `form:"max_price"`
+	BranchId        string   `form:"branch_id"`
+	Isbn            string   `form:"isbn"`
+	Publisher       string   `form:"publisher"`
+	Genre           string   `form:"genre"`
+	Language        string   `form:"language"`
+	MinPages        *int     `form:"min_pages"`
+	MaxPages        *int     `form:"max_pages"`
+	PublishedAfter  string   `form:"published_after"`
+	PublishedBefore string   `form:"published_before"`
+	Ebook           *bool    `form:"ebook"`
+	Decade          *int     `form:"decade"`
+	Sort            string   `form:"sort" binding:"omitempty,oneof=publish_date -publish_date price -price"`
+	// Facets lists the facets to count next to the books, separated by commas
+	Facets string `form:"facets"`
 }
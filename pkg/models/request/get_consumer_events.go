@@ -0,0 +1,9 @@
+package request
+
+type GetConsumerEvents struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+type CommitConsumerOffset struct {
+	Offset string `json:"offset" binding:"required"`
+}
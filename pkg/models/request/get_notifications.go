@@ -0,0 +1,5 @@
+package request
+
+type GetNotifications struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=200"`
+}
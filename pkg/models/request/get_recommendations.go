@@ -0,0 +1,5 @@
+package request
+
+type GetRecommendations struct {
+	Size int `form:"size" binding:"omitempty,min=1,max=50"`
+}
@@ -0,0 +1,8 @@
+package request
+
+// GetStoreInventory takes the GET /books filters to compute the inventory
+// over a part of the catalog.
+type GetStoreInventory struct {
+	GetBooks
+	TopAuthors int `form:"top_authors" binding:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,10 @@
+package request
+
+import "time"
+
+type GetUserActivity struct {
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Offset int       `form:"offset" binding:"omitempty,min=0"`
+	Limit  int       `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
@@ -0,0 +1,7 @@
+package request
+
+type ImportBooks struct {
+	Format         string `form:"format" binding:"required,oneof=csv ndjson"`
+	ImportId       string `form:"import_id" binding:"omitempty,max=64,alphanum"`
+	AllowDuplicate bool   `form:"allow_duplicate"`
+}
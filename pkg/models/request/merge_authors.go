@@ -0,0 +1,5 @@
+package request
+
+type MergeAuthors struct {
+	SourceIds []string `json:"source_ids" binding:"required,min=1,dive,required"`
+}
@@ -0,0 +1,6 @@
+package request
+
+type StreamEvents struct {
+	Topics   string `form:"topics"`
+	Username string `form:"username"`
+}
@@ -0,0 +1,8 @@
+package request
+
+type SuggestBooks struct {
+	Prefix       string   `form:"prefix" binding:"required,max=100"`
+	Size         int      `form:"size" binding:"omitempty,min=1,max=50"`
+	TitleWeight  *float64 `form:"title_weight" binding:"omitempty,min=0"`
+	AuthorWeight *float64 `form:"author_weight" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,13 @@
+package request
+
+type UpdateBookFields struct {
+	Price          *float64 `json:"price" binding:"omitempty,gt=0"`
+	EbookAvailable *bool    `json:"ebook_available"`
+	Publisher      *string  `json:"publisher"`
+	Genres         []string `json:"genres" binding:"omitempty,dive,required"`
+	Language       *string  `json:"language" binding:"omitempty,bcp47_language_tag"`
+	PageCount      *int     `json:"page_count" binding:"omitempty,gt=0"`
+	Edition        *string  `json:"edition"`
+	Description    *string  `json:"description"`
+	CoverImageUrl  *string  `json:"cover_image_url" binding:"omitempty,url"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// RequestInfo identifies who made a change and in which request, for the
+// audit trail.
+type RequestInfo struct {
+	Username  string
+	RequestId string
+}
@@ -0,0 +1,5 @@
+package response
+
+type AddCopies struct {
+	CopyIds []string `json:"copy_ids"`
+}
@@ -0,0 +1,14 @@
+package response
+
+type BulkBooks struct {
+	Errors bool           `json:"errors"`
+	Items  []BulkBookItem `json:"items"`
+}
+
+type BulkBookItem struct {
+	Op         string `json:"op"`
+	Id         string `json:"id,omitempty"`
+	Status     int    `json:"status"`
+	Error      string `json:"error,omitempty"`
+	ExistingId string `json:"existing_id,omitempty"`
+}
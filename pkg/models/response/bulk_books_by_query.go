@@ -0,0 +1,12 @@
+package response
+
+type BulkBooksByQuery struct {
+	Action   string `json:"action"`
+	DryRun   bool   `json:"dry_run"`
+	Matched  int    `json:"matched"`
+	Affected int    `json:"affected"`
+	// Skipped books changed after they matched, Failed ones could not be
+	// written
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
@@ -0,0 +1,22 @@
+package response
+
+import (
+	"pkg/service/pkg/models"
+	"time"
+)
+
+type GetAnalyticsTop struct {
+	Window string                  `json:"window"`
+	From   time.Time               `json:"from"`
+	To     time.Time               `json:"to"`
+	Top    []models.AnalyticsCount `json:"top"`
+}
+
+type GetTraffic struct {
+	Window   string                 `json:"window"`
+	Interval string                 `json:"interval"`
+	From     time.Time              `json:"from"`
+	To       time.Time              `json:"to"`
+	Requests int                    `json:"requests"`
+	Buckets  []models.TrafficBucket `json:"buckets"`
+}
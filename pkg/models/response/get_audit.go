@@ -0,0 +1,7 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetAudit struct {
+	Entries []models.AuditEntry `json:"entries"`
+}
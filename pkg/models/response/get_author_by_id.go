@@ -0,0 +1,8 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetAuthorById struct {
+	Author models.Author `json:"author"`
+	Books  []models.Book `json:"books"`
+}
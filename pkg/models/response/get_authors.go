@@ -0,0 +1,7 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetAuthors struct {
+	Authors []models.Author `json:"authors"`
+}
@@ -3,5 +3,6 @@ package response
 import "pkg/service/pkg/models"
 
 type GetBooks struct {
-	Books []models.Book `json:"books"`
+	Books  []models.Book                   `json:"books"`
+	Facets map[string][]models.FacetBucket `json:"facets,omitempty"`
 }
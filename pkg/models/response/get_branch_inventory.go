@@ -0,0 +1,10 @@
+package response
+
+type GetBranchInventory struct {
+	BranchId        string `json:"branch_id"`
+	Books           int    `json:"books"`
+	Copies          int    `json:"copies"`
+	AvailableCopies int    `json:"available_copies"`
+	OutgoingCopies  int    `json:"outgoing_copies"`
+	IncomingCopies  int    `json:"incoming_copies"`
+}
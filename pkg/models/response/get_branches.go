@@ -0,0 +1,7 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetBranches struct {
+	Branches []models.Branch `json:"branches"`
+}
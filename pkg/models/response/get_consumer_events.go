@@ -0,0 +1,11 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetConsumerEvents struct {
+	Consumer string               `json:"consumer"`
+	Offset   string               `json:"offset"`
+	Events   []models.StreamEvent `json:"events"`
+	// NextOffset is the offset to commit once the events are handled
+	NextOffset string `json:"next_offset,omitempty"`
+}
@@ -0,0 +1,7 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetNotifications struct {
+	Notifications []models.Notification `json:"notifications"`
+}
@@ -0,0 +1,7 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetRecommendations struct {
+	Books []models.Recommendation `json:"books"`
+}
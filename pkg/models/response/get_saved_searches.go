@@ -0,0 +1,7 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetSavedSearches struct {
+	SavedSearches []models.SavedSearch `json:"saved_searches"`
+}
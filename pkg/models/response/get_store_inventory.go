@@ -1,6 +1,14 @@
 package response
 
+import "pkg/service/pkg/models"
+
 type GetBooksInventory struct {
-	Books   int `json:"books"`
-	Authors int `json:"authors"`
+	Books            int                  `json:"books"`
+	Authors          int                  `json:"authors"`
+	Ebooks           int                  `json:"ebooks"`
+	PrintOnly        int                  `json:"print_only"`
+	TotalValue       float64              `json:"total_value"`
+	Price            models.PriceStats    `json:"price"`
+	TopAuthors       []models.AuthorBooks `json:"top_authors"`
+	PublicationYears []models.YearBooks   `json:"publication_years"`
 }
@@ -1,5 +1,9 @@
 package response
 
+import "pkg/service/pkg/models"
+
 type GetUserActivity struct {
-	Actions []string `json:"actions"`
+	Events     []models.UserAction `json:"events"`
+	Total      int                 `json:"total"`
+	NextOffset *int                `json:"next_offset,omitempty"`
 }
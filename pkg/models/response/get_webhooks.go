@@ -0,0 +1,11 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type GetWebhooks struct {
+	Webhooks []models.WebhookSubscription `json:"webhooks"`
+}
+
+type GetWebhookDeliveries struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
@@ -0,0 +1,18 @@
+package response
+
+type ImportBooks struct {
+	ImportId       string            `json:"import_id"`
+	ResumedFromRow int               `json:"resumed_from_row,omitempty"`
+	Created        int               `json:"created"`
+	Skipped        int               `json:"skipped"`
+	Failed         int               `json:"failed"`
+	Rows           []ImportRowResult `json:"rows"`
+}
+
+type ImportRowResult struct {
+	Row        int    `json:"row"`
+	Status     string `json:"status"`
+	Id         string `json:"id,omitempty"`
+	ExistingId string `json:"existing_id,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
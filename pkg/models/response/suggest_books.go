@@ -0,0 +1,7 @@
+package response
+
+import "pkg/service/pkg/models"
+
+type SuggestBooks struct {
+	Suggestions []models.Suggestion `json:"suggestions"`
+}
@@ -0,0 +1,9 @@
+package response
+
+type Transfer struct {
+	Id           string   `json:"id"`
+	BookId       string   `json:"book_id"`
+	FromBranchId string   `json:"from_branch_id"`
+	ToBranchId   string   `json:"to_branch_id"`
+	CopyIds      []string `json:"copy_ids"`
+}
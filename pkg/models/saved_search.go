@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// SavedSearch is a named set of book filters a user wants to hear about when
+// new books arrive.
+type SavedSearch struct {
+	Id        string             `json:"id"`
+	Username  string             `json:"username"`
+	Name      string             `json:"name"`
+	Filters   SavedSearchFilters `json:"filters"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type SavedSearchFilters struct {
+	Title      string  `json:"title,omitempty"`
+	AuthorId   string  `json:"author_id,omitempty"`
+	AuthorName string  `json:"author_name,omitempty"`
+	MinPrice   float64 `json:"min_price,omitempty"`
+	MaxPrice   float64 `json:"max_price,omitempty"`
+}
+
+func (f SavedSearchFilters) BookFilters() BookFilters {
+	return BookFilters{
+		Title:      f.Title,
+		AuthorId:   f.AuthorId,
+		AuthorName: f.AuthorName,
+		MinPrice:   f.MinPrice,
+		MaxPrice:   f.MaxPrice,
+	}
+}
+
+// Notification tells a user that a new book matches one of their saved
+// searches.
+type Notification struct {
+	Username        string    `json:"username"`
+	SavedSearchId   string    `json:"saved_search_id"`
+	SavedSearchName string    `json:"saved_search_name"`
+	BookId          string    `json:"book_id"`
+	BookTitle       string    `json:"book_title"`
+	AuthorNames     []string  `json:"author_names"`
+	Price           float64   `json:"price"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func NewNotification(search SavedSearch, book Book, createdAt time.Time) Notification {
+	return Notification{
+		Username:        search.Username,
+		SavedSearchId:   search.Id,
+		SavedSearchName: search.Name,
+		BookId:          book.Id,
+		BookTitle:       book.Title,
+		AuthorNames:     book.AuthorNames,
+		Price:           book.Price,
+		CreatedAt:       createdAt,
+	}
+}
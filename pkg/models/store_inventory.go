@@ -1,6 +1,33 @@
 package models
 
+// InventoryPercentiles are the price percentiles reported in the inventory.
+var InventoryPercentiles = []float64{25, 50, 75, 95, 99}
+
 type StoreInventory struct {
-	TotalBooks    int
-	UniqueAuthors int
+	TotalBooks       int
+	UniqueAuthors    int
+	EbookBooks       int
+	PrintOnlyBooks   int
+	TotalValue       float64
+	Price            PriceStats
+	TopAuthors       []AuthorBooks
+	PublicationYears []YearBooks
+}
+
+type PriceStats struct {
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Avg         float64            `json:"avg"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+type AuthorBooks struct {
+	AuthorId   string `json:"author_id"`
+	AuthorName string `json:"author_name"`
+	Books      int    `json:"books"`
+}
+
+type YearBooks struct {
+	Year  int `json:"year"`
+	Books int `json:"books"`
 }
@@ -0,0 +1,8 @@
+package models
+
+// StreamEvent is a book event as stored in the event stream. Offsets are
+// opaque and only compared by the stream that issued them.
+type StreamEvent struct {
+	Offset string    `json:"offset"`
+	Event  BookEvent `json:"event"`
+}
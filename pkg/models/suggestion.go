@@ -0,0 +1,14 @@
+package models
+
+type Suggestion struct {
+	Text  string  `json:"text"`
+	Field string  `json:"field"`
+	Score float64 `json:"score"`
+}
+
+// SuggestWeights multiply the scores of the completions of each field. A zero
+// weight leaves the field out.
+type SuggestWeights struct {
+	Title  float64
+	Author float64
+}
@@ -0,0 +1,9 @@
+package models
+
+type Transfer struct {
+	Id           string
+	BookId       string
+	FromBranchId string
+	ToBranchId   string
+	CopyIds      []string
+}
@@ -1,6 +1,19 @@
 package models
 
+import "time"
+
+// UserAction is a single request made by a user, recorded once the response
+// has been written.
 type UserAction struct {
-	Username string
-	Action   string
+	Username   string    `json:"username"`
+	Timestamp  time.Time `json:"timestamp"`
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	Path       string    `json:"path"`
+	BookId     string    `json:"book_id,omitempty"`
+	ResourceId string    `json:"resource_id,omitempty"`
+	Status     int       `json:"status"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+	LatencyMs  float64   `json:"latency_ms"`
+	ClientIp   string    `json:"client_ip"`
 }
@@ -1,5 +1,6 @@
 package models
 
 type UserActivity struct {
-	Actions []string
+	Events []UserAction
+	Total  int
 }
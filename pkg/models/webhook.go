@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookSubscription struct {
+	Id         string    `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (s WebhookSubscription) Subscribes(eventType string) bool {
+	return containsString(s.EventTypes, eventType)
+}
+
+// WebhookDelivery is one event sent to one subscriber, with the outcome of
+// the latest attempt. Replays are new deliveries pointing at the original.
+type WebhookDelivery struct {
+	Id             string          `json:"id"`
+	SubscriptionId string          `json:"subscription_id"`
+	EventId        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	ReplayOf       string          `json:"replay_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
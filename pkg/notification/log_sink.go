@@ -0,0 +1,28 @@
+package notification
+
+import (
+	"log"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"strings"
+)
+
+var _ interfaces.NotificationSink = &LogSink{}
+
+type LogSink struct{}
+
+func NewLogSink() interfaces.NotificationSink {
+	return &LogSink{}
+}
+
+func (l *LogSink) Send(notifications []models.Notification) error {
+	for _, notification := range notifications {
+		log.Printf("notification for %s: %q by %s matches saved search %q",
+			notification.Username,
+			notification.BookTitle,
+			strings.Join(notification.AuthorNames, ", "),
+			notification.SavedSearchName,
+		)
+	}
+	return nil
+}
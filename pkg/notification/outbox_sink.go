@@ -0,0 +1,21 @@
+package notification
+
+import (
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+)
+
+var _ interfaces.NotificationSink = &OutboxSink{}
+
+// OutboxSink stores notifications for users to read them later.
+type OutboxSink struct {
+	notificationsRepository interfaces.NotificationsRepository
+}
+
+func NewOutboxSink(notificationsRepository interfaces.NotificationsRepository) interfaces.NotificationSink {
+	return &OutboxSink{notificationsRepository: notificationsRepository}
+}
+
+func (o *OutboxSink) Send(notifications []models.Notification) error {
+	return o.notificationsRepository.Save(notifications)
+}
@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"errors"
+	"log"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.ArrivalsNotifier = &SavedSearchNotifier{}
+
+// SavedSearchNotifier matches new books against the saved searches and sends
+// a notification for every match.
+type SavedSearchNotifier struct {
+	savedSearchesRepository interfaces.SavedSearchesRepository
+	sink                    interfaces.NotificationSink
+}
+
+func NewSavedSearchNotifier(savedSearchesRepository interfaces.SavedSearchesRepository, sink interfaces.NotificationSink) interfaces.ArrivalsNotifier {
+	return &SavedSearchNotifier{
+		savedSearchesRepository: savedSearchesRepository,
+		sink:                    sink,
+	}
+}
+
+// NotifyArrivals keeps going past books that fail to match, so one failure
+// does not hold back the notifications of the other books.
+func (s *SavedSearchNotifier) NotifyArrivals(books []models.Book) error {
+	createdAt := time.Now().UTC()
+	notifications := make([]models.Notification, 0)
+	failed := 0
+	for _, book := range books {
+		searches, err := s.savedSearchesRepository.Match(book)
+		if err != nil {
+			log.Printf("failed to match book %s against saved searches: %s", book.Id, err.Error())
+			failed++
+			continue
+		}
+		for _, search := range searches {
+			notifications = append(notifications, models.NewNotification(search, book, createdAt))
+		}
+	}
+
+	if len(notifications) > 0 {
+		if err := s.sink.Send(notifications); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return errors.New("error matching books against saved searches")
+	}
+	return nil
+}
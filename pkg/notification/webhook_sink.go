@@ -0,0 +1,48 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.NotificationSink = &WebhookSink{}
+
+// WebhookSink posts every batch of notifications as a JSON array to a
+// receiver, which has to answer with a 2xx status.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) interfaces.NotificationSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: consts.NotificationWebhookTimeout * time.Second},
+	}
+}
+
+func (w *WebhookSink) Send(notifications []models.Notification) error {
+	body, err := json.Marshal(notifications)
+	if err != nil {
+		return err
+	}
+
+	res, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("error posting %d notifications: %s", len(notifications), err)
+		return fmt.Errorf("error posting notifications to %s", w.url)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("notification webhook answered with status %d", res.StatusCode)
+	}
+	return nil
+}
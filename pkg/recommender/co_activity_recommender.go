@@ -0,0 +1,59 @@
+package recommender
+
+import (
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+)
+
+var _ interfaces.Recommender = &CoActivityRecommender{}
+
+// CoActivityRecommender recommends the books that the users who viewed a
+// book also viewed, scored by how many of them did.
+type CoActivityRecommender struct {
+	analyticsRepository interfaces.AnalyticsRepository
+	booksRepository     interfaces.BooksRepository
+}
+
+func NewCoActivityRecommender(analyticsRepository interfaces.AnalyticsRepository, booksRepository interfaces.BooksRepository) interfaces.Recommender {
+	return &CoActivityRecommender{
+		analyticsRepository: analyticsRepository,
+		booksRepository:     booksRepository,
+	}
+}
+
+func (c *CoActivityRecommender) Recommend(book models.Book, size int) ([]models.Recommendation, error) {
+	// Some of the viewed books may have been deleted since, so ask for more
+	coViewed, err := c.analyticsRepository.CoViewed(book.Id, size*2)
+	if err != nil {
+		return nil, err
+	}
+	if len(coViewed) == 0 {
+		return make([]models.Recommendation, 0), nil
+	}
+
+	ids := make([]string, 0, len(coViewed))
+	for _, count := range coViewed {
+		ids = append(ids, count.Key)
+	}
+	books, err := c.booksRepository.Get(models.BookFilters{Ids: ids})
+	if err != nil {
+		return nil, err
+	}
+	booksById := make(map[string]models.Book)
+	for _, found := range *books {
+		booksById[found.Id] = found
+	}
+
+	recommendations := make([]models.Recommendation, 0, size)
+	for _, count := range coViewed {
+		found, ok := booksById[count.Key]
+		if !ok {
+			continue
+		}
+		recommendations = append(recommendations, models.Recommendation{Book: found, Score: float64(count.Count)})
+		if len(recommendations) == size {
+			break
+		}
+	}
+	return recommendations, nil
+}
@@ -0,0 +1,126 @@
+package recommender
+
+import (
+	"fmt"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	analytics_repository "pkg/service/pkg/repository/analytics/memory"
+	books_repository "pkg/service/pkg/repository/books/memory"
+	"testing"
+	"time"
+)
+
+// coActivityFixture is a book viewed by four users together with books
+// viewed by four, three, two and one of them.
+type coActivityFixture struct {
+	booksRepository interfaces.BooksRepository
+	recommender     interfaces.Recommender
+	book            models.Book
+	ids             map[string]string
+}
+
+func newCoActivityFixture(t *testing.T) coActivityFixture {
+	t.Helper()
+
+	booksRepository := books_repository.NewBooksRepositoryMemory()
+	analyticsRepository := analytics_repository.NewAnalyticsRepositoryMemory(time.Hour)
+
+	ids := make(map[string]string)
+	for _, title := range []string{"viewed", "four", "three", "two", "one"} {
+		id, err := booksRepository.Create(models.BookSource{Title: title, Price: 10})
+		if err != nil {
+			t.Fatalf("create %s: %s", title, err)
+		}
+		ids[title] = id
+	}
+
+	viewers := map[string]int{"viewed": 4, "four": 4, "three": 3, "two": 2, "one": 1}
+	actions := make([]models.UserAction, 0)
+	at := time.Now()
+	for title, count := range viewers {
+		for i := 0; i < count; i++ {
+			actions = append(actions, models.UserAction{
+				Username:  fmt.Sprintf("user-%d", i),
+				Timestamp: at,
+				Method:    http.MethodGet,
+				Route:     consts.GetBookUrlPath,
+				BookId:    ids[title],
+				Status:    http.StatusOK,
+			})
+		}
+	}
+	if err := analyticsRepository.RecordActions(actions); err != nil {
+		t.Fatalf("record actions: %s", err)
+	}
+
+	book, err := booksRepository.GetById(ids["viewed"])
+	if err != nil {
+		t.Fatalf("get viewed book: %s", err)
+	}
+	return coActivityFixture{
+		booksRepository: booksRepository,
+		recommender:     NewCoActivityRecommender(analyticsRepository, booksRepository),
+		book:            *book,
+		ids:             ids,
+	}
+}
+
+func (f coActivityFixture) assertRecommended(t *testing.T, size int, titles []string, scores []float64) {
+	t.Helper()
+
+	recommendations, err := f.recommender.Recommend(f.book, size)
+	if err != nil {
+		t.Fatalf("recommend: %s", err)
+	}
+	if len(recommendations) != len(titles) {
+		t.Fatalf("got %d recommendations, want %d: %+v", len(recommendations), len(titles), recommendations)
+	}
+	for i, recommendation := range recommendations {
+		if recommendation.Book.Id != f.ids[titles[i]] || recommendation.Score != scores[i] {
+			t.Errorf("recommendation %d is %q scored %g, want %q scored %g",
+				i, recommendation.Book.Title, recommendation.Score, titles[i], scores[i])
+		}
+	}
+}
+
+func TestCoActivityRecommenderOrdersByViewers(t *testing.T) {
+	f := newCoActivityFixture(t)
+	f.assertRecommended(t, 10, []string{"four", "three", "two", "one"}, []float64{4, 3, 2, 1})
+}
+
+func TestCoActivityRecommenderTruncatesToSize(t *testing.T) {
+	f := newCoActivityFixture(t)
+	f.assertRecommended(t, 2, []string{"four", "three"}, []float64{4, 3})
+}
+
+func TestCoActivityRecommenderSkipsDeletedBooks(t *testing.T) {
+	f := newCoActivityFixture(t)
+	if err := f.booksRepository.Delete(f.ids["four"], nil, "admin"); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+	if err := f.booksRepository.Delete(f.ids["two"], nil, "admin"); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+	if err := f.booksRepository.Purge(f.ids["two"]); err != nil {
+		t.Fatalf("purge: %s", err)
+	}
+
+	f.assertRecommended(t, 10, []string{"three", "one"}, []float64{3, 1})
+	// The books skipped are made up for from the extra candidates
+	f.assertRecommended(t, 2, []string{"three", "one"}, []float64{3, 1})
+}
+
+func TestCoActivityRecommenderWithoutViews(t *testing.T) {
+	f := newCoActivityFixture(t)
+	recommender := NewCoActivityRecommender(analytics_repository.NewAnalyticsRepositoryMemory(time.Hour), f.booksRepository)
+
+	recommendations, err := recommender.Recommend(f.book, 5)
+	if err != nil {
+		t.Fatalf("recommend: %s", err)
+	}
+	if recommendations == nil || len(recommendations) != 0 {
+		t.Errorf("got %+v, want no recommendations", recommendations)
+	}
+}
@@ -0,0 +1,24 @@
+package recommender
+
+import (
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+)
+
+var _ interfaces.Recommender = &SimilarRecommender{}
+
+// SimilarRecommender recommends books by their content, as scored by the
+// books repository.
+type SimilarRecommender struct {
+	booksRepository interfaces.BooksRepository
+}
+
+func NewSimilarRecommender(booksRepository interfaces.BooksRepository) interfaces.Recommender {
+	return &SimilarRecommender{
+		booksRepository: booksRepository,
+	}
+}
+
+func (s *SimilarRecommender) Recommend(book models.Book, size int) ([]models.Recommendation, error) {
+	return s.booksRepository.FindSimilar(book, size)
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+	"time"
+)
+
+var _ interfaces.AnalyticsRepository = &AnalyticsRepositoryMemory{}
+
+// AnalyticsRepositoryMemory keeps a counter per key in every hourly bucket of
+// each dimension, like the sorted sets in Redis.
+type AnalyticsRepositoryMemory struct {
+	retention time.Duration
+	mu        sync.Mutex
+	buckets   map[string]map[time.Time]map[string]int
+	viewers   map[string]map[string]time.Time
+	viewed    map[string]map[string]time.Time
+}
+
+func NewAnalyticsRepositoryMemory(retention time.Duration) interfaces.AnalyticsRepository {
+	return &AnalyticsRepositoryMemory{
+		retention: retention,
+		buckets: map[string]map[time.Time]map[string]int{
+			consts.AnalyticsDimensionBooks:  {},
+			consts.AnalyticsDimensionUsers:  {},
+			consts.AnalyticsDimensionRoutes: {},
+		},
+		viewers: make(map[string]map[string]time.Time),
+		viewed:  make(map[string]map[string]time.Time),
+	}
+}
+
+func (m *AnalyticsRepositoryMemory) RecordActions(actions []models.UserAction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, ua := range actions {
+		hour := ua.Timestamp.UTC().Truncate(time.Hour)
+		for dimension, key := range ua.AnalyticsKeys() {
+			if m.buckets[dimension][hour] == nil {
+				m.buckets[dimension][hour] = make(map[string]int)
+			}
+			m.buckets[dimension][hour][key]++
+		}
+		if ua.IsBookView() {
+			addRecent(m.viewers, ua.BookId, ua.Username, ua.Timestamp)
+			addRecent(m.viewed, ua.Username, ua.BookId, ua.Timestamp)
+		}
+	}
+
+	cutoff := time.Now().Add(-m.retention)
+	for _, hours := range m.buckets {
+		for hour := range hours {
+			if hour.Before(cutoff) {
+				delete(hours, hour)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *AnalyticsRepositoryMemory) Top(dimension string, from time.Time, to time.Time, limit int) ([]models.AnalyticsCount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totals := make(map[string]int)
+	for _, hour := range models.AnalyticsHours(from, to) {
+		for key, count := range m.buckets[dimension][hour] {
+			totals[key] += count
+		}
+	}
+
+	top := make([]models.AnalyticsCount, 0, len(totals))
+	for key, count := range totals {
+		top = append(top, models.AnalyticsCount{Key: key, Count: count})
+	}
+	models.SortAnalyticsCounts(top)
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+func (m *AnalyticsRepositoryMemory) Traffic(from time.Time, to time.Time) ([]models.TrafficBucket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buckets := make([]models.TrafficBucket, 0)
+	for _, hour := range models.AnalyticsHours(from, to) {
+		bucket := models.TrafficBucket{Start: hour, Routes: make([]models.AnalyticsCount, 0)}
+		for route, count := range m.buckets[consts.AnalyticsDimensionRoutes][hour] {
+			bucket.Routes = append(bucket.Routes, models.AnalyticsCount{Key: route, Count: count})
+			bucket.Requests += count
+		}
+		models.SortAnalyticsCounts(bucket.Routes)
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// CoViewed counts the other books viewed by the recent viewers of the book.
+func (m *AnalyticsRepositoryMemory) CoViewed(bookId string, size int) ([]models.AnalyticsCount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[string]int)
+	for username := range m.viewers[bookId] {
+		for viewedId := range m.viewed[username] {
+			if viewedId != bookId {
+				counts[viewedId]++
+			}
+		}
+	}
+
+	coViewed := make([]models.AnalyticsCount, 0, len(counts))
+	for key, count := range counts {
+		coViewed = append(coViewed, models.AnalyticsCount{Key: key, Count: count})
+	}
+	models.SortAnalyticsCounts(coViewed)
+	if len(coViewed) > size {
+		coViewed = coViewed[:size]
+	}
+	return coViewed, nil
+}
+
+// addRecent keeps the latest time of each member, and only the newest
+// members, like a sorted set trimmed by rank.
+func addRecent(sets map[string]map[string]time.Time, key string, member string, at time.Time) {
+	if sets[key] == nil {
+		sets[key] = make(map[string]time.Time)
+	}
+	if at.After(sets[key][member]) {
+		sets[key][member] = at
+	}
+
+	for len(sets[key]) > consts.CoViewHistory {
+		oldest := ""
+		for candidate, candidateAt := range sets[key] {
+			if oldest == "" || candidateAt.Before(sets[key][oldest]) {
+				oldest = candidate
+			}
+		}
+		delete(sets[key], oldest)
+	}
+}
@@ -0,0 +1,198 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.AnalyticsRepository = &AnalyticsRepositoryRedis{}
+
+// AnalyticsRepositoryRedis keeps a sorted set per dimension and hour, scored
+// by the number of requests, which expires once it falls out of retention.
+type AnalyticsRepositoryRedis struct {
+	retention time.Duration
+}
+
+func NewAnalyticsRepositoryRedis(retention time.Duration) interfaces.AnalyticsRepository {
+	return &AnalyticsRepositoryRedis{
+		retention: retention,
+	}
+}
+
+func (r *AnalyticsRepositoryRedis) RecordActions(actions []models.UserAction) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+
+	expiring := make(map[string]time.Time)
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, ua := range actions {
+			hour := ua.Timestamp.UTC().Truncate(time.Hour)
+			for dimension, key := range ua.AnalyticsKeys() {
+				bucketKey := createBucketKey(dimension, hour)
+				pipe.ZIncrBy(ctx, bucketKey, 1, key)
+				expiring[bucketKey] = hour
+			}
+			if ua.IsBookView() {
+				viewed := float64(ua.Timestamp.UnixMilli())
+				r.addRecent(ctx, pipe, createBookViewersKey(ua.BookId), &redis.Z{Score: viewed, Member: ua.Username})
+				r.addRecent(ctx, pipe, createUserBooksKey(ua.Username), &redis.Z{Score: viewed, Member: ua.BookId})
+			}
+		}
+		for bucketKey, hour := range expiring {
+			pipe.ExpireAt(ctx, bucketKey, hour.Add(time.Hour+r.retention))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error recording %d actions in analytics: %s", len(actions), err)
+		return errors.New("error recording analytics")
+	}
+
+	return nil
+}
+
+// Top adds up the hourly buckets in a temporary sorted set and reads its
+// highest counts.
+func (r *AnalyticsRepositoryRedis) Top(dimension string, from time.Time, to time.Time, limit int) ([]models.AnalyticsCount, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	unionKey, err := createUnionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+
+	var top *redis.ZSliceCmd
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZUnionStore(ctx, unionKey, &redis.ZStore{Keys: createBucketKeys(dimension, from, to)})
+		top = pipe.ZRevRangeWithScores(ctx, unionKey, 0, int64(limit-1))
+		pipe.Del(ctx, unionKey)
+		return nil
+	})
+	if err != nil {
+		log.Printf("error getting top %s: %s", dimension, err)
+		return nil, errors.New("error getting top " + dimension)
+	}
+
+	counts := toAnalyticsCounts(top.Val())
+	models.SortAnalyticsCounts(counts)
+	return counts, nil
+}
+
+func (r *AnalyticsRepositoryRedis) Traffic(from time.Time, to time.Time) ([]models.TrafficBucket, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+
+	hours := models.AnalyticsHours(from, to)
+	routes := make([]*redis.ZSliceCmd, 0, len(hours))
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, hour := range hours {
+			routes = append(routes, pipe.ZRevRangeWithScores(ctx, createBucketKey(consts.AnalyticsDimensionRoutes, hour), 0, -1))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error getting traffic: %s", err)
+		return nil, errors.New("error getting traffic")
+	}
+
+	buckets := make([]models.TrafficBucket, 0, len(hours))
+	for i, hour := range hours {
+		bucket := models.TrafficBucket{Start: hour, Routes: toAnalyticsCounts(routes[i].Val())}
+		for _, route := range bucket.Routes {
+			bucket.Requests += route.Count
+		}
+		models.SortAnalyticsCounts(bucket.Routes)
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// CoViewed counts the other books viewed by the recent viewers of the book.
+func (r *AnalyticsRepositoryRedis) CoViewed(bookId string, size int) ([]models.AnalyticsCount, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+
+	viewers, err := client.ZRevRange(ctx, createBookViewersKey(bookId), 0, consts.CoViewHistory-1).Result()
+	if err != nil {
+		log.Printf("error getting viewers of book %s: %s", bookId, err)
+		return nil, errors.New("error getting co-viewed books")
+	}
+
+	viewed := make([]*redis.StringSliceCmd, 0, len(viewers))
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, username := range viewers {
+			viewed = append(viewed, pipe.ZRevRange(ctx, createUserBooksKey(username), 0, consts.CoViewHistory-1))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error getting books viewed with book %s: %s", bookId, err)
+		return nil, errors.New("error getting co-viewed books")
+	}
+
+	counts := make(map[string]int)
+	for _, books := range viewed {
+		for _, viewedId := range books.Val() {
+			if viewedId != bookId {
+				counts[viewedId]++
+			}
+		}
+	}
+
+	coViewed := make([]models.AnalyticsCount, 0, len(counts))
+	for key, count := range counts {
+		coViewed = append(coViewed, models.AnalyticsCount{Key: key, Count: count})
+	}
+	models.SortAnalyticsCounts(coViewed)
+	if len(coViewed) > size {
+		coViewed = coViewed[:size]
+	}
+	return coViewed, nil
+}
+
+// addRecent adds a member to a sorted set scored by time, trimmed to the
+// newest members and expiring with the retention.
+func (r *AnalyticsRepositoryRedis) addRecent(ctx context.Context, pipe redis.Pipeliner, key string, member *redis.Z) {
+	pipe.ZAdd(ctx, key, member)
+	pipe.ZRemRangeByRank(ctx, key, 0, -consts.CoViewHistory-1)
+	pipe.Expire(ctx, key, r.retention)
+}
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"os"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+func newRedisClient() (*redis.Client, error) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = consts.DefaultRedisAddress
+	}
+	options := &redis.Options{
+		Addr:     addr,
+		Password: "",
+		DB:       0,
+	}
+
+	client := redis.NewClient(options)
+	if _, err := client.Ping(context.Background()).Result(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func createBucketKey(dimension string, hour time.Time) string {
+	return fmt.Sprintf(consts.AnalyticsRedisKey, dimension, hour.Unix())
+}
+
+func createBookViewersKey(bookId string) string {
+	return fmt.Sprintf(consts.AnalyticsBookViewersRedisKey, bookId)
+}
+
+func createUserBooksKey(username string) string {
+	return fmt.Sprintf(consts.AnalyticsUserBooksRedisKey, username)
+}
+
+func createBucketKeys(dimension string, from time.Time, to time.Time) []string {
+	hours := models.AnalyticsHours(from, to)
+	keys := make([]string, 0, len(hours))
+	for _, hour := range hours {
+		keys = append(keys, createBucketKey(dimension, hour))
+	}
+	return keys
+}
+
+func createUnionKey() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(consts.AnalyticsRedisUnionKey, hex.EncodeToString(b)), nil
+}
+
+func toAnalyticsCounts(members []redis.Z) []models.AnalyticsCount {
+	counts := make([]models.AnalyticsCount, 0, len(members))
+	for _, member := range members {
+		counts = append(counts, models.AnalyticsCount{Key: fmt.Sprint(member.Member), Count: int(member.Score)})
+	}
+	return counts
+}
@@ -0,0 +1,109 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.AuditRepository = &AuditRepositoryElastic{}
+
+type AuditRepositoryElastic struct {
+	index string
+}
+
+func NewAuditRepositoryElastic(indexName string) interfaces.AuditRepository {
+	return &AuditRepositoryElastic{index: indexName}
+}
+
+func (e *AuditRepositoryElastic) Save(entries []models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	bulk := client.Bulk().Index(e.index)
+	for _, entry := range entries {
+		bulk.Add(elastic.NewBulkIndexRequest().Doc(entry))
+	}
+
+	res, err := bulk.Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).Do(context.Background())
+	if err != nil {
+		log.Printf("error saving audit entries: %s", err)
+		return errors.New("error saving audit entries")
+	}
+	if res.Errors {
+		log.Printf("error saving audit entries: %d failed", len(res.Failed()))
+		return errors.New("error saving audit entries")
+	}
+
+	return nil
+}
+
+func (e *AuditRepositoryElastic) Find(filters models.AuditFilters) ([]models.AuditEntry, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(createAuditFetchQuery(filters)).
+		SortBy(elastic.NewFieldSort("timestamp").Desc()).
+		Size(filters.Limit).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error searching audit entries: %s", err)
+		return nil, errors.New("error searching audit entries")
+	}
+
+	entries := make([]models.AuditEntry, 0, len(searchResult.Hits.Hits))
+	for _, hit := range searchResult.Hits.Hits {
+		entry := models.AuditEntry{}
+		if err = json.Unmarshal(hit.Source, &entry); err != nil {
+			return nil, err
+		}
+		entry.Id = hit.Id
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func createAuditFetchQuery(filters models.AuditFilters) *elastic.BoolQuery {
+	boolQuery := elastic.NewBoolQuery()
+	if filters.BookId != "" {
+		boolQuery = boolQuery.Filter(elastic.NewTermQuery("book_id", filters.BookId))
+	}
+	if filters.Actor != "" {
+		boolQuery = boolQuery.Filter(elastic.NewTermQuery("actor", filters.Actor))
+	}
+	if filters.Action != "" {
+		boolQuery = boolQuery.Filter(elastic.NewTermQuery("action", filters.Action))
+	}
+	if !filters.From.IsZero() || !filters.To.IsZero() {
+		rangeQuery := elastic.NewRangeQuery("timestamp")
+		if !filters.From.IsZero() {
+			rangeQuery = rangeQuery.Gte(filters.From.Format(time.RFC3339Nano))
+		}
+		if !filters.To.IsZero() {
+			rangeQuery = rangeQuery.Lte(filters.To.Format(time.RFC3339Nano))
+		}
+		boolQuery = boolQuery.Filter(rangeQuery)
+	}
+	return boolQuery
+}
@@ -0,0 +1,54 @@
+package elastic
+
+import (
+	"context"
+	"errors"
+	"log"
+	"pkg/service/pkg/consts"
+	"time"
+)
+
+// Field values change type from one field to the next, so the changes are
+// kept in the source without being indexed.
+func auditIndexBody() map[string]interface{} {
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"book_id":    map[string]interface{}{"type": "keyword"},
+				"action":     map[string]interface{}{"type": "keyword"},
+				"actor":      map[string]interface{}{"type": "keyword"},
+				"request_id": map[string]interface{}{"type": "keyword"},
+				"timestamp":  map[string]interface{}{"type": "date"},
+				"changes":    map[string]interface{}{"type": "object", "enabled": false},
+			},
+		},
+	}
+}
+
+// EnsureIndex creates the audit index if it does not exist yet.
+func EnsureIndex(indexName string) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+
+	exists, err := client.IndexExists(indexName).Do(ctx)
+	if err != nil {
+		log.Printf("error checking audit index: %s", err)
+		return errors.New("error checking audit index")
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err = client.CreateIndex(indexName).BodyJson(auditIndexBody()).Do(ctx); err != nil {
+		log.Printf("error creating audit index: %s", err)
+		return errors.New("error creating audit index")
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"fmt"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+)
+
+var _ interfaces.AuditRepository = &AuditRepositoryMemory{}
+
+type AuditRepositoryMemory struct {
+	mu      sync.RWMutex
+	entries []models.AuditEntry
+}
+
+func NewAuditRepositoryMemory() interfaces.AuditRepository {
+	return &AuditRepositoryMemory{}
+}
+
+func (m *AuditRepositoryMemory) Save(entries []models.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entry := range entries {
+		entry.Id = fmt.Sprintf("%d", len(m.entries)+1)
+		m.entries = append(m.entries, entry)
+	}
+	return nil
+}
+
+// Find returns the newest matching entries first, like the Elastic repository.
+func (m *AuditRepositoryMemory) Find(filters models.AuditFilters) ([]models.AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]models.AuditEntry, 0)
+	for i := len(m.entries) - 1; i >= 0 && len(entries) < filters.Limit; i-- {
+		if filters.Matches(m.entries[i]) {
+			entries = append(entries, m.entries[i])
+		}
+	}
+	return entries, nil
+}
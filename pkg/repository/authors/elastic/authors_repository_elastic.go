@@ -0,0 +1,189 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.AuthorsRepository = &AuthorsRepositoryElastic{}
+
+type AuthorsRepositoryElastic struct {
+	index string
+}
+
+func NewAuthorsRepositoryElastic(indexName string) interfaces.AuthorsRepository {
+	return &AuthorsRepositoryElastic{index: indexName}
+}
+
+func (e *AuthorsRepositoryElastic) Create(author models.AuthorSource) (string, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return "", err
+	}
+	defer client.Stop()
+
+	createResult, err := client.Index().
+		Index(e.index).
+		BodyJson(author).
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error creating author: %s", err)
+		return "", errors.New("error creating author")
+	}
+
+	return createResult.Id, nil
+}
+
+func (e *AuthorsRepositoryElastic) Get(name string) (*[]models.Author, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	var query elastic.Query = elastic.NewMatchAllQuery()
+	if name != "" {
+		query = elastic.NewMultiMatchQuery(name, "name", "aliases")
+	}
+
+	return e.search(client, query, consts.BooksQuerySize)
+}
+
+func (e *AuthorsRepositoryElastic) GetById(authorId string) (*models.Author, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+	res, err := client.Get().
+		Index(e.index).
+		Id(authorId).
+		Do(ctx)
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			log.Printf("author not found: %s", err)
+			return nil, &models.NotFoundError{Resource: "author", Id: authorId}
+		}
+		return nil, err
+	}
+
+	author := models.Author{}
+	err = json.Unmarshal(res.Source, &author)
+	if err != nil {
+		return nil, err
+	}
+
+	author.Id = res.Id
+	return &author, nil
+}
+
+func (e *AuthorsRepositoryElastic) FindByName(name string) (*models.Author, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	query := elastic.NewBoolQuery().
+		Should(elastic.NewTermQuery("name.keyword", name), elastic.NewTermQuery("aliases.keyword", name)).
+		MinimumNumberShouldMatch(1)
+
+	authors, err := e.search(client, query, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(*authors) == 0 {
+		return nil, nil
+	}
+
+	return &(*authors)[0], nil
+}
+
+func (e *AuthorsRepositoryElastic) Update(authorId string, author models.AuthorSource) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	_, err = client.Index().
+		Index(e.index).
+		Id(authorId).
+		BodyJson(author).
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error updating author: %s", err)
+		return errors.New("error updating author")
+	}
+
+	return nil
+}
+
+func (e *AuthorsRepositoryElastic) Delete(authorId string) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	_, err = client.Delete().
+		Index(e.index).
+		Id(authorId).
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			log.Printf("error deleting author - author not found")
+			return &models.NotFoundError{Resource: "author", Id: authorId}
+		}
+		log.Printf("error deleting author: %s", err)
+		return errors.New("error deleting author")
+	}
+
+	return nil
+}
+
+func (e *AuthorsRepositoryElastic) search(client *elastic.Client, query elastic.Query, size int) (*[]models.Author, error) {
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(query).
+		Size(size).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error searching authors: %s", err)
+		return nil, errors.New("error searching authors")
+	}
+
+	authors := make([]models.Author, 0)
+	for _, hit := range searchResult.Hits.Hits {
+		author := models.Author{Id: hit.Id}
+		err = json.Unmarshal(hit.Source, &author)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+	return &authors, nil
+}
@@ -0,0 +1,67 @@
+package elastic
+
+import (
+	"context"
+	"errors"
+	"log"
+	"pkg/service/pkg/consts"
+	"time"
+)
+
+func authorsIndexBody() map[string]interface{} {
+	nameField := map[string]interface{}{
+		"type": "text",
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "keyword", "normalizer": "author_name_normalizer"},
+		},
+	}
+
+	return map[string]interface{}{
+		"settings": map[string]interface{}{
+			"analysis": map[string]interface{}{
+				"normalizer": map[string]interface{}{
+					"author_name_normalizer": map[string]interface{}{
+						"type":   "custom",
+						"filter": []string{"lowercase", "asciifolding"},
+					},
+				},
+			},
+		},
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"name":    nameField,
+				"aliases": nameField,
+				"bio":     map[string]interface{}{"type": "text"},
+			},
+		},
+	}
+}
+
+// EnsureIndex creates the authors index if it does not exist yet. Names and
+// aliases are matched through a case and accent insensitive keyword field.
+func EnsureIndex(indexName string) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+
+	exists, err := client.IndexExists(indexName).Do(ctx)
+	if err != nil {
+		log.Printf("error checking authors index: %s", err)
+		return errors.New("error checking authors index")
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err = client.CreateIndex(indexName).BodyJson(authorsIndexBody()).Do(ctx); err != nil {
+		log.Printf("error creating authors index: %s", err)
+		return errors.New("error creating authors index")
+	}
+
+	return nil
+}
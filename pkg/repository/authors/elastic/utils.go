@@ -0,0 +1,20 @@
+package elastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic/v7"
+	"os"
+)
+
+func getElasticClient() (*elastic.Client, error) {
+	url := os.Getenv("ELASTICSEARCH_URL")
+	if url == "" {
+		return nil, errors.New("cannot find elastic url in the environment")
+	}
+	client, err := elastic.NewClient(elastic.SetURL(url))
+	if err != nil {
+		return nil, err
+	}
+
+	return client, err
+}
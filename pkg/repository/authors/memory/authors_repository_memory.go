@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"strings"
+	"sync"
+)
+
+var _ interfaces.AuthorsRepository = &AuthorsRepositoryMemory{}
+
+// AuthorsRepositoryMemory keeps authors in process memory, for running with
+// the memory books backend.
+type AuthorsRepositoryMemory struct {
+	mu      sync.RWMutex
+	authors map[string]models.AuthorSource
+	ids     []string
+}
+
+func NewAuthorsRepositoryMemory() interfaces.AuthorsRepository {
+	return &AuthorsRepositoryMemory{authors: make(map[string]models.AuthorSource)}
+}
+
+func (m *AuthorsRepositoryMemory) Create(author models.AuthorSource) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := newId()
+	m.authors[id] = author
+	m.ids = append(m.ids, id)
+	return id, nil
+}
+
+// Get matches the name against the words of the names and aliases, like the
+// match query in Elastic.
+func (m *AuthorsRepositoryMemory) Get(name string) (*[]models.Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	words := strings.Fields(strings.ToLower(name))
+	authors := make([]models.Author, 0)
+	for _, id := range m.ids {
+		author := m.authors[id]
+		if len(words) > 0 && !matchesAnyWord(author, words) {
+			continue
+		}
+		authors = append(authors, newAuthor(id, author))
+		if len(authors) == consts.BooksQuerySize {
+			break
+		}
+	}
+	return &authors, nil
+}
+
+func (m *AuthorsRepositoryMemory) GetById(authorId string) (*models.Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	author, found := m.authors[authorId]
+	if !found {
+		return nil, &models.NotFoundError{Resource: "author", Id: authorId}
+	}
+	res := newAuthor(authorId, author)
+	return &res, nil
+}
+
+func (m *AuthorsRepositoryMemory) FindByName(name string) (*models.Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.ids {
+		author := m.authors[id]
+		if author.Name == name || containsString(author.Aliases, name) {
+			res := newAuthor(id, author)
+			return &res, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *AuthorsRepositoryMemory) Update(authorId string, author models.AuthorSource) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.authors[authorId]; !found {
+		m.ids = append(m.ids, authorId)
+	}
+	m.authors[authorId] = author
+	return nil
+}
+
+func (m *AuthorsRepositoryMemory) Delete(authorId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.authors[authorId]; !found {
+		return &models.NotFoundError{Resource: "author", Id: authorId}
+	}
+	delete(m.authors, authorId)
+	for i, id := range m.ids {
+		if id == authorId {
+			m.ids = append(m.ids[:i], m.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
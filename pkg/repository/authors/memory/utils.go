@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"pkg/service/pkg/models"
+	"strings"
+)
+
+func newId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newAuthor(id string, source models.AuthorSource) models.Author {
+	return models.Author{Id: id, Name: source.Name, Aliases: source.Aliases, Bio: source.Bio}
+}
+
+func matchesAnyWord(author models.AuthorSource, words []string) bool {
+	texts := append([]string{author.Name}, author.Aliases...)
+	for _, text := range texts {
+		for _, field := range strings.Fields(strings.ToLower(text)) {
+			for _, word := range words {
+				if field == word {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+package cached
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	cache_memory "pkg/service/pkg/repository/cache/memory"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var _ interfaces.BooksRepository = &BooksRepositoryCached{}
+
+// notFound is cached for books the backing repository does not have, so
+// lookups of missing ids do not reach it either.
+var notFound = []byte("null")
+
+// Tier is a cache tier with how long books and missing books are kept in it.
+// Tiers are read in order, so faster and smaller tiers go first.
+type Tier struct {
+	Name    string
+	Store   interfaces.CacheStore
+	Ttl     time.Duration
+	MissTtl time.Duration
+}
+
+// BooksRepositoryCached caches the books looked up by id in front of the
+// backing repository. Besides lookups by id, only the store inventory is
+// cached, in process and for inventoryTtl: other query results depend on
+// every book and are cheap enough to always go to the backing repository.
+//
+// Writes through this repository invalidate the books they touch in every
+// tier. Tiers local to another instance are not invalidated, so their Ttl
+// bounds how stale they get. Shared tiers are only filled while the book is
+// at the version read before loading it, so a load that raced a write on
+// another instance does not put back what it read. Every write clears the
+// cached inventories, since any book may count towards any of them.
+type BooksRepositoryCached struct {
+	backing      interfaces.BooksRepository
+	tiers        []Tier
+	inventory    interfaces.CacheStore
+	inventoryTtl time.Duration
+	loads        group
+	// invalidations is bumped by every invalidation, so a load that raced
+	// one does not cache what it read before it.
+	invalidations uint64
+}
+
+func NewBooksRepositoryCached(backing interfaces.BooksRepository, inventoryTtl time.Duration, tiers ...Tier) interfaces.BooksRepository {
+	if len(tiers) == 0 && inventoryTtl <= 0 {
+		return backing
+	}
+	return &BooksRepositoryCached{
+		backing:      backing,
+		tiers:        tiers,
+		inventory:    cache_memory.NewCacheMemory(consts.BooksInventoryCacheSize),
+		inventoryTtl: inventoryTtl,
+	}
+}
+
+func (c *BooksRepositoryCached) GetById(bookId string) (*models.Book, error) {
+	if len(c.tiers) == 0 {
+		return c.backing.GetById(bookId)
+	}
+
+	value, found := c.lookup(c.tiers[:1], bookId)
+	if !found {
+		var err error
+		var shared bool
+		value, err, shared = c.loads.do(bookId, func() ([]byte, error) {
+			return c.load(bookId)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if shared {
+			metrics.Add("shared", 1)
+		}
+	}
+
+	if string(value) == string(notFound) {
+		return nil, &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	// Every caller decodes its own copy, callers may change the book
+	book := models.Book{}
+	if err := json.Unmarshal(value, &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// load reads the book from the tiers after the first, and from the backing
+// repository if they do not have it either, filling the tiers it missed.
+func (c *BooksRepositoryCached) load(bookId string) ([]byte, error) {
+	invalidations := atomic.LoadUint64(&c.invalidations)
+	versions := c.versions(bookId)
+	if value, found := c.lookup(c.tiers[1:], bookId); found {
+		c.fill(c.tiers[:1], bookId, value, invalidations, versions)
+		return value, nil
+	}
+
+	metrics.Add("loads", 1)
+	book, err := c.backing.GetById(bookId)
+	var notFoundErr *models.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.fill(c.tiers, bookId, notFound, invalidations, versions)
+		return notFound, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(book)
+	if err != nil {
+		return nil, err
+	}
+	c.fill(c.tiers, bookId, value, invalidations, versions)
+	return value, nil
+}
+
+// versions reads the version of the book in every shared tier. Tiers whose
+// version cannot be read are left out, and are not filled either.
+func (c *BooksRepositoryCached) versions(bookId string) map[string]string {
+	versions := make(map[string]string)
+	for _, tier := range c.tiers {
+		store, versioned := tier.Store.(interfaces.VersionedCacheStore)
+		if !versioned {
+			continue
+		}
+		version, err := store.Version(bookId)
+		if err != nil {
+			log.Printf("error reading the version of book %s from the %s cache: %s", bookId, tier.Name, err)
+			metrics.Add("errors", 1)
+			continue
+		}
+		versions[tier.Name] = version
+	}
+	return versions
+}
+
+func (c *BooksRepositoryCached) lookup(tiers []Tier, bookId string) ([]byte, bool) {
+	for _, tier := range tiers {
+		value, found, err := tier.Store.Get(bookId)
+		if err != nil {
+			log.Printf("error reading book %s from the %s cache: %s", bookId, tier.Name, err)
+			metrics.Add("errors", 1)
+			continue
+		}
+		recordLookup(tier.Name, found)
+		if found {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (c *BooksRepositoryCached) fill(tiers []Tier, bookId string, value []byte, invalidations uint64, versions map[string]string) {
+	if atomic.LoadUint64(&c.invalidations) != invalidations {
+		return
+	}
+	for _, tier := range tiers {
+		ttl := tier.Ttl
+		if string(value) == string(notFound) {
+			ttl = tier.MissTtl
+		}
+		if ttl <= 0 {
+			continue
+		}
+		var err error
+		if store, versioned := tier.Store.(interfaces.VersionedCacheStore); versioned {
+			version, found := versions[tier.Name]
+			if !found {
+				continue
+			}
+			err = store.SetIfVersion(bookId, value, ttl, version)
+		} else {
+			err = tier.Store.Set(bookId, value, ttl)
+		}
+		if err != nil {
+			log.Printf("error caching book %s in the %s cache: %s", bookId, tier.Name, err)
+			metrics.Add("errors", 1)
+		}
+	}
+}
+
+func (c *BooksRepositoryCached) invalidate(bookIds ...string) {
+	if len(bookIds) == 0 {
+		return
+	}
+	c.invalidateInventory()
+	metrics.Add("invalidations", int64(len(bookIds)))
+	for _, tier := range c.tiers {
+		if err := tier.Store.Delete(bookIds...); err != nil {
+			log.Printf("error invalidating books in the %s cache: %s", tier.Name, err)
+			metrics.Add("errors", 1)
+		}
+	}
+}
+
+// invalidateInventory also bumps invalidations, so an inventory loaded before
+// is not cached.
+func (c *BooksRepositoryCached) invalidateInventory() {
+	atomic.AddUint64(&c.invalidations, 1)
+	if err := c.inventory.Clear(); err != nil {
+		log.Printf("error clearing the inventory cache: %s", err)
+		metrics.Add("errors", 1)
+	}
+}
+
+func (c *BooksRepositoryCached) Create(book models.BookSource) (string, error) {
+	bookId, err := c.backing.Create(book)
+	if err != nil {
+		return "", err
+	}
+
+	c.invalidate(bookId)
+	return bookId, nil
+}
+
+// UpdateTitle invalidates the book even when the update fails, since a
+// version conflict means the cached book may be out of date.
+func (c *BooksRepositoryCached) UpdateTitle(bookId string, title string, version *models.BookVersion) (*models.BookVersion, error) {
+	defer c.invalidate(bookId)
+	return c.backing.UpdateTitle(bookId, title, version)
+}
+
+func (c *BooksRepositoryCached) Delete(bookId string, version *models.BookVersion, deletedBy string) error {
+	defer c.invalidate(bookId)
+	return c.backing.Delete(bookId, version, deletedBy)
+}
+
+func (c *BooksRepositoryCached) Restore(bookId string) error {
+	defer c.invalidate(bookId)
+	return c.backing.Restore(bookId)
+}
+
+func (c *BooksRepositoryCached) Purge(bookId string) error {
+	defer c.invalidate(bookId)
+	return c.backing.Purge(bookId)
+}
+
+// PurgeDeleted leaves the books alone, books in the trash are cached as
+// missing.
+func (c *BooksRepositoryCached) PurgeDeleted(before time.Time) (int, error) {
+	defer c.invalidateInventory()
+	return c.backing.PurgeDeleted(before)
+}
+
+// Bulk invalidates the books named by the operations and the ones the
+// results report, which include the ids given to new books.
+func (c *BooksRepositoryCached) Bulk(operations []models.BulkOperation) ([]models.BulkItemResult, error) {
+	results, err := c.backing.Bulk(operations)
+
+	bookIds := make([]string, 0, len(operations)+len(results))
+	for _, operation := range operations {
+		if operation.Id != "" {
+			bookIds = append(bookIds, operation.Id)
+		}
+	}
+	for _, result := range results {
+		if result.Id != "" {
+			bookIds = append(bookIds, result.Id)
+		}
+	}
+	c.invalidate(bookIds...)
+	return results, err
+}
+
+func (c *BooksRepositoryCached) Get(filters models.BookFilters) (*[]models.Book, error) {
+	return c.backing.Get(filters)
+}
+
+func (c *BooksRepositoryCached) GetFaceted(filters models.BookFilters, facets []string) (*models.FacetedBooks, error) {
+	return c.backing.GetFaceted(filters, facets)
+}
+
+func (c *BooksRepositoryCached) Suggest(prefix string, weights models.SuggestWeights, size int) ([]models.Suggestion, error) {
+	return c.backing.Suggest(prefix, weights, size)
+}
+
+func (c *BooksRepositoryCached) FindSimilar(book models.Book, size int) ([]models.Recommendation, error) {
+	return c.backing.FindSimilar(book, size)
+}
+
+func (c *BooksRepositoryCached) Scroll(filters models.BookFilters, fn func(book models.Book) error) error {
+	return c.backing.Scroll(filters, fn)
+}
+
+func (c *BooksRepositoryCached) GetStoreInventory(filters models.BookFilters, topAuthors int) (*models.StoreInventory, error) {
+	if c.inventoryTtl <= 0 {
+		return c.backing.GetStoreInventory(filters, topAuthors)
+	}
+
+	key, err := inventoryKey(filters, topAuthors)
+	if err != nil {
+		return nil, err
+	}
+	value, found, _ := c.inventory.Get(key)
+	recordLookup(consts.InventoryCacheTier, found)
+	if found {
+		inventory := models.StoreInventory{}
+		if err = json.Unmarshal(value, &inventory); err != nil {
+			return nil, err
+		}
+		return &inventory, nil
+	}
+
+	invalidations := atomic.LoadUint64(&c.invalidations)
+	inventory, err := c.backing.GetStoreInventory(filters, topAuthors)
+	if err != nil {
+		return nil, err
+	}
+	if value, err = json.Marshal(inventory); err != nil {
+		return nil, err
+	}
+	if atomic.LoadUint64(&c.invalidations) == invalidations {
+		_ = c.inventory.Set(key, value, c.inventoryTtl)
+	}
+	return inventory, nil
+}
+
+func (c *BooksRepositoryCached) FindDuplicates(books []models.BookSource) ([]string, error) {
+	return c.backing.FindDuplicates(books)
+}
+
+func (c *BooksRepositoryCached) Count(filters models.BookFilters) (int, error) {
+	return c.backing.Count(filters)
+}
+
+// inventoryKey is the hash of the filters, which may hold many book ids.
+func inventoryKey(filters models.BookFilters, topAuthors int) (string, error) {
+	value, err := json.Marshal(filters)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(append(value, []byte(strconv.Itoa(topAuthors))...))
+	return hex.EncodeToString(hash[:]), nil
+}
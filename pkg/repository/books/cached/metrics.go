@@ -0,0 +1,17 @@
+package cached
+
+import "expvar"
+
+// metrics is served under books_cache on /debug/vars. Hits and misses are
+// counted per tier and for the inventory, loads are the lookups that reached
+// the backing repository and shared are lookups that waited for another
+// one's load.
+var metrics = expvar.NewMap("books_cache")
+
+func recordLookup(tier string, hit bool) {
+	if hit {
+		metrics.Add(tier+"_hits", 1)
+	} else {
+		metrics.Add(tier+"_misses", 1)
+	}
+}
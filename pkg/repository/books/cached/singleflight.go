@@ -0,0 +1,45 @@
+package cached
+
+import (
+	"errors"
+	"sync"
+)
+
+// group runs one call per key at a time. Callers asking for a key that is
+// already being loaded wait for that load and share its result.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done  sync.WaitGroup
+	value []byte
+	err   error
+}
+
+func (g *group) do(key string, fn func() ([]byte, error)) ([]byte, error, bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, found := g.calls[key]; found {
+		g.mu.Unlock()
+		c.done.Wait()
+		return c.value, c.err, true
+	}
+	c := &call{err: errors.New("cache load failed")}
+	c.done.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// Waiters are released even if fn panics, and the panic carries on
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.done.Done()
+	}()
+	c.value, c.err = fn()
+	return c.value, c.err, false
+}
@@ -6,10 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/olivere/elastic/v7"
+	"io"
 	"log"
+	"math"
+	"net/http"
 	"pkg/service/pkg/consts"
 	"pkg/service/pkg/interfaces"
 	"pkg/service/pkg/models"
+	"strings"
 	"time"
 )
 
@@ -30,12 +34,23 @@ func (e *BooksRepositoryElastic) Create(bookSource models.BookSource) (string, e
 	}
 	defer client.Stop()
 
-	createResult, err := client.Index().
+	indexService := client.Index().
 		Index(e.index).
 		BodyJson(bookSource).
-		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
-		Do(context.Background())
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout))
+	if bookSource.Isbn13 != "" {
+		indexService = indexService.Id(models.IsbnBookId(bookSource.Isbn13)).OpType("create")
+	}
+	createResult, err := indexService.Do(context.Background())
 
+	if elastic.IsConflict(err) {
+		duplicateErr := &models.DuplicateBookError{ExistingId: models.IsbnBookId(bookSource.Isbn13), Field: "isbn"}
+		if existing, err := e.getBook(duplicateErr.ExistingId); err == nil {
+			duplicateErr.Trashed = existing.DeletedAt != nil
+		}
+		return "", duplicateErr
+	}
 	if err != nil {
 		log.Printf("error creating book: %s", err)
 		return "", errors.New("error creating book")
@@ -55,6 +70,8 @@ func (e *BooksRepositoryElastic) Get(filters models.BookFilters) (*[]models.Book
 	searchResult, err := client.Search().
 		Index(e.index).
 		Query(query).
+		SortBy(createBooksSorter(filters.Sort)).
+		SeqNoAndPrimaryTerm(true).
 		Size(consts.BooksQuerySize).
 		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
 		Do(context.Background())
@@ -66,7 +83,7 @@ func (e *BooksRepositoryElastic) Get(filters models.BookFilters) (*[]models.Book
 
 	books := make([]models.Book, 0)
 	for _, hit := range searchResult.Hits.Hits {
-		book := models.Book{Id: hit.Id}
+		book := models.Book{Id: hit.Id, Version: models.NewBookVersion(hit.SeqNo, hit.PrimaryTerm)}
 		err = json.Unmarshal(hit.Source, &book)
 		if err != nil {
 			return nil, err
@@ -76,7 +93,254 @@ func (e *BooksRepositoryElastic) Get(filters models.BookFilters) (*[]models.Book
 	return &books, nil
 }
 
+// GetFaceted returns the books matching the filters with bucket counts for
+// each facet. The selections of the facets are applied as a post filter, and
+// every facet is counted without its own selection, so the buckets next to a
+// selected one keep their counts.
+func (e *BooksRepositoryElastic) GetFaceted(filters models.BookFilters, facets []string) (*models.FacetedBooks, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	selections := make(map[string]elastic.Query)
+	queryFilters := filters
+	for _, facet := range facets {
+		if filters.HasFacet(facet) {
+			selections[facet] = facetFilterQuery(facet, filters)
+			queryFilters = queryFilters.WithoutFacet(facet)
+		}
+	}
+
+	search := client.Search().
+		Index(e.index).
+		Query(createBooksFetchQuery(queryFilters)).
+		SortBy(createBooksSorter(filters.Sort)).
+		SeqNoAndPrimaryTerm(true).
+		Size(consts.BooksQuerySize).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout))
+	if len(selections) > 0 {
+		search = search.PostFilter(facetsFilter(selections, ""))
+	}
+	for _, facet := range facets {
+		filter := facetsFilter(selections, facet)
+		if facet == consts.FacetDecade {
+			filter = filter.Filter(elastic.NewExistsQuery("publish_date"))
+		}
+		search = search.Aggregation(facet, elastic.NewFilterAggregation().Filter(filter).SubAggregation(facet, facetAggregation(facet)))
+	}
+
+	searchResult, err := search.Do(context.Background())
+	if err != nil {
+		log.Printf("error searching books with facets: %s", err)
+		return nil, errors.New("error searching books")
+	}
+
+	res := &models.FacetedBooks{Books: make([]models.Book, 0), Facets: make(map[string][]models.FacetBucket)}
+	for _, hit := range searchResult.Hits.Hits {
+		book := models.Book{Id: hit.Id, Version: models.NewBookVersion(hit.SeqNo, hit.PrimaryTerm)}
+		if err = json.Unmarshal(hit.Source, &book); err != nil {
+			return nil, err
+		}
+		res.Books = append(res.Books, book)
+	}
+	for _, facet := range facets {
+		res.Facets[facet] = make([]models.FacetBucket, 0)
+		if bucket, found := searchResult.Aggregations.Filter(facet); found {
+			res.Facets[facet] = facetBuckets(facet, bucket.Aggregations, filters)
+		}
+	}
+
+	return res, nil
+}
+
+// Suggest completes the prefix from the search as you type fields of the
+// titles and author names, keeping the best score of each distinct text.
+func (e *BooksRepositoryElastic) Suggest(prefix string, weights models.SuggestWeights, size int) ([]models.Suggestion, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	query := elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("deleted_at")).MinimumNumberShouldMatch(1)
+	if weights.Title > 0 {
+		query = query.Should(suggestQuery(prefix, "title_suggest", weights.Title).QueryName(consts.SuggestFieldTitle))
+	}
+	if weights.Author > 0 {
+		query = query.Should(suggestQuery(prefix, "author_suggest", weights.Author).QueryName(consts.SuggestFieldAuthor))
+	}
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(query).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("title", "author_names")).
+		Size(size * 3).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error suggesting books: %s", err)
+		return nil, errors.New("error suggesting books")
+	}
+
+	suggestions := make([]models.Suggestion, 0)
+	seen := make(map[string]int)
+	add := func(text string, field string, score float64) {
+		key := field + ":" + strings.ToLower(text)
+		if i, found := seen[key]; found {
+			suggestions[i].Score = math.Max(suggestions[i].Score, score)
+			return
+		}
+		seen[key] = len(suggestions)
+		suggestions = append(suggestions, models.Suggestion{Text: text, Field: field, Score: score})
+	}
+
+	for _, hit := range searchResult.Hits.Hits {
+		book := models.BookSource{}
+		if err = json.Unmarshal(hit.Source, &book); err != nil {
+			return nil, err
+		}
+		score := 0.0
+		if hit.Score != nil {
+			score = *hit.Score
+		}
+		for _, matched := range hit.MatchedQueries {
+			switch matched {
+			case consts.SuggestFieldTitle:
+				add(book.Title, consts.SuggestFieldTitle, score)
+			case consts.SuggestFieldAuthor:
+				for _, name := range book.AuthorNames {
+					if matchesPrefix(name, prefix) {
+						add(name, consts.SuggestFieldAuthor, score)
+					}
+				}
+			}
+		}
+	}
+
+	sortSuggestions(suggestions)
+	if len(suggestions) > size {
+		suggestions = suggestions[:size]
+	}
+	return suggestions, nil
+}
+
+// FindSimilar scores the books that share terms with the book in their title,
+// authors and genres, with a boost for books priced close to it.
+func (e *BooksRepositoryElastic) FindSimilar(book models.Book, size int) ([]models.Recommendation, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	moreLikeThis := elastic.NewMoreLikeThisQuery().
+		Field("title", "author_names", "genres").
+		LikeItems(elastic.NewMoreLikeThisQueryItem().Index(e.index).Id(book.Id)).
+		MinTermFreq(1).
+		MinDocFreq(1)
+	query := elastic.NewFunctionScoreQuery().
+		Query(elastic.NewBoolQuery().Must(moreLikeThis).MustNot(elastic.NewExistsQuery("deleted_at"))).
+		AddScoreFunc(elastic.NewGaussDecayFunction().
+			FieldName("price").
+			Origin(book.Price).
+			Scale(similarPriceScale(book.Price)).
+			Weight(consts.SimilarPriceWeight)).
+		BoostMode("sum")
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(query).
+		SeqNoAndPrimaryTerm(true).
+		Size(size).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error finding books similar to %s: %s", book.Id, err)
+		return nil, errors.New("error finding similar books")
+	}
+
+	recommendations := make([]models.Recommendation, 0)
+	for _, hit := range searchResult.Hits.Hits {
+		similar := models.Book{Id: hit.Id, Version: models.NewBookVersion(hit.SeqNo, hit.PrimaryTerm)}
+		if err = json.Unmarshal(hit.Source, &similar); err != nil {
+			return nil, err
+		}
+		recommendation := models.Recommendation{Book: similar}
+		if hit.Score != nil {
+			recommendation.Score = *hit.Score
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+	return recommendations, nil
+}
+
+func (e *BooksRepositoryElastic) Scroll(filters models.BookFilters, fn func(book models.Book) error) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	var sorter elastic.Sorter = elastic.NewFieldSort("_doc")
+	if filters.Sort != "" {
+		sorter = createBooksSorter(filters.Sort)
+	}
+
+	searchSource := elastic.NewSearchSource().
+		Query(createBooksFetchQuery(filters)).
+		SortBy(sorter).
+		SeqNoAndPrimaryTerm(true)
+	scroll := client.Scroll(e.index).
+		SearchSource(searchSource).
+		Size(consts.BooksScrollSize).
+		KeepAlive(consts.BooksScrollKeepAlive)
+	defer func() {
+		if err := scroll.Clear(context.Background()); err != nil {
+			log.Printf("error clearing books scroll: %s", err)
+		}
+	}()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+		searchResult, err := scroll.Do(ctx)
+		cancel()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			log.Printf("error scrolling books: %s", err)
+			return errors.New("error scrolling books")
+		}
+
+		for _, hit := range searchResult.Hits.Hits {
+			book := models.Book{Id: hit.Id, Version: models.NewBookVersion(hit.SeqNo, hit.PrimaryTerm)}
+			if err = json.Unmarshal(hit.Source, &book); err != nil {
+				return err
+			}
+			if err = fn(book); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (e *BooksRepositoryElastic) GetById(bookId string) (*models.Book, error) {
+	book, err := e.getBook(bookId)
+	if err != nil {
+		return nil, err
+	}
+	if book.DeletedAt != nil {
+		return nil, &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	return book, nil
+}
+
+// getBook fetches a book whether or not it is in the trash.
+func (e *BooksRepositoryElastic) getBook(bookId string) (*models.Book, error) {
 	client, err := getElasticClient()
 	if err != nil {
 		return nil, err
@@ -93,7 +357,7 @@ func (e *BooksRepositoryElastic) GetById(bookId string) (*models.Book, error) {
 	if err != nil {
 		if elastic.IsNotFound(err) {
 			log.Printf("book not found: %s", err)
-			return nil, errors.New("book not found")
+			return nil, &models.NotFoundError{Resource: "book", Id: bookId}
 		}
 		return nil, err
 	}
@@ -105,32 +369,98 @@ func (e *BooksRepositoryElastic) GetById(bookId string) (*models.Book, error) {
 	}
 
 	book.Id = res.Id
+	book.Version = models.NewBookVersion(res.SeqNo, res.PrimaryTerm)
 	return &book, nil
 }
 
-func (e *BooksRepositoryElastic) UpdateTitle(bookId string, title string) error {
+func (e *BooksRepositoryElastic) UpdateTitle(bookId string, title string, version *models.BookVersion) (*models.BookVersion, error) {
+	// Books in the trash cannot be edited, so the update is always
+	// conditioned on a version of the book outside the trash
+	if version == nil {
+		book, err := e.GetById(bookId)
+		if err != nil {
+			return nil, err
+		}
+		version = book.Version
+	}
+
 	client, err := getElasticClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer client.Stop()
 
-	_, err = client.Update().
+	res, err := client.Update().
 		Index(e.index).
 		Id(bookId).
 		Doc(map[string]interface{}{"title": title}).
+		IfSeqNo(version.SeqNo).
+		IfPrimaryTerm(version.PrimaryTerm).
 		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
 		Do(context.Background())
 
 	if err != nil {
+		if elastic.IsConflict(err) {
+			return nil, &models.VersionConflictError{BookId: bookId}
+		}
 		log.Printf("error updating book: %s", err)
-		return errors.New("error updating book")
+		return nil, errors.New("error updating book")
+	}
+
+	return &models.BookVersion{SeqNo: res.SeqNo, PrimaryTerm: res.PrimaryTerm}, nil
+}
+
+// Delete moves the book to the trash. It stays in the index, hidden from
+// every query, until it is restored or purged.
+func (e *BooksRepositoryElastic) Delete(bookId string, version *models.BookVersion, deletedBy string) error {
+	book, err := e.getBook(bookId)
+	if err != nil {
+		return err
+	}
+	if book.DeletedAt != nil {
+		return &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	if version == nil {
+		version = book.Version
+	}
+
+	err = e.updateVersion(bookId, *version, deletedFields(time.Now(), deletedBy))
+	if err != nil {
+		log.Printf("error deleting book: %s", err)
+		return err
 	}
 
 	return nil
 }
 
-func (e *BooksRepositoryElastic) Delete(bookId string) error {
+func (e *BooksRepositoryElastic) Restore(bookId string) error {
+	book, err := e.getBook(bookId)
+	if err != nil {
+		return err
+	}
+	if book.DeletedAt == nil {
+		return &models.ConflictError{Message: "book is not in the trash"}
+	}
+
+	err = e.updateVersion(bookId, *book.Version, restoredFields())
+	if err != nil {
+		log.Printf("error restoring book: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// Purge permanently deletes a book that is in the trash.
+func (e *BooksRepositoryElastic) Purge(bookId string) error {
+	book, err := e.getBook(bookId)
+	if err != nil {
+		return err
+	}
+	if book.DeletedAt == nil {
+		return &models.ConflictError{Message: "book is not in the trash"}
+	}
+
 	client, err := getElasticClient()
 	if err != nil {
 		return err
@@ -140,32 +470,90 @@ func (e *BooksRepositoryElastic) Delete(bookId string) error {
 	_, err = client.Delete().
 		Index(e.index).
 		Id(bookId).
+		IfSeqNo(book.Version.SeqNo).
+		IfPrimaryTerm(book.Version.PrimaryTerm).
+		Refresh("wait_for").
 		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
 		Do(context.Background())
 
 	if err != nil {
-		if elastic.IsNotFound(err) {
-			log.Printf("error deleteing book - book not found")
-			return errors.New("book not found")
+		if elastic.IsConflict(err) {
+			return &models.VersionConflictError{BookId: bookId}
 		}
-		log.Printf("error deleting book: %s", err)
-		return errors.New("error deleting book")
+		log.Printf("error purging book: %s", err)
+		return errors.New("error purging book")
 	}
 
 	return nil
 }
 
-func (e *BooksRepositoryElastic) GetStoreInventory() (*models.StoreInventory, error) {
+func (e *BooksRepositoryElastic) PurgeDeleted(before time.Time) (int, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Stop()
+
+	res, err := client.DeleteByQuery(e.index).
+		Query(createBooksFetchQuery(models.BookFilters{Deleted: true, DeletedBefore: before})).
+		Refresh("true").
+		Conflicts("proceed").
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error purging deleted books: %s", err)
+		return 0, errors.New("error purging deleted books")
+	}
+
+	return int(res.Deleted), nil
+}
+
+func (e *BooksRepositoryElastic) updateVersion(bookId string, version models.BookVersion, fields map[string]interface{}) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	_, err = client.Update().
+		Index(e.index).
+		Id(bookId).
+		Doc(fields).
+		IfSeqNo(version.SeqNo).
+		IfPrimaryTerm(version.PrimaryTerm).
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		if elastic.IsConflict(err) {
+			return &models.VersionConflictError{BookId: bookId}
+		}
+		return errors.New("error updating book")
+	}
+
+	return nil
+}
+
+// GetStoreInventory computes the catalog statistics of the books matching the
+// filters in a single aggregations request.
+func (e *BooksRepositoryElastic) GetStoreInventory(filters models.BookFilters, topAuthors int) (*models.StoreInventory, error) {
 	client, err := getElasticClient()
 	if err != nil {
 		return nil, err
 	}
 	defer client.Stop()
 
-	searchSource := elastic.NewSearchSource().Aggregation(
-		consts.UniqueAuthorsAggregationName,
-		elastic.NewCardinalityAggregation().Field("author_name.keyword"),
-	)
+	searchSource := elastic.NewSearchSource().Query(createBooksFetchQuery(filters)).
+		Aggregation(consts.UniqueAuthorsAggregationName, elastic.NewCardinalityAggregation().Field("author_ids")).
+		Aggregation(consts.PriceStatsAggregationName, elastic.NewStatsAggregation().Field("price")).
+		Aggregation(consts.PricePercentilesAggregationName, elastic.NewPercentilesAggregation().Field("price").Percentiles(models.InventoryPercentiles...)).
+		Aggregation(consts.EbooksAggregationName, elastic.NewFilterAggregation().Filter(elastic.NewTermQuery("ebook_available", true))).
+		Aggregation(consts.TopAuthorsAggregationName, elastic.NewTermsAggregation().Field("author_ids").Size(topAuthors).
+			SubAggregation(consts.TopAuthorNameAggregationName, elastic.NewTopHitsAggregation().Size(1).
+				FetchSourceContext(elastic.NewFetchSourceContext(true).Include("author_ids", "author_names")))).
+		Aggregation(consts.PublicationYearsAggregationName, elastic.NewDateHistogramAggregation().Field("publish_date").
+			CalendarInterval("year").MinDocCount(1))
 
 	searchResult, err := client.Search().
 		Index(e.index).
@@ -186,17 +574,192 @@ func (e *BooksRepositoryElastic) GetStoreInventory() (*models.StoreInventory, er
 	}
 
 	aggResult, found := searchResult.Aggregations.Cardinality(consts.UniqueAuthorsAggregationName)
-	if !found {
+	if !found || aggResult == nil || aggResult.Value == nil {
+		log.Printf("error getting books inventory - unique authors aggregation is missing")
 		return nil, errors.New("failed to count unique authors")
 	}
 
-	if aggResult == nil {
-		log.Printf("error getting books inventory - aggResult is nil")
-		return nil, errors.New("error getting books inventory")
+	inventory := &models.StoreInventory{
+		TotalBooks:       int(searchResult.TotalHits()),
+		UniqueAuthors:    int(*aggResult.Value),
+		Price:            models.PriceStats{Percentiles: make(map[string]float64)},
+		TopAuthors:       make([]models.AuthorBooks, 0),
+		PublicationYears: make([]models.YearBooks, 0),
+	}
+
+	if stats, found := searchResult.Aggregations.Stats(consts.PriceStatsAggregationName); found {
+		inventory.Price.Min = floatValue(stats.Min)
+		inventory.Price.Max = floatValue(stats.Max)
+		inventory.Price.Avg = floatValue(stats.Avg)
+		inventory.TotalValue = floatValue(stats.Sum)
+	}
+	if percentiles, found := searchResult.Aggregations.Percentiles(consts.PricePercentilesAggregationName); found && inventory.TotalBooks > 0 {
+		inventory.Price.Percentiles = percentiles.Values
+	}
+	if ebooks, found := searchResult.Aggregations.Filter(consts.EbooksAggregationName); found {
+		inventory.EbookBooks = int(ebooks.DocCount)
+	}
+	inventory.PrintOnlyBooks = inventory.TotalBooks - inventory.EbookBooks
+
+	if authors, found := searchResult.Aggregations.Terms(consts.TopAuthorsAggregationName); found {
+		for _, bucket := range authors.Buckets {
+			authorId := fmt.Sprint(bucket.Key)
+			inventory.TopAuthors = append(inventory.TopAuthors, models.AuthorBooks{
+				AuthorId:   authorId,
+				AuthorName: topHitAuthorName(bucket, authorId),
+				Books:      int(bucket.DocCount),
+			})
+		}
+	}
+	if years, found := searchResult.Aggregations.DateHistogram(consts.PublicationYearsAggregationName); found {
+		for _, bucket := range years.Buckets {
+			inventory.PublicationYears = append(inventory.PublicationYears, models.YearBooks{
+				Year:  time.UnixMilli(int64(bucket.Key)).UTC().Year(),
+				Books: int(bucket.DocCount),
+			})
+		}
+	}
+
+	return inventory, nil
+}
+
+func (e *BooksRepositoryElastic) FindDuplicates(books []models.BookSource) ([]string, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	isbns := make([]interface{}, 0)
+	dedupKeys := make([]interface{}, 0)
+	for _, book := range books {
+		if book.Isbn13 != "" {
+			isbns = append(isbns, book.Isbn13)
+		} else {
+			dedupKeys = append(dedupKeys, book.DedupKey)
+		}
+	}
+
+	query := elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("deleted_at")).MinimumNumberShouldMatch(1)
+	if len(isbns) > 0 {
+		query = query.Should(elastic.NewTermsQuery("isbn_13", isbns...))
+	}
+	if len(dedupKeys) > 0 {
+		query = query.Should(elastic.NewTermsQuery("dedup_key", dedupKeys...))
+	}
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(query).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("isbn_13", "dedup_key")).
+		Size(len(books)).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error searching duplicate books: %s", err)
+		return nil, errors.New("error searching duplicate books")
+	}
+
+	byIsbn := make(map[string]string)
+	byDedupKey := make(map[string]string)
+	for _, hit := range searchResult.Hits.Hits {
+		existing := models.BookSource{}
+		if err = json.Unmarshal(hit.Source, &existing); err != nil {
+			return nil, err
+		}
+		if existing.Isbn13 != "" {
+			byIsbn[existing.Isbn13] = hit.Id
+		}
+		byDedupKey[existing.DedupKey] = hit.Id
+	}
+
+	duplicateIds := make([]string, len(books))
+	for i, book := range books {
+		if book.Isbn13 != "" {
+			duplicateIds[i] = byIsbn[book.Isbn13]
+		} else {
+			duplicateIds[i] = byDedupKey[book.DedupKey]
+		}
+	}
+	return duplicateIds, nil
+}
+
+func (e *BooksRepositoryElastic) Bulk(operations []models.BulkOperation) ([]models.BulkItemResult, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	bulk := client.Bulk().Index(e.index).Refresh("wait_for")
+	for _, operation := range operations {
+		bulk.Add(createBulkRequest(operation))
+	}
+
+	res, err := bulk.Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).Do(context.Background())
+	if err != nil {
+		log.Printf("error running bulk books request: %s", err)
+		return nil, errors.New("error running bulk books request")
+	}
+
+	results := bulkItemResults(res)
+	if err = e.markTrashed(operations, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// markTrashed tells the creates that conflict with a book in the trash apart
+// from the ones conflicting with a live book.
+func (e *BooksRepositoryElastic) markTrashed(operations []models.BulkOperation, results []models.BulkItemResult) error {
+	conflicts := make(map[string][]int)
+	ids := make([]string, 0)
+	for i, result := range results {
+		if operations[i].Action != consts.BulkActionCreate || result.Status != http.StatusConflict {
+			continue
+		}
+		if _, found := conflicts[result.Id]; !found {
+			ids = append(ids, result.Id)
+		}
+		conflicts[result.Id] = append(conflicts[result.Id], i)
+	}
+
+	for start := 0; start < len(ids); start += consts.BooksQuerySize {
+		end := start + consts.BooksQuerySize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		trashed, err := e.Get(models.BookFilters{Ids: ids[start:end], Deleted: true})
+		if err != nil {
+			return err
+		}
+		for _, book := range *trashed {
+			for _, i := range conflicts[book.Id] {
+				results[i].Trashed = true
+			}
+		}
+	}
+	return nil
+}
+
+func (e *BooksRepositoryElastic) Count(filters models.BookFilters) (int, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+	count, err := client.Count(e.index).
+		Query(createBooksFetchQuery(filters)).
+		Do(ctx)
+
+	if err != nil {
+		log.Printf("error counting books: %s", err)
+		return 0, errors.New("error counting books")
 	}
 
-	return &models.StoreInventory{
-		TotalBooks:    int(searchResult.TotalHits()),
-		UniqueAuthors: int(*aggResult.Value),
-	}, nil
+	return int(count), nil
 }
@@ -0,0 +1,117 @@
+package elastic
+
+import (
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"strconv"
+)
+
+// facetFilterQuery is the part of the books query that selects a bucket of
+// the facet.
+func facetFilterQuery(facet string, filters models.BookFilters) elastic.Query {
+	switch facet {
+	case consts.FacetAuthors:
+		return elastic.NewTermQuery("author_ids", filters.AuthorId)
+	case consts.FacetPrice:
+		rangeQuery := elastic.NewRangeQuery("price")
+		if filters.MinPrice > 0 {
+			rangeQuery = rangeQuery.Gte(filters.MinPrice)
+		}
+		if _, band := filters.SelectedPriceBand(); band && filters.MaxPrice > 0 {
+			// A selected band leaves out its upper bound, which starts the next one
+			rangeQuery = rangeQuery.Lt(filters.MaxPrice)
+		} else if filters.MaxPrice > 0 {
+			rangeQuery = rangeQuery.Lte(filters.MaxPrice)
+		}
+		return rangeQuery
+	case consts.FacetEbook:
+		return elastic.NewTermQuery("ebook_available", *filters.Ebook)
+	case consts.FacetDecade:
+		return decadeQuery(filters.Decade)
+	}
+	return nil
+}
+
+// facetsFilter combines the selections of every facet but the excluded one,
+// so a facet is counted as if its own selection was not made.
+func facetsFilter(selections map[string]elastic.Query, excluded string) *elastic.BoolQuery {
+	boolQuery := elastic.NewBoolQuery()
+	for facet, query := range selections {
+		if facet != excluded {
+			boolQuery = boolQuery.Filter(query)
+		}
+	}
+	return boolQuery
+}
+
+func facetAggregation(facet string) elastic.Aggregation {
+	switch facet {
+	case consts.FacetAuthors:
+		return elastic.NewTermsAggregation().Field("author_ids").Size(consts.FacetSize).
+			SubAggregation(consts.TopAuthorNameAggregationName, elastic.NewTopHitsAggregation().Size(1).
+				FetchSourceContext(elastic.NewFetchSourceContext(true).Include("author_ids", "author_names")))
+	case consts.FacetPrice:
+		rangeAggregation := elastic.NewRangeAggregation().Field("price").Keyed(false)
+		for _, band := range models.PriceFacetBands {
+			if band.To == 0 {
+				rangeAggregation = rangeAggregation.AddUnboundedToWithKey(band.Key(), band.From)
+			} else {
+				rangeAggregation = rangeAggregation.AddRangeWithKey(band.Key(), band.From, band.To)
+			}
+		}
+		return rangeAggregation
+	case consts.FacetEbook:
+		return elastic.NewTermsAggregation().Field("ebook_available")
+	case consts.FacetDecade:
+		return elastic.NewHistogramAggregation().Script(elastic.NewScript("doc['publish_date'].value.getYear()")).
+			Interval(10).MinDocCount(1)
+	}
+	return nil
+}
+
+func facetBuckets(facet string, aggregations elastic.Aggregations, filters models.BookFilters) []models.FacetBucket {
+	buckets := make([]models.FacetBucket, 0)
+	add := func(key string, label string, count int64) {
+		buckets = append(buckets, models.FacetBucket{
+			Key:      key,
+			Label:    label,
+			Count:    int(count),
+			Selected: filters.FacetSelected(facet, key),
+		})
+	}
+
+	switch facet {
+	case consts.FacetAuthors:
+		if terms, found := aggregations.Terms(facet); found {
+			for _, bucket := range terms.Buckets {
+				authorId := fmt.Sprint(bucket.Key)
+				add(authorId, topHitAuthorName(bucket, authorId), bucket.DocCount)
+			}
+		}
+	case consts.FacetPrice:
+		if ranges, found := aggregations.Range(facet); found {
+			for _, bucket := range ranges.Buckets {
+				add(bucket.Key, "", bucket.DocCount)
+			}
+		}
+	case consts.FacetEbook:
+		if terms, found := aggregations.Terms(facet); found {
+			for _, bucket := range terms.Buckets {
+				key := fmt.Sprint(bucket.Key)
+				if bucket.KeyAsString != nil {
+					key = *bucket.KeyAsString
+				}
+				add(key, "", bucket.DocCount)
+			}
+		}
+	case consts.FacetDecade:
+		if histogram, found := aggregations.Histogram(facet); found {
+			for _, bucket := range histogram.Buckets {
+				add(strconv.Itoa(int(bucket.Key)), "", bucket.DocCount)
+			}
+		}
+	}
+	return buckets
+}
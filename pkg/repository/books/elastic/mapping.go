@@ -0,0 +1,127 @@
+package elastic
+
+import (
+	"context"
+	"errors"
+	"log"
+	"pkg/service/pkg/consts"
+	"time"
+)
+
+func booksIndexProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"title":               copiedTo(textWithKeyword(), "title_suggest"),
+		"title_suggest":       map[string]interface{}{"type": "search_as_you_type"},
+		"author_ids":          map[string]interface{}{"type": "keyword"},
+		"author_names":        copiedTo(textWithKeyword(), "author_suggest"),
+		"author_suggest":      map[string]interface{}{"type": "search_as_you_type"},
+		"price":               map[string]interface{}{"type": "double"},
+		"ebook_available":     map[string]interface{}{"type": "boolean"},
+		"publish_date":        map[string]interface{}{"type": "date", "format": consts.PublishDateFormat},
+		"publish_date_legacy": map[string]interface{}{"type": "keyword", "index": false},
+		"isbn_10":             map[string]interface{}{"type": "keyword"},
+		"isbn_13":             map[string]interface{}{"type": "keyword"},
+		"publisher":           textWithKeyword(),
+		"genres":              textWithKeyword(),
+		"language":            map[string]interface{}{"type": "keyword"},
+		"page_count":          map[string]interface{}{"type": "integer"},
+		"edition":             map[string]interface{}{"type": "keyword"},
+		"description":         map[string]interface{}{"type": "text"},
+		"cover_image_url":     map[string]interface{}{"type": "keyword", "index": false},
+		"dedup_key":           map[string]interface{}{"type": "keyword"},
+		"deleted_at":          map[string]interface{}{"type": "date"},
+		"deleted_by":          map[string]interface{}{"type": "keyword"},
+	}
+}
+
+// IndexProperties returns the mapping of the book fields, for other indices
+// that run book queries such as the saved search percolator.
+func IndexProperties() map[string]interface{} {
+	return booksIndexProperties()
+}
+
+func textWithKeyword() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "text",
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+		},
+	}
+}
+
+// copiedTo also indexes the field into a field that is only searched, such as
+// the search as you type fields behind suggestions.
+func copiedTo(field map[string]interface{}, target string) map[string]interface{} {
+	field["copy_to"] = target
+	return field
+}
+
+// EnsureIndex creates the books index with the full mapping, or adds any
+// fields missing from the mapping of an existing index. Fields that are
+// already mapped are left untouched since their type cannot be changed in place.
+func EnsureIndex(indexName string) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+
+	exists, err := client.IndexExists(indexName).Do(ctx)
+	if err != nil {
+		log.Printf("error checking books index: %s", err)
+		return errors.New("error checking books index")
+	}
+
+	properties := booksIndexProperties()
+	if !exists {
+		body := map[string]interface{}{
+			"mappings": map[string]interface{}{"properties": properties},
+		}
+		if _, err = client.CreateIndex(indexName).BodyJson(body).Do(ctx); err != nil {
+			log.Printf("error creating books index: %s", err)
+			return errors.New("error creating books index")
+		}
+		return nil
+	}
+
+	mappings, err := client.GetMapping().Index(indexName).Do(ctx)
+	if err != nil {
+		log.Printf("error getting books index mapping: %s", err)
+		return errors.New("error getting books index mapping")
+	}
+	for _, indexMapping := range mappings {
+		for field := range mappedProperties(indexMapping) {
+			delete(properties, field)
+		}
+	}
+	if len(properties) == 0 {
+		return nil
+	}
+
+	_, err = client.PutMapping().
+		Index(indexName).
+		BodyJson(map[string]interface{}{"properties": properties}).
+		Do(ctx)
+	if err != nil {
+		log.Printf("error updating books index mapping: %s", err)
+		return errors.New("error updating books index mapping")
+	}
+
+	return nil
+}
+
+func mappedProperties(indexMapping interface{}) map[string]interface{} {
+	index, ok := indexMapping.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	mappings, ok := index["mappings"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	properties, _ := mappings["properties"].(map[string]interface{})
+	return properties
+}
@@ -0,0 +1,289 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"io"
+	"log"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"sort"
+	"time"
+)
+
+const backfillAuthorsScript = "ctx._source.author_ids = params.author_ids; ctx._source.author_names = params.author_names; ctx._source.dedup_key = params.dedup_key; ctx._source.remove('author_name')"
+
+// legacyBook is the part of an old book the migrations read.
+type legacyBook struct {
+	Title       string             `json:"title"`
+	AuthorIds   []string           `json:"author_ids"`
+	AuthorName  string             `json:"author_name"`
+	PublishDate models.PublishDate `json:"publish_date"`
+}
+
+// MismatchedFields lists the fields of an existing books index whose mapped
+// type or copy_to differs from the current mapping, such as a publish_date
+// that was dynamically mapped as text or a title that is not copied to the
+// suggest field yet. Those can only be fixed by RebuildIndex.
+func MismatchedFields(indexName string) ([]string, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+
+	mappings, err := client.GetMapping().Index(indexName).Do(ctx)
+	if err != nil {
+		log.Printf("error getting books index mapping: %s", err)
+		return nil, errors.New("error getting books index mapping")
+	}
+
+	fields := make([]string, 0)
+	for field, wanted := range booksIndexProperties() {
+		for _, indexMapping := range mappings {
+			mapped, ok := mappedProperties(indexMapping)[field].(map[string]interface{})
+			wantedField := wanted.(map[string]interface{})
+			if ok && (mapped["type"] != wantedField["type"] || !sameCopyTo(mapped["copy_to"], wantedField["copy_to"])) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// RebuildIndex recreates the books index with the current mapping, copying
+// the documents out to a temporary index and back. The original index is only
+// dropped once every document has been copied with the new mapping.
+func RebuildIndex(indexName string) (int, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Stop()
+
+	ctx := context.Background()
+	tempIndexName := fmt.Sprintf("%s_rebuild_%d", indexName, time.Now().Unix())
+	if err = EnsureIndex(tempIndexName); err != nil {
+		return 0, err
+	}
+
+	copied, err := reindex(indexName, tempIndexName)
+	if err != nil {
+		if _, deleteErr := client.DeleteIndex(tempIndexName).Do(ctx); deleteErr != nil {
+			log.Printf("error deleting temporary books index %s: %s", tempIndexName, deleteErr)
+		}
+		return 0, err
+	}
+
+	if _, err = client.DeleteIndex(indexName).Do(ctx); err != nil {
+		log.Printf("error deleting books index: %s", err)
+		return 0, errors.New("error deleting books index")
+	}
+	if err = EnsureIndex(indexName); err != nil {
+		return 0, fmt.Errorf("%w, documents are kept in %s", err, tempIndexName)
+	}
+	if copied, err = reindex(tempIndexName, indexName); err != nil {
+		return 0, fmt.Errorf("%w, documents are kept in %s", err, tempIndexName)
+	}
+
+	if _, err = client.DeleteIndex(tempIndexName).Do(ctx); err != nil {
+		log.Printf("error deleting temporary books index %s: %s", tempIndexName, err)
+	}
+	return copied, nil
+}
+
+// BackfillAuthors links the books written before author entities existed,
+// which only have the single author_name string, to the author resolve
+// returns for that name and drops the legacy field. Books that already have
+// author_ids are left alone, so running it again only picks up what is left.
+func BackfillAuthors(indexName string, resolve func(name string) (string, error)) (int, error) {
+	query := elastic.NewBoolQuery().
+		Filter(elastic.NewExistsQuery("author_name")).
+		MustNot(elastic.NewExistsQuery("author_ids"))
+
+	return updateEach(indexName, query, "backfilling book authors", func(hit *elastic.SearchHit) (*elastic.BulkUpdateRequest, error) {
+		var book legacyBook
+		if err := json.Unmarshal(hit.Source, &book); err != nil {
+			return nil, err
+		}
+		authorIds := make([]string, 0, 1)
+		authorNames := make([]string, 0, 1)
+		if book.AuthorName != "" {
+			authorId, err := resolve(book.AuthorName)
+			if err != nil {
+				return nil, err
+			}
+			authorIds = append(authorIds, authorId)
+			authorNames = append(authorNames, book.AuthorName)
+		}
+		script := elastic.NewScript(backfillAuthorsScript).Params(map[string]interface{}{
+			"author_ids":   authorIds,
+			"author_names": authorNames,
+			"dedup_key":    models.DedupKey(book.Title, authorIds, book.PublishDate.String()),
+		})
+		return elastic.NewBulkUpdateRequest().Script(script), nil
+	})
+}
+
+// NormalizePublishDates rewrites the publish dates stored before they were
+// validated, such as timestamps or dates with slashes, in the YYYY-MM-DD,
+// YYYY-MM or YYYY form the mapping expects. Values that cannot be read as a
+// date at all are cleared and kept in publish_date_legacy. It has to run
+// before the index is rebuilt, which would otherwise fail on those books.
+func NormalizePublishDates(indexName string) (int, int, error) {
+	cleared := 0
+	normalized, err := updateEach(indexName, elastic.NewExistsQuery("publish_date"), "normalizing publish dates", func(hit *elastic.SearchHit) (*elastic.BulkUpdateRequest, error) {
+		var book legacyBook
+		if err := json.Unmarshal(hit.Source, &book); err != nil {
+			return nil, err
+		}
+		publishDate, ok := book.PublishDate, true
+		if book.PublishDate.Validate() != nil {
+			publishDate, ok = models.ParseLegacyPublishDate(book.PublishDate.Raw())
+		} else if !publishDateChanged(hit.Source, book.PublishDate) {
+			return nil, nil
+		}
+
+		fields := map[string]interface{}{"publish_date": nil}
+		if ok {
+			fields["publish_date"] = publishDate.String()
+		} else {
+			fields["publish_date_legacy"] = book.PublishDate.Raw()
+			cleared++
+		}
+		fields["dedup_key"] = models.DedupKey(book.Title, book.AuthorIds, publishDate.String())
+		return elastic.NewBulkUpdateRequest().Doc(fields), nil
+	})
+	return normalized - cleared, cleared, err
+}
+
+// publishDateChanged tells whether a parsed publish date is stored in a
+// different form than it is written back, e.g. with surrounding spaces.
+func publishDateChanged(source json.RawMessage, publishDate models.PublishDate) bool {
+	var stored struct {
+		PublishDate interface{} `json:"publish_date"`
+	}
+	if err := json.Unmarshal(source, &stored); err != nil {
+		return false
+	}
+	return stored.PublishDate != nil && stored.PublishDate != publishDate.String()
+}
+
+// updateEach scrolls the books matching the query and applies the update
+// built for each of them, skipping the ones it returns nil for. Updates are
+// made against the version the book was read at, so a book changed in the
+// meantime fails and is picked up when the migration is run again.
+func updateEach(indexName string, query elastic.Query, description string, update func(hit *elastic.SearchHit) (*elastic.BulkUpdateRequest, error)) (int, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Stop()
+
+	searchSource := elastic.NewSearchSource().
+		Query(query).
+		SortBy(elastic.NewFieldSort("_doc")).
+		SeqNoAndPrimaryTerm(true)
+	scroll := client.Scroll(indexName).
+		SearchSource(searchSource).
+		Size(consts.BooksScrollSize).
+		KeepAlive(consts.BooksScrollKeepAlive)
+	defer func() {
+		if err := scroll.Clear(context.Background()); err != nil {
+			log.Printf("error clearing books scroll: %s", err)
+		}
+	}()
+
+	updated := 0
+	failed := 0
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+		searchResult, err := scroll.Do(ctx)
+		cancel()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("error scrolling books while %s: %s", description, err)
+			return updated, fmt.Errorf("error %s", description)
+		}
+
+		bulk := client.Bulk().Index(indexName).Refresh("true")
+		for _, hit := range searchResult.Hits.Hits {
+			request, err := update(hit)
+			if err != nil {
+				return updated, err
+			}
+			if request != nil {
+				bulk.Add(versioned(request.Id(hit.Id), models.NewBookVersion(hit.SeqNo, hit.PrimaryTerm)))
+			}
+		}
+		if bulk.NumberOfActions() == 0 {
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+		res, err := bulk.Do(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("error %s: %s", description, err)
+			return updated, fmt.Errorf("error %s", description)
+		}
+		for _, result := range bulkItemResults(res) {
+			if result.Status >= http.StatusBadRequest {
+				failed++
+				continue
+			}
+			updated++
+		}
+	}
+
+	if failed > 0 {
+		return updated, fmt.Errorf("error %s, %d books failed, run it again to retry them", description, failed)
+	}
+	return updated, nil
+}
+
+func reindex(source string, destination string) (int, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Stop()
+
+	res, err := client.Reindex().
+		SourceIndex(source).
+		DestinationIndex(destination).
+		Refresh("true").
+		WaitForCompletion(true).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error reindexing books from %s to %s: %s", source, destination, err)
+		return 0, errors.New("error reindexing books")
+	}
+	if len(res.Failures) > 0 {
+		log.Printf("error reindexing books from %s to %s: %d documents failed", source, destination, len(res.Failures))
+		return 0, fmt.Errorf("error reindexing books, %d documents failed", len(res.Failures))
+	}
+
+	return int(res.Created + res.Updated), nil
+}
+
+// sameCopyTo compares the copy_to of a mapping read back from Elastic, which
+// is always a list, with the one in the current mapping.
+func sameCopyTo(mapped interface{}, wanted interface{}) bool {
+	if wanted == nil {
+		return mapped == nil
+	}
+	targets, ok := mapped.([]interface{})
+	return ok && len(targets) == 1 && targets[0] == wanted
+}
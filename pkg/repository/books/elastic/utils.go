@@ -1,10 +1,17 @@
 package elastic
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/olivere/elastic/v7"
+	"math"
 	"os"
+	"pkg/service/pkg/consts"
 	"pkg/service/pkg/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func getElasticClient() (*elastic.Client, error) {
@@ -20,14 +27,41 @@ func getElasticClient() (*elastic.Client, error) {
 	return client, err
 }
 
+// FetchQuery returns the query GetBooks runs for the filters.
+func FetchQuery(filters models.BookFilters) *elastic.BoolQuery {
+	return createBooksFetchQuery(filters)
+}
+
+// createBooksFetchQuery leaves books in the trash out unless the filters ask
+// for the trash itself.
 func createBooksFetchQuery(filters models.BookFilters) *elastic.BoolQuery {
 	boolQuery := elastic.NewBoolQuery()
+	if filters.Deleted {
+		boolQuery = boolQuery.Filter(elastic.NewExistsQuery("deleted_at"))
+	} else {
+		boolQuery = boolQuery.MustNot(elastic.NewExistsQuery("deleted_at"))
+	}
+	if !filters.DeletedBefore.IsZero() {
+		boolQuery = boolQuery.Filter(elastic.NewRangeQuery("deleted_at").Lt(filters.DeletedBefore.Format(time.RFC3339)))
+	}
+	if filters.Ids != nil {
+		idsQuery := elastic.NewIdsQuery().Ids(filters.Ids...)
+		boolQuery = boolQuery.Must(idsQuery)
+	}
+	if filters.Query != "" {
+		multiMatchQuery := elastic.NewMultiMatchQuery(filters.Query, "title", "author_names", "publisher", "genres", "description")
+		boolQuery = boolQuery.Must(multiMatchQuery)
+	}
 	if filters.Title != "" {
 		termQuery := elastic.NewTermQuery("title.keyword", filters.Title)
 		boolQuery = boolQuery.Must(termQuery)
 	}
+	if filters.AuthorId != "" {
+		termQuery := elastic.NewTermQuery("author_ids", filters.AuthorId)
+		boolQuery = boolQuery.Must(termQuery)
+	}
 	if filters.AuthorName != "" {
-		termQuery := elastic.NewTermQuery("author_name.keyword", filters.AuthorName)
+		termQuery := elastic.NewTermQuery("author_names.keyword", filters.AuthorName)
 		boolQuery = boolQuery.Must(termQuery)
 	}
 	if filters.MinPrice > 0 || filters.MaxPrice > 0 {
@@ -40,6 +74,175 @@ func createBooksFetchQuery(filters models.BookFilters) *elastic.BoolQuery {
 		}
 		boolQuery = boolQuery.Must(rangeQuery)
 	}
+	if !filters.PublishedAfter.IsZero() || !filters.PublishedBefore.IsZero() {
+		rangeQuery := elastic.NewRangeQuery("publish_date").Format("yyyy-MM-dd")
+		if !filters.PublishedAfter.IsZero() {
+			rangeQuery = rangeQuery.Gte(filters.PublishedAfter.Format("2006-01-02"))
+		}
+		if !filters.PublishedBefore.IsZero() {
+			rangeQuery = rangeQuery.Lte(filters.PublishedBefore.Format("2006-01-02"))
+		}
+		boolQuery = boolQuery.Must(rangeQuery)
+	}
+	if filters.Ebook != nil {
+		termQuery := elastic.NewTermQuery("ebook_available", *filters.Ebook)
+		boolQuery = boolQuery.Must(termQuery)
+	}
+	if filters.Decade != 0 {
+		boolQuery = boolQuery.Must(decadeQuery(filters.Decade))
+	}
+	if filters.Isbn != "" {
+		isbnQuery := elastic.NewBoolQuery().
+			Should(elastic.NewTermQuery("isbn_10", filters.Isbn), elastic.NewTermQuery("isbn_13", filters.Isbn)).
+			MinimumNumberShouldMatch(1)
+		boolQuery = boolQuery.Must(isbnQuery)
+	}
+	if filters.Publisher != "" {
+		termQuery := elastic.NewTermQuery("publisher.keyword", filters.Publisher)
+		boolQuery = boolQuery.Must(termQuery)
+	}
+	if filters.Genre != "" {
+		termQuery := elastic.NewTermQuery("genres.keyword", filters.Genre)
+		boolQuery = boolQuery.Must(termQuery)
+	}
+	if filters.Language != "" {
+		termQuery := elastic.NewTermQuery("language", filters.Language)
+		boolQuery = boolQuery.Must(termQuery)
+	}
+	if filters.MinPages > 0 || filters.MaxPages > 0 {
+		rangeQuery := elastic.NewRangeQuery("page_count")
+		if filters.MinPages > 0 {
+			rangeQuery = rangeQuery.Gte(filters.MinPages)
+		}
+		if filters.MaxPages > 0 {
+			rangeQuery = rangeQuery.Lte(filters.MaxPages)
+		}
+		boolQuery = boolQuery.Must(rangeQuery)
+	}
 
 	return boolQuery
 }
+
+func createBooksSorter(sort string) elastic.Sorter {
+	if sort == "" {
+		return elastic.NewScoreSort()
+	}
+	if strings.HasPrefix(sort, "-") {
+		return elastic.NewFieldSort(strings.TrimPrefix(sort, "-")).Desc()
+	}
+	return elastic.NewFieldSort(sort).Asc()
+}
+
+func bulkItemResults(res *elastic.BulkResponse) []models.BulkItemResult {
+	results := make([]models.BulkItemResult, 0, len(res.Items))
+	for _, item := range res.Items {
+		for _, itemResult := range item {
+			result := models.BulkItemResult{Id: itemResult.Id, Status: itemResult.Status}
+			if itemResult.Error != nil {
+				result.Error = itemResult.Error.Reason
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func createBulkRequest(operation models.BulkOperation) elastic.BulkableRequest {
+	switch operation.Action {
+	case consts.BulkActionUpdate:
+		return versioned(elastic.NewBulkUpdateRequest().Id(operation.Id).Doc(operation.Fields), operation.Version)
+	case consts.BulkActionDelete:
+		return versioned(elastic.NewBulkUpdateRequest().Id(operation.Id).Doc(deletedFields(time.Now(), operation.DeletedBy)), operation.Version)
+	default:
+		bookId := operation.Id
+		if bookId == "" && operation.Book.Isbn13 != "" {
+			bookId = models.IsbnBookId(operation.Book.Isbn13)
+		}
+		if bookId == "" {
+			return elastic.NewBulkIndexRequest().Doc(operation.Book)
+		}
+		return elastic.NewBulkIndexRequest().OpType("create").Id(bookId).Doc(operation.Book)
+	}
+}
+
+func versioned(request *elastic.BulkUpdateRequest, version *models.BookVersion) *elastic.BulkUpdateRequest {
+	if version == nil {
+		return request
+	}
+	return request.IfSeqNo(version.SeqNo).IfPrimaryTerm(version.PrimaryTerm)
+}
+
+func deletedFields(deletedAt time.Time, deletedBy string) map[string]interface{} {
+	return map[string]interface{}{
+		"deleted_at": deletedAt.UTC().Format(time.RFC3339),
+		"deleted_by": deletedBy,
+	}
+}
+
+func restoredFields() map[string]interface{} {
+	return map[string]interface{}{"deleted_at": nil, "deleted_by": nil}
+}
+
+func floatValue(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// topHitAuthorName reads the name of an author from the book sampled for its
+// terms bucket, where names are stored in the same order as the ids.
+func topHitAuthorName(bucket *elastic.AggregationBucketKeyItem, authorId string) string {
+	topHits, found := bucket.TopHits(consts.TopAuthorNameAggregationName)
+	if !found || topHits.Hits == nil || len(topHits.Hits.Hits) == 0 {
+		return ""
+	}
+
+	book := models.BookSource{}
+	if err := json.Unmarshal(topHits.Hits.Hits[0].Source, &book); err != nil {
+		return ""
+	}
+	for i, id := range book.AuthorIds {
+		if id == authorId && i < len(book.AuthorNames) {
+			return book.AuthorNames[i]
+		}
+	}
+	return ""
+}
+
+func decadeQuery(decade int) *elastic.RangeQuery {
+	return elastic.NewRangeQuery("publish_date").Format("yyyy").Gte(strconv.Itoa(decade)).Lte(strconv.Itoa(decade + 9))
+}
+
+func suggestQuery(prefix string, field string, weight float64) *elastic.MultiMatchQuery {
+	return elastic.NewMultiMatchQuery(prefix, field, field+"._2gram", field+"._3gram").
+		Type("bool_prefix").
+		Boost(weight)
+}
+
+// matchesPrefix reports whether a word of the text starts with the prefix,
+// which is how a search as you type field matches a single name.
+func matchesPrefix(text string, prefix string) bool {
+	text, prefix = strings.ToLower(text), strings.ToLower(strings.TrimSpace(prefix))
+	for i := 0; i < len(text); i++ {
+		if (i == 0 || text[i-1] == ' ') && strings.HasPrefix(text[i:], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortSuggestions(suggestions []models.Suggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+}
+
+// similarPriceScale is the price distance at which the price boost of a
+// similar book halves.
+func similarPriceScale(price float64) float64 {
+	return math.Max(price*consts.SimilarPriceScale, 1)
+}
@@ -0,0 +1,462 @@
+package memory
+
+import (
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var _ interfaces.BooksRepository = &BooksRepositoryMemory{}
+
+// BooksRepositoryMemory keeps books in process memory. It is meant for local
+// runs and tooling; nothing survives a restart.
+type BooksRepositoryMemory struct {
+	mu       sync.RWMutex
+	books    map[string]models.BookSource
+	versions map[string]models.BookVersion
+	ids      []string
+	seqNo    int64
+	// suggestions is built on the first Suggest after a write
+	suggestions *suggestTrie
+}
+
+func NewBooksRepositoryMemory() interfaces.BooksRepository {
+	return &BooksRepositoryMemory{
+		books:    make(map[string]models.BookSource),
+		versions: make(map[string]models.BookVersion),
+	}
+}
+
+func (m *BooksRepositoryMemory) Create(bookSource models.BookSource) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := newId()
+	if bookSource.Isbn13 != "" {
+		id = models.IsbnBookId(bookSource.Isbn13)
+		if existing, found := m.books[id]; found {
+			return "", &models.DuplicateBookError{ExistingId: id, Field: "isbn", Trashed: existing.DeletedAt != nil}
+		}
+	}
+	m.put(id, bookSource)
+	return id, nil
+}
+
+func (m *BooksRepositoryMemory) Get(filters models.BookFilters) (*[]models.Book, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	books := m.find(filters)
+	sortBooks(books, filters.Sort)
+	if len(books) > consts.BooksQuerySize {
+		books = books[:consts.BooksQuerySize]
+	}
+	return &books, nil
+}
+
+// GetFaceted counts every facet over the books matching all the filters but
+// the selection of that facet, like the post filter in Elastic.
+func (m *BooksRepositoryMemory) GetFaceted(filters models.BookFilters, facets []string) (*models.FacetedBooks, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	priceFaceted := false
+	for _, facet := range facets {
+		priceFaceted = priceFaceted || facet == consts.FacetPrice
+	}
+
+	books := m.findFaceted(filters, priceFaceted)
+	sortBooks(books, filters.Sort)
+	if len(books) > consts.BooksQuerySize {
+		books = books[:consts.BooksQuerySize]
+	}
+
+	res := &models.FacetedBooks{Books: books, Facets: make(map[string][]models.FacetBucket)}
+	for _, facet := range facets {
+		res.Facets[facet] = facetBuckets(facet, m.findFaceted(filters.WithoutFacet(facet), priceFaceted), filters)
+	}
+	return res, nil
+}
+
+// findFaceted matches a price band selected through the price facet without
+// its upper bound, like the post filter of the Elastic repository. Price
+// filters are otherwise inclusive.
+func (m *BooksRepositoryMemory) findFaceted(filters models.BookFilters, priceFaceted bool) []models.Book {
+	band, selected := filters.SelectedPriceBand()
+	if !priceFaceted || !selected {
+		return m.find(filters)
+	}
+
+	books := make([]models.Book, 0)
+	for _, book := range m.find(filters.WithoutFacet(consts.FacetPrice)) {
+		if band.Contains(book.Price) {
+			books = append(books, book)
+		}
+	}
+	return books
+}
+
+func (m *BooksRepositoryMemory) Suggest(prefix string, weights models.SuggestWeights, size int) ([]models.Suggestion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.suggestions == nil {
+		m.suggestions = newSuggestTrie()
+		for _, id := range m.ids {
+			source := m.books[id]
+			if source.DeletedAt != nil {
+				continue
+			}
+			m.suggestions.insert(source.Title, consts.SuggestFieldTitle)
+			for _, name := range source.AuthorNames {
+				m.suggestions.insert(name, consts.SuggestFieldAuthor)
+			}
+		}
+	}
+
+	suggestions := m.suggestions.search(prefix, weights)
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	if len(suggestions) > size {
+		suggestions = suggestions[:size]
+	}
+	return suggestions, nil
+}
+
+// FindSimilar scores books by the overlap of their title words, authors and
+// genres with the book, plus the same price boost as the Elastic query.
+func (m *BooksRepositoryMemory) FindSimilar(book models.Book, size int) ([]models.Recommendation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	recommendations := make([]models.Recommendation, 0)
+	for _, candidate := range m.find(models.BookFilters{}) {
+		if candidate.Id == book.Id {
+			continue
+		}
+		score := similarity(book, candidate)
+		if score == 0 {
+			continue
+		}
+		score += priceBoost(book.Price, candidate.Price)
+		recommendations = append(recommendations, models.Recommendation{Book: candidate, Score: score})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool { return recommendations[i].Score > recommendations[j].Score })
+	if len(recommendations) > size {
+		recommendations = recommendations[:size]
+	}
+	return recommendations, nil
+}
+
+func (m *BooksRepositoryMemory) Scroll(filters models.BookFilters, fn func(book models.Book) error) error {
+	m.mu.RLock()
+	books := m.find(filters)
+	m.mu.RUnlock()
+
+	sortBooks(books, filters.Sort)
+	for _, book := range books {
+		if err := fn(book); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *BooksRepositoryMemory) GetById(bookId string) (*models.Book, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	source, found := m.books[bookId]
+	if !found || source.DeletedAt != nil {
+		return nil, &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	book := m.newBook(bookId, source)
+	return &book, nil
+}
+
+func (m *BooksRepositoryMemory) UpdateTitle(bookId string, title string, version *models.BookVersion) (*models.BookVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, found := m.books[bookId]
+	if !found || source.DeletedAt != nil {
+		return nil, &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	if version != nil && *version != m.versions[bookId] {
+		return nil, &models.VersionConflictError{BookId: bookId}
+	}
+	source.Title = title
+	m.put(bookId, source)
+
+	updated := m.versions[bookId]
+	return &updated, nil
+}
+
+func (m *BooksRepositoryMemory) Delete(bookId string, version *models.BookVersion, deletedBy string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, found := m.books[bookId]
+	if !found || source.DeletedAt != nil {
+		return &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	if version != nil && *version != m.versions[bookId] {
+		return &models.VersionConflictError{BookId: bookId}
+	}
+	m.put(bookId, trash(source, deletedBy))
+	return nil
+}
+
+func (m *BooksRepositoryMemory) Restore(bookId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, found := m.books[bookId]
+	if !found {
+		return &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	if source.DeletedAt == nil {
+		return &models.ConflictError{Message: "book is not in the trash"}
+	}
+	source.DeletedAt = nil
+	source.DeletedBy = ""
+	m.put(bookId, source)
+	return nil
+}
+
+func (m *BooksRepositoryMemory) Purge(bookId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, found := m.books[bookId]
+	if !found {
+		return &models.NotFoundError{Resource: "book", Id: bookId}
+	}
+	if source.DeletedAt == nil {
+		return &models.ConflictError{Message: "book is not in the trash"}
+	}
+	m.remove(bookId)
+	return nil
+}
+
+func (m *BooksRepositoryMemory) PurgeDeleted(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	books := m.find(models.BookFilters{Deleted: true, DeletedBefore: before})
+	for _, book := range books {
+		m.remove(book.Id)
+	}
+	return len(books), nil
+}
+
+func (m *BooksRepositoryMemory) GetStoreInventory(filters models.BookFilters, topAuthors int) (*models.StoreInventory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	books := m.find(filters)
+	inventory := &models.StoreInventory{
+		TotalBooks:       len(books),
+		Price:            models.PriceStats{Percentiles: make(map[string]float64)},
+		TopAuthors:       make([]models.AuthorBooks, 0),
+		PublicationYears: make([]models.YearBooks, 0),
+	}
+
+	prices := make([]float64, 0, len(books))
+	authors := make(map[string]*models.AuthorBooks)
+	years := make(map[int]int)
+	for _, book := range books {
+		prices = append(prices, book.Price)
+		inventory.TotalValue += book.Price
+		if book.EbookAvailable {
+			inventory.EbookBooks++
+		}
+		for i, authorId := range book.AuthorIds {
+			if authors[authorId] == nil {
+				authors[authorId] = &models.AuthorBooks{AuthorId: authorId}
+				if i < len(book.AuthorNames) {
+					authors[authorId].AuthorName = book.AuthorNames[i]
+				}
+			}
+			authors[authorId].Books++
+		}
+		if !book.PublishDate.IsZero() {
+			years[book.PublishDate.Start().Year()]++
+		}
+	}
+	inventory.UniqueAuthors = len(authors)
+	inventory.PrintOnlyBooks = inventory.TotalBooks - inventory.EbookBooks
+
+	if len(prices) > 0 {
+		sort.Float64s(prices)
+		inventory.Price.Min = prices[0]
+		inventory.Price.Max = prices[len(prices)-1]
+		inventory.Price.Avg = inventory.TotalValue / float64(len(prices))
+		for _, percent := range models.InventoryPercentiles {
+			inventory.Price.Percentiles[strconv.FormatFloat(percent, 'f', 1, 64)] = percentile(prices, percent)
+		}
+	}
+
+	for _, author := range authors {
+		inventory.TopAuthors = append(inventory.TopAuthors, *author)
+	}
+	sort.Slice(inventory.TopAuthors, func(i, j int) bool {
+		if inventory.TopAuthors[i].Books != inventory.TopAuthors[j].Books {
+			return inventory.TopAuthors[i].Books > inventory.TopAuthors[j].Books
+		}
+		return inventory.TopAuthors[i].AuthorId < inventory.TopAuthors[j].AuthorId
+	})
+	if len(inventory.TopAuthors) > topAuthors {
+		inventory.TopAuthors = inventory.TopAuthors[:topAuthors]
+	}
+
+	for year, count := range years {
+		inventory.PublicationYears = append(inventory.PublicationYears, models.YearBooks{Year: year, Books: count})
+	}
+	sort.Slice(inventory.PublicationYears, func(i, j int) bool {
+		return inventory.PublicationYears[i].Year < inventory.PublicationYears[j].Year
+	})
+
+	return inventory, nil
+}
+
+func (m *BooksRepositoryMemory) FindDuplicates(books []models.BookSource) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	duplicateIds := make([]string, len(books))
+	for i, book := range books {
+		for _, id := range m.ids {
+			existing := m.books[id]
+			if existing.DeletedAt != nil {
+				continue
+			}
+			if (book.Isbn13 != "" && existing.Isbn13 == book.Isbn13) ||
+				(book.Isbn13 == "" && existing.DedupKey == book.DedupKey) {
+				duplicateIds[i] = id
+				break
+			}
+		}
+	}
+	return duplicateIds, nil
+}
+
+func (m *BooksRepositoryMemory) Bulk(operations []models.BulkOperation) ([]models.BulkItemResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make([]models.BulkItemResult, len(operations))
+	for i, operation := range operations {
+		results[i] = m.apply(operation)
+	}
+	return results, nil
+}
+
+func (m *BooksRepositoryMemory) Count(filters models.BookFilters) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.find(filters)), nil
+}
+
+func (m *BooksRepositoryMemory) apply(operation models.BulkOperation) models.BulkItemResult {
+	if operation.Action == consts.BulkActionCreate && operation.Id == "" && operation.Book.Isbn13 != "" {
+		operation.Id = models.IsbnBookId(operation.Book.Isbn13)
+	}
+	result := models.BulkItemResult{Id: operation.Id}
+	existing, found := m.books[operation.Id]
+	if found && operation.Version != nil && *operation.Version != m.versions[operation.Id] {
+		result.Status = http.StatusConflict
+		result.Error = "version conflict"
+		return result
+	}
+
+	switch operation.Action {
+	case consts.BulkActionCreate:
+		if operation.Id == "" {
+			result.Id = newId()
+		} else if found {
+			result.Status = http.StatusConflict
+			result.Error = "document already exists"
+			result.Trashed = existing.DeletedAt != nil
+			return result
+		}
+		m.put(result.Id, *operation.Book)
+		result.Status = http.StatusCreated
+	case consts.BulkActionUpdate:
+		if !found {
+			result.Status = http.StatusNotFound
+			result.Error = "document missing"
+			return result
+		}
+		source, err := existing.WithFields(operation.Fields)
+		if err != nil {
+			result.Status = http.StatusBadRequest
+			result.Error = err.Error()
+			return result
+		}
+		m.put(operation.Id, source)
+		result.Status = http.StatusOK
+	case consts.BulkActionDelete:
+		if !found {
+			result.Status = http.StatusNotFound
+			result.Error = "not_found"
+			return result
+		}
+		m.put(operation.Id, trash(existing, operation.DeletedBy))
+		result.Status = http.StatusOK
+	}
+	return result
+}
+
+func (m *BooksRepositoryMemory) find(filters models.BookFilters) []models.Book {
+	books := make([]models.Book, 0)
+	for _, id := range m.ids {
+		book := m.newBook(id, m.books[id])
+		if filters.Matches(book) {
+			books = append(books, book)
+		}
+	}
+	return books
+}
+
+// put stores the source under a new version, numbered like Elastic sequence
+// numbers on a single primary term.
+func (m *BooksRepositoryMemory) put(id string, source models.BookSource) {
+	if _, found := m.books[id]; !found {
+		m.ids = append(m.ids, id)
+	}
+	m.books[id] = source
+	m.versions[id] = models.BookVersion{SeqNo: m.seqNo, PrimaryTerm: 1}
+	m.seqNo++
+	m.suggestions = nil
+}
+
+func (m *BooksRepositoryMemory) newBook(id string, source models.BookSource) models.Book {
+	book := models.NewBook(id, source)
+	version := m.versions[id]
+	book.Version = &version
+	return book
+}
+
+func (m *BooksRepositoryMemory) remove(id string) {
+	m.suggestions = nil
+	delete(m.books, id)
+	delete(m.versions, id)
+	for i, existing := range m.ids {
+		if existing == id {
+			m.ids = append(m.ids[:i], m.ids[i+1:]...)
+			return
+		}
+	}
+}
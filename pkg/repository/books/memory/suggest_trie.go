@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"strings"
+)
+
+type suggestEntry struct {
+	text  string
+	field string
+	books int
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	entries  []*suggestEntry
+}
+
+// suggestTrie finds titles and author names by the start of any of their
+// words. Every text is inserted once per word, from that word to its end, so
+// "harry potter" is found by both "har" and "pot".
+type suggestTrie struct {
+	root    *trieNode
+	entries map[string]*suggestEntry
+}
+
+func newSuggestTrie() *suggestTrie {
+	return &suggestTrie{root: &trieNode{}, entries: make(map[string]*suggestEntry)}
+}
+
+func (t *suggestTrie) insert(text string, field string) {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	if normalized == "" {
+		return
+	}
+
+	key := field + ":" + normalized
+	if entry, found := t.entries[key]; found {
+		entry.books++
+		return
+	}
+	entry := &suggestEntry{text: text, field: field, books: 1}
+	t.entries[key] = entry
+
+	for i := 0; i < len(normalized); i++ {
+		if i > 0 && normalized[i-1] != ' ' {
+			continue
+		}
+		node := t.root
+		for _, r := range normalized[i:] {
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode)
+			}
+			if node.children[r] == nil {
+				node.children[r] = &trieNode{}
+			}
+			node = node.children[r]
+		}
+		node.entries = append(node.entries, entry)
+	}
+}
+
+// search scores every text under the prefix by its field weight times the
+// number of books it appears in.
+func (t *suggestTrie) search(prefix string, weights models.SuggestWeights) []models.Suggestion {
+	node := t.root
+	for _, r := range strings.ToLower(strings.TrimSpace(prefix)) {
+		if node = node.children[r]; node == nil {
+			return make([]models.Suggestion, 0)
+		}
+	}
+
+	seen := make(map[*suggestEntry]bool)
+	suggestions := make([]models.Suggestion, 0)
+	var collect func(node *trieNode)
+	collect = func(node *trieNode) {
+		for _, entry := range node.entries {
+			if seen[entry] {
+				continue
+			}
+			seen[entry] = true
+
+			weight := weights.Title
+			if entry.field == consts.SuggestFieldAuthor {
+				weight = weights.Author
+			}
+			if weight > 0 {
+				suggestions = append(suggestions, models.Suggestion{Text: entry.text, Field: entry.field, Score: weight * float64(entry.books)})
+			}
+		}
+		for _, child := range node.children {
+			collect(child)
+		}
+	}
+	collect(node)
+	return suggestions
+}
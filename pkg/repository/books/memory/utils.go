@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func newId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func trash(source models.BookSource, deletedBy string) models.BookSource {
+	deletedAt := time.Now().UTC()
+	source.DeletedAt = &deletedAt
+	source.DeletedBy = deletedBy
+	return source
+}
+
+func sortBooks(books []models.Book, sortBy string) {
+	if sortBy == "" {
+		return
+	}
+	descending := strings.HasPrefix(sortBy, "-")
+	field := strings.TrimPrefix(sortBy, "-")
+
+	sort.SliceStable(books, func(i, j int) bool {
+		a, b := books[i], books[j]
+		if descending {
+			a, b = b, a
+		}
+		switch field {
+		case "price":
+			return a.Price < b.Price
+		case "publish_date":
+			return a.PublishDate.Start().Before(b.PublishDate.Start())
+		case "deleted_at":
+			return a.DeletedAt != nil && b.DeletedAt != nil && a.DeletedAt.Before(*b.DeletedAt)
+		}
+		return false
+	})
+}
+
+// percentile interpolates between the closest ranks of the sorted values.
+func percentile(sorted []float64, percent float64) float64 {
+	rank := percent / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+func facetBuckets(facet string, books []models.Book, filters models.BookFilters) []models.FacetBucket {
+	counts := make(map[string]int)
+	labels := make(map[string]string)
+	keys := make([]string, 0)
+	count := func(key string, label string) {
+		if _, found := counts[key]; !found {
+			keys = append(keys, key)
+			labels[key] = label
+		}
+		counts[key]++
+	}
+
+	for _, book := range books {
+		switch facet {
+		case consts.FacetAuthors:
+			for i, authorId := range book.AuthorIds {
+				label := ""
+				if i < len(book.AuthorNames) {
+					label = book.AuthorNames[i]
+				}
+				count(authorId, label)
+			}
+		case consts.FacetPrice:
+			for _, band := range models.PriceFacetBands {
+				if band.Contains(book.Price) {
+					count(band.Key(), "")
+				}
+			}
+		case consts.FacetEbook:
+			count(strconv.FormatBool(book.EbookAvailable), "")
+		case consts.FacetDecade:
+			if !book.PublishDate.IsZero() {
+				count(strconv.Itoa(models.Decade(book.PublishDate.Start().Year())), "")
+			}
+		}
+	}
+
+	// Ordered like the Elastic aggregations: ranges in band order, decades
+	// ascending and terms by count
+	switch facet {
+	case consts.FacetPrice:
+		keys = keys[:0]
+		for _, band := range models.PriceFacetBands {
+			keys = append(keys, band.Key())
+		}
+	case consts.FacetDecade:
+		sort.Strings(keys)
+	default:
+		sort.SliceStable(keys, func(i, j int) bool { return counts[keys[i]] > counts[keys[j]] })
+		if facet == consts.FacetAuthors && len(keys) > consts.FacetSize {
+			keys = keys[:consts.FacetSize]
+		}
+	}
+
+	buckets := make([]models.FacetBucket, 0, len(keys))
+	for _, key := range keys {
+		buckets = append(buckets, models.FacetBucket{
+			Key:      key,
+			Label:    labels[key],
+			Count:    counts[key],
+			Selected: filters.FacetSelected(facet, key),
+		})
+	}
+	return buckets
+}
+
+func similarity(book models.Book, candidate models.Book) float64 {
+	return jaccard(strings.Fields(strings.ToLower(book.Title)), strings.Fields(strings.ToLower(candidate.Title))) +
+		jaccard(book.AuthorIds, candidate.AuthorIds) +
+		jaccard(lowerAll(book.Genres), lowerAll(candidate.Genres))
+}
+
+// priceBoost decays like a gauss function that halves at the scale distance.
+func priceBoost(price float64, candidatePrice float64) float64 {
+	scale := math.Max(price*consts.SimilarPriceScale, 1)
+	distance := (candidatePrice - price) / scale
+	return consts.SimilarPriceWeight * math.Exp(-math.Ln2*distance*distance)
+}
+
+func jaccard(a []string, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool)
+	for _, value := range a {
+		set[value] = true
+	}
+	shared := 0
+	union := len(set)
+	seen := make(map[string]bool)
+	for _, value := range b {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		if set[value] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, value := range values {
+		lowered = append(lowered, strings.ToLower(value))
+	}
+	return lowered
+}
@@ -0,0 +1,109 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.BranchesRepository = &BranchesRepositoryElastic{}
+
+type BranchesRepositoryElastic struct {
+	index string
+}
+
+func NewBranchesRepositoryElastic(indexName string) interfaces.BranchesRepository {
+	return &BranchesRepositoryElastic{index: indexName}
+}
+
+func (e *BranchesRepositoryElastic) Create(branch models.BranchSource) (string, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return "", err
+	}
+	defer client.Stop()
+
+	createResult, err := client.Index().
+		Index(e.index).
+		BodyJson(branch).
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error creating branch: %s", err)
+		return "", errors.New("error creating branch")
+	}
+
+	return createResult.Id, nil
+}
+
+func (e *BranchesRepositoryElastic) Get() (*[]models.Branch, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(elastic.NewMatchAllQuery()).
+		Size(consts.BooksQuerySize).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error searching branches: %s", err)
+		return nil, errors.New("error searching branches")
+	}
+
+	branches := make([]models.Branch, 0)
+	for _, hit := range searchResult.Hits.Hits {
+		branch := models.Branch{Id: hit.Id}
+		err = json.Unmarshal(hit.Source, &branch)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch)
+	}
+	return &branches, nil
+}
+
+func (e *BranchesRepositoryElastic) GetById(branchId string) (*models.Branch, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+	res, err := client.Get().
+		Index(e.index).
+		Id(branchId).
+		Do(ctx)
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			log.Printf("branch not found: %s", err)
+			return nil, &models.NotFoundError{Resource: "branch", Id: branchId}
+		}
+		return nil, err
+	}
+
+	branch := models.Branch{}
+	err = json.Unmarshal(res.Source, &branch)
+	if err != nil {
+		return nil, err
+	}
+
+	branch.Id = res.Id
+	return &branch, nil
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+)
+
+var _ interfaces.BranchesRepository = &BranchesRepositoryMemory{}
+
+// BranchesRepositoryMemory keeps branches in process memory, for running
+// with the memory books backend.
+type BranchesRepositoryMemory struct {
+	mu       sync.RWMutex
+	branches map[string]models.BranchSource
+	ids      []string
+}
+
+func NewBranchesRepositoryMemory() interfaces.BranchesRepository {
+	return &BranchesRepositoryMemory{branches: make(map[string]models.BranchSource)}
+}
+
+func (m *BranchesRepositoryMemory) Create(branch models.BranchSource) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := newId()
+	m.branches[id] = branch
+	m.ids = append(m.ids, id)
+	return id, nil
+}
+
+func (m *BranchesRepositoryMemory) Get() (*[]models.Branch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	branches := make([]models.Branch, 0, len(m.ids))
+	for _, id := range m.ids {
+		branch := m.branches[id]
+		branches = append(branches, models.Branch{Id: id, Name: branch.Name, Address: branch.Address})
+		if len(branches) == consts.BooksQuerySize {
+			break
+		}
+	}
+	return &branches, nil
+}
+
+func (m *BranchesRepositoryMemory) GetById(branchId string) (*models.Branch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	branch, found := m.branches[branchId]
+	if !found {
+		return nil, &models.NotFoundError{Resource: "branch", Id: branchId}
+	}
+	return &models.Branch{Id: branchId, Name: branch.Name, Address: branch.Address}, nil
+}
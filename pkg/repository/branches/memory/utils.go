@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+func newId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
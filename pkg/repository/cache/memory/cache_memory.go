@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"container/list"
+	"pkg/service/pkg/interfaces"
+	"sync"
+	"time"
+)
+
+var _ interfaces.CacheStore = &CacheMemory{}
+
+// CacheMemory is an in-process LRU cache of up to size entries. Expired
+// entries are dropped when they are read or pushed out.
+type CacheMemory struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewCacheMemory(size int) interfaces.CacheStore {
+	return &CacheMemory{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *CacheMemory) Get(key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.entries[key]
+	if !found {
+		return nil, false, nil
+	}
+	e := element.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+	return e.value, true, nil
+}
+
+func (c *CacheMemory) Set(key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, found := c.entries[key]; found {
+		e := element.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *CacheMemory) Delete(keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, found := c.entries[key]; found {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+func (c *CacheMemory) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	return nil
+}
+
+func (c *CacheMemory) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
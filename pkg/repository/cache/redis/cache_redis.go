@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"time"
+)
+
+var _ interfaces.VersionedCacheStore = &CacheRedis{}
+
+// setIfVersionScript stores the entry only when the version key still holds
+// the version the caller read, a missing key being version "".
+var setIfVersionScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[2])
+if (current or '') ~= ARGV[3] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// CacheRedis keeps entries in Redis under prefix, shared by every instance.
+// Every deleted key gets a version key, kept for consts.CacheVersionTtlSeconds
+// which outlasts any load that read the key before it was deleted.
+// Cache lookups are on the path of every read, so one client and its pool of
+// connections is kept for the life of the store.
+type CacheRedis struct {
+	prefix string
+	client *redis.Client
+}
+
+func NewCacheRedis(prefix string) interfaces.VersionedCacheStore {
+	return &CacheRedis{prefix: prefix, client: newRedisClient()}
+}
+
+func (c *CacheRedis) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		log.Printf("error getting cache entry %s: %s", key, err)
+		return nil, false, errors.New("error getting cache entry")
+	}
+
+	return value, true, nil
+}
+
+func (c *CacheRedis) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		log.Printf("error setting cache entry %s: %s", key, err)
+		return errors.New("error setting cache entry")
+	}
+
+	return nil
+}
+
+// Version returns "" for keys that were not deleted lately.
+func (c *CacheRedis) Version(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	version, err := c.client.Get(ctx, c.versionKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		log.Printf("error getting cache entry version %s: %s", key, err)
+		return "", errors.New("error getting cache entry version")
+	}
+
+	return version, nil
+}
+
+// SetIfVersion leaves the entry alone when its key was deleted since version
+// was read.
+func (c *CacheRedis) SetIfVersion(key string, value []byte, ttl time.Duration, version string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	err := setIfVersionScript.Run(ctx, c.client, []string{c.prefix + key, c.versionKey(key)},
+		value, ttl.Milliseconds(), version).Err()
+	if err != nil {
+		log.Printf("error setting cache entry %s: %s", key, err)
+		return errors.New("error setting cache entry")
+	}
+
+	return nil
+}
+
+// Delete bumps the versions of the keys along with deleting them, so loads
+// that started before do not store them again.
+func (c *CacheRedis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.prefix+key)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, prefixed...)
+		for _, key := range keys {
+			pipe.Incr(ctx, c.versionKey(key))
+			pipe.Expire(ctx, c.versionKey(key), consts.CacheVersionTtlSeconds*time.Second)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error deleting cache entries: %s", err)
+		return errors.New("error deleting cache entries")
+	}
+
+	return nil
+}
+
+// Clear deletes the entries under the prefix in batches, scanning rather
+// than blocking Redis with KEYS. The version keys are left, so loads that
+// started before the clear still do not store what they read.
+func (c *CacheRedis) Clear() error {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	var cursor uint64
+	for {
+		var keys []string
+		var err error
+		keys, cursor, err = c.client.Scan(ctx, cursor, c.prefix+"*", consts.CacheClearBatchSize).Result()
+		if err != nil {
+			log.Printf("error scanning cache entries: %s", err)
+			return errors.New("error clearing cache")
+		}
+		keys = withoutVersionKeys(keys, c.prefix)
+		if len(keys) > 0 {
+			if err = c.client.Del(ctx, keys...).Err(); err != nil {
+				log.Printf("error deleting cache entries: %s", err)
+				return errors.New("error clearing cache")
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func (c *CacheRedis) versionKey(key string) string {
+	return c.prefix + consts.CacheVersionKeyPrefix + key
+}
@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"github.com/go-redis/redis/v8"
+	"os"
+	"pkg/service/pkg/consts"
+	"strings"
+)
+
+// newRedisClient does not connect yet, the client connects on first use and
+// keeps a pool of connections from then on.
+func newRedisClient() *redis.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = consts.DefaultRedisAddress
+	}
+	options := &redis.Options{
+		Addr:     addr,
+		Password: "",
+		DB:       0,
+	}
+
+	return redis.NewClient(options)
+}
+
+func withoutVersionKeys(keys []string, prefix string) []string {
+	entries := keys[:0]
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix+consts.CacheVersionKeyPrefix) {
+			entries = append(entries, key)
+		}
+	}
+	return entries
+}
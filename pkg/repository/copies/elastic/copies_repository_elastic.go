@@ -0,0 +1,398 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"io"
+	"log"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.CopiesRepository = &CopiesRepositoryElastic{}
+
+type CopiesRepositoryElastic struct {
+	index string
+}
+
+func NewCopiesRepositoryElastic(indexName string) interfaces.CopiesRepository {
+	return &CopiesRepositoryElastic{index: indexName}
+}
+
+func (e *CopiesRepositoryElastic) Create(bookId string, branchId string, copies int) ([]string, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	bulk := client.Bulk().Index(e.index).Refresh("wait_for")
+	for i := 0; i < copies; i++ {
+		bulk.Add(elastic.NewBulkIndexRequest().Doc(models.BookCopySource{
+			BookId:   bookId,
+			BranchId: branchId,
+			Status:   consts.CopyStatusAvailable,
+		}))
+	}
+
+	res, err := bulk.Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).Do(context.Background())
+	if err != nil || bulkFailures(res) > 0 {
+		log.Printf("error creating copies: %v", err)
+		return nil, errors.New("error creating copies")
+	}
+
+	copyIds := make([]string, 0, copies)
+	for _, item := range res.Indexed() {
+		copyIds = append(copyIds, item.Id)
+	}
+	return copyIds, nil
+}
+
+// GetAvailableBookIds pages through the books with available copies in the
+// branch, however many there are.
+func (e *CopiesRepositoryElastic) GetAvailableBookIds(branchId string) ([]string, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	query := elastic.NewBoolQuery().Filter(
+		elastic.NewTermQuery("branch_id.keyword", branchId),
+		elastic.NewTermQuery("status.keyword", consts.CopyStatusAvailable),
+	)
+
+	bookIds := make([]string, 0)
+	var after map[string]interface{}
+	for {
+		bookIdsAgg := elastic.NewCompositeAggregation().
+			Sources(elastic.NewCompositeAggregationTermsValuesSource("book_id").Field("book_id.keyword")).
+			Size(consts.BooksQuerySize)
+		if after != nil {
+			bookIdsAgg = bookIdsAgg.AggregateAfter(after)
+		}
+		searchSource := elastic.NewSearchSource().Query(query).Aggregation(consts.AvailableBookIdsAggregationName, bookIdsAgg)
+
+		searchResult, err := client.Search().
+			Index(e.index).
+			SearchSource(searchSource).
+			Size(0).
+			Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+			Do(context.Background())
+
+		if err != nil {
+			log.Printf("error searching available copies: %s", err)
+			return nil, errors.New("error searching available copies")
+		}
+
+		aggResult, found := searchResult.Aggregations.Composite(consts.AvailableBookIdsAggregationName)
+		if !found {
+			return nil, errors.New("failed to collect available books")
+		}
+		for _, bucket := range aggResult.Buckets {
+			bookIds = append(bookIds, fmt.Sprint(bucket.Key["book_id"]))
+		}
+		if len(aggResult.Buckets) < consts.BooksQuerySize || aggResult.AfterKey == nil {
+			return bookIds, nil
+		}
+		after = aggResult.AfterKey
+	}
+}
+
+func (e *CopiesRepositoryElastic) GetBranchInventory(branchId string) (*models.BranchInventory, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	branchCopies := elastic.NewFilterAggregation().
+		Filter(elastic.NewTermQuery("branch_id.keyword", branchId)).
+		SubAggregation(consts.CopiedBooksAggregationName,
+			elastic.NewCardinalityAggregation().Field("book_id.keyword")).
+		SubAggregation(consts.AvailableCopiesAggregationName,
+			elastic.NewFilterAggregation().Filter(elastic.NewTermQuery("status.keyword", consts.CopyStatusAvailable))).
+		SubAggregation(consts.OutgoingCopiesAggregationName,
+			elastic.NewFilterAggregation().Filter(elastic.NewTermQuery("status.keyword", consts.CopyStatusInTransit)))
+	incomingCopies := elastic.NewFilterAggregation().Filter(elastic.NewBoolQuery().Filter(
+		elastic.NewTermQuery("destination_branch_id.keyword", branchId),
+		elastic.NewTermQuery("status.keyword", consts.CopyStatusInTransit),
+	))
+
+	searchSource := elastic.NewSearchSource().
+		Aggregation(consts.BranchCopiesAggregationName, branchCopies).
+		Aggregation(consts.IncomingCopiesAggregationName, incomingCopies)
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		SearchSource(searchSource).
+		Size(0).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error getting branch inventory: %s", err)
+		return nil, errors.New("error getting branch inventory")
+	}
+
+	branchAgg, found := searchResult.Aggregations.Filter(consts.BranchCopiesAggregationName)
+	if !found {
+		return nil, errors.New("error getting branch inventory")
+	}
+	booksAgg, found := branchAgg.Cardinality(consts.CopiedBooksAggregationName)
+	if !found || booksAgg.Value == nil {
+		return nil, errors.New("failed to count branch books")
+	}
+	availableAgg, found := branchAgg.Filter(consts.AvailableCopiesAggregationName)
+	if !found {
+		return nil, errors.New("failed to count available copies")
+	}
+	outgoingAgg, found := branchAgg.Filter(consts.OutgoingCopiesAggregationName)
+	if !found {
+		return nil, errors.New("failed to count outgoing copies")
+	}
+	incomingAgg, found := searchResult.Aggregations.Filter(consts.IncomingCopiesAggregationName)
+	if !found {
+		return nil, errors.New("failed to count incoming copies")
+	}
+
+	return &models.BranchInventory{
+		TotalBooks:      int(*booksAgg.Value),
+		TotalCopies:     int(branchAgg.DocCount),
+		AvailableCopies: int(availableAgg.DocCount),
+		OutgoingCopies:  int(outgoingAgg.DocCount),
+		IncomingCopies:  int(incomingAgg.DocCount),
+	}, nil
+}
+
+// StartTransfer picks the copies and moves each of them in transit only while
+// it is at the version it was picked at. When another transfer took any of
+// them in between, the copies moved are put back and the transfer fails with
+// a conflict, so two transfers never take the same copy.
+func (e *CopiesRepositoryElastic) StartTransfer(bookId string, fromBranchId string, toBranchId string, copies int) (*models.Transfer, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	query := elastic.NewBoolQuery().Filter(
+		elastic.NewTermQuery("book_id.keyword", bookId),
+		elastic.NewTermQuery("branch_id.keyword", fromBranchId),
+		elastic.NewTermQuery("status.keyword", consts.CopyStatusAvailable),
+	)
+	hits, err := e.searchCopies(client, query, copies)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) < copies {
+		return nil, &models.ConflictError{Message: fmt.Sprintf("branch has only %d available copies of the book", len(hits))}
+	}
+
+	transferId, err := newTransferId()
+	if err != nil {
+		return nil, err
+	}
+
+	bulk := client.Bulk().Index(e.index).Refresh("wait_for")
+	for _, hit := range hits {
+		bulk.Add(versioned(elastic.NewBulkUpdateRequest().Id(hit.bookCopy.Id).Doc(map[string]interface{}{
+			"status":                consts.CopyStatusInTransit,
+			"destination_branch_id": toBranchId,
+			"transfer_id":           transferId,
+		}), hit))
+	}
+
+	res, err := bulk.Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).Do(context.Background())
+	if err != nil {
+		log.Printf("error starting transfer %s: %s", transferId, err)
+		return nil, errors.New("error starting transfer")
+	}
+	if bulkFailures(res) > 0 {
+		conflict := false
+		for _, item := range res.Failed() {
+			conflict = conflict || item.Status == http.StatusConflict
+		}
+		e.rollbackTransfer(client, transferId, res.Succeeded())
+		if conflict {
+			return nil, &models.ConflictError{Message: "copies of the book were taken by another transfer, try again"}
+		}
+		log.Printf("error starting transfer %s: %d copies failed", transferId, bulkFailures(res))
+		return nil, errors.New("error starting transfer")
+	}
+
+	bookCopies := make([]models.BookCopy, 0, len(hits))
+	for _, hit := range hits {
+		bookCopies = append(bookCopies, hit.bookCopy)
+	}
+	return newTransfer(transferId, bookId, fromBranchId, toBranchId, bookCopies), nil
+}
+
+// rollbackTransfer makes the copies moved by a transfer that failed to start
+// available again, unless they changed since.
+func (e *CopiesRepositoryElastic) rollbackTransfer(client *elastic.Client, transferId string, moved []*elastic.BulkResponseItem) {
+	if len(moved) == 0 {
+		return
+	}
+
+	bulk := client.Bulk().Index(e.index).Refresh("wait_for")
+	for _, item := range moved {
+		bulk.Add(elastic.NewBulkUpdateRequest().Id(item.Id).IfSeqNo(item.SeqNo).IfPrimaryTerm(item.PrimaryTerm).Doc(map[string]interface{}{
+			"status":                consts.CopyStatusAvailable,
+			"destination_branch_id": nil,
+			"transfer_id":           nil,
+		}))
+	}
+
+	res, err := bulk.Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).Do(context.Background())
+	if err != nil || bulkFailures(res) > 0 {
+		log.Printf("error rolling back transfer %s: %v", transferId, err)
+	}
+}
+
+// CompleteTransfer scrolls through the copies in transit, so every copy of the
+// transfer arrives however many there are.
+func (e *CopiesRepositoryElastic) CompleteTransfer(transferId string) (*models.Transfer, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	query := elastic.NewBoolQuery().Filter(
+		elastic.NewTermQuery("transfer_id.keyword", transferId),
+		elastic.NewTermQuery("status.keyword", consts.CopyStatusInTransit),
+	)
+	bookCopies := make([]models.BookCopy, 0)
+	err = e.scrollCopies(client, query, func(hits []copyHit) error {
+		bulk := client.Bulk().Index(e.index).Refresh("wait_for")
+		for _, hit := range hits {
+			bulk.Add(elastic.NewBulkUpdateRequest().Id(hit.bookCopy.Id).Doc(map[string]interface{}{
+				"branch_id":             hit.bookCopy.DestinationBranchId,
+				"status":                consts.CopyStatusAvailable,
+				"destination_branch_id": nil,
+				"transfer_id":           nil,
+			}))
+			bookCopies = append(bookCopies, hit.bookCopy)
+		}
+
+		res, err := bulk.Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).Do(context.Background())
+		if err != nil || bulkFailures(res) > 0 {
+			log.Printf("error completing transfer %s: %v", transferId, err)
+			return errors.New("error completing transfer")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(bookCopies) == 0 {
+		return nil, &models.NotFoundError{Resource: "transfer", Id: transferId}
+	}
+
+	first := bookCopies[0]
+	return newTransfer(transferId, first.BookId, first.BranchId, first.DestinationBranchId, bookCopies), nil
+}
+
+// copyHit is a copy together with the version it was read at.
+type copyHit struct {
+	bookCopy    models.BookCopy
+	seqNo       *int64
+	primaryTerm *int64
+}
+
+func (e *CopiesRepositoryElastic) searchCopies(client *elastic.Client, query elastic.Query, size int) ([]copyHit, error) {
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(query).
+		Size(size).
+		SeqNoAndPrimaryTerm(true).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error searching copies: %s", err)
+		return nil, errors.New("error searching copies")
+	}
+
+	return copyHits(searchResult)
+}
+
+// scrollCopies hands the copies matching the query to fn a page at a time.
+func (e *CopiesRepositoryElastic) scrollCopies(client *elastic.Client, query elastic.Query, fn func(hits []copyHit) error) error {
+	searchSource := elastic.NewSearchSource().
+		Query(query).
+		SortBy(elastic.NewFieldSort("_doc")).
+		SeqNoAndPrimaryTerm(true)
+	scroll := client.Scroll(e.index).
+		SearchSource(searchSource).
+		Size(consts.BooksScrollSize).
+		KeepAlive(consts.BooksScrollKeepAlive)
+	defer func() {
+		if err := scroll.Clear(context.Background()); err != nil {
+			log.Printf("error clearing copies scroll: %s", err)
+		}
+	}()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+		searchResult, err := scroll.Do(ctx)
+		cancel()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			log.Printf("error scrolling copies: %s", err)
+			return errors.New("error scrolling copies")
+		}
+
+		hits, err := copyHits(searchResult)
+		if err != nil {
+			return err
+		}
+		if err = fn(hits); err != nil {
+			return err
+		}
+	}
+}
+
+func copyHits(searchResult *elastic.SearchResult) ([]copyHit, error) {
+	hits := make([]copyHit, 0, len(searchResult.Hits.Hits))
+	for _, hit := range searchResult.Hits.Hits {
+		bookCopy := models.BookCopy{Id: hit.Id}
+		if err := json.Unmarshal(hit.Source, &bookCopy); err != nil {
+			return nil, err
+		}
+		hits = append(hits, copyHit{bookCopy: bookCopy, seqNo: hit.SeqNo, primaryTerm: hit.PrimaryTerm})
+	}
+	return hits, nil
+}
+
+// versioned conditions the update on the version the copy was read at.
+func versioned(request *elastic.BulkUpdateRequest, hit copyHit) *elastic.BulkUpdateRequest {
+	if hit.seqNo == nil || hit.primaryTerm == nil {
+		return request
+	}
+	return request.IfSeqNo(*hit.seqNo).IfPrimaryTerm(*hit.primaryTerm)
+}
+
+func newTransfer(transferId string, bookId string, fromBranchId string, toBranchId string, bookCopies []models.BookCopy) *models.Transfer {
+	copyIds := make([]string, 0, len(bookCopies))
+	for _, bookCopy := range bookCopies {
+		copyIds = append(copyIds, bookCopy.Id)
+	}
+
+	return &models.Transfer{
+		Id:           transferId,
+		BookId:       bookId,
+		FromBranchId: fromBranchId,
+		ToBranchId:   toBranchId,
+		CopyIds:      copyIds,
+	}
+}
@@ -0,0 +1,37 @@
+package elastic
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/olivere/elastic/v7"
+	"os"
+)
+
+func getElasticClient() (*elastic.Client, error) {
+	url := os.Getenv("ELASTICSEARCH_URL")
+	if url == "" {
+		return nil, errors.New("cannot find elastic url in the environment")
+	}
+	client, err := elastic.NewClient(elastic.SetURL(url))
+	if err != nil {
+		return nil, err
+	}
+
+	return client, err
+}
+
+func newTransferId() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func bulkFailures(res *elastic.BulkResponse) int {
+	if res == nil {
+		return 0
+	}
+	return len(res.Failed())
+}
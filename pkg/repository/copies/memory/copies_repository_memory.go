@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"fmt"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+)
+
+var _ interfaces.CopiesRepository = &CopiesRepositoryMemory{}
+
+// CopiesRepositoryMemory keeps book copies in process memory, for running
+// with the memory books backend. Transfers are applied under one lock, so
+// two transfers can never pick the same copies.
+type CopiesRepositoryMemory struct {
+	mu     sync.RWMutex
+	copies map[string]models.BookCopySource
+	ids    []string
+}
+
+func NewCopiesRepositoryMemory() interfaces.CopiesRepository {
+	return &CopiesRepositoryMemory{copies: make(map[string]models.BookCopySource)}
+}
+
+func (m *CopiesRepositoryMemory) Create(bookId string, branchId string, copies int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copyIds := make([]string, 0, copies)
+	for i := 0; i < copies; i++ {
+		id := newId()
+		m.copies[id] = models.BookCopySource{BookId: bookId, BranchId: branchId, Status: consts.CopyStatusAvailable}
+		m.ids = append(m.ids, id)
+		copyIds = append(copyIds, id)
+	}
+	return copyIds, nil
+}
+
+func (m *CopiesRepositoryMemory) GetAvailableBookIds(branchId string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bookIds := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, bookCopy := range m.find(func(bookCopy models.BookCopySource) bool {
+		return bookCopy.BranchId == branchId && bookCopy.Status == consts.CopyStatusAvailable
+	}) {
+		if !seen[bookCopy.BookId] {
+			seen[bookCopy.BookId] = true
+			bookIds = append(bookIds, bookCopy.BookId)
+		}
+	}
+	return bookIds, nil
+}
+
+func (m *CopiesRepositoryMemory) GetBranchInventory(branchId string) (*models.BranchInventory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inventory := &models.BranchInventory{}
+	books := make(map[string]bool)
+	for _, id := range m.ids {
+		bookCopy := m.copies[id]
+		if bookCopy.BranchId == branchId {
+			books[bookCopy.BookId] = true
+			inventory.TotalCopies++
+			switch bookCopy.Status {
+			case consts.CopyStatusAvailable:
+				inventory.AvailableCopies++
+			case consts.CopyStatusInTransit:
+				inventory.OutgoingCopies++
+			}
+		}
+		if bookCopy.DestinationBranchId == branchId && bookCopy.Status == consts.CopyStatusInTransit {
+			inventory.IncomingCopies++
+		}
+	}
+	inventory.TotalBooks = len(books)
+	return inventory, nil
+}
+
+func (m *CopiesRepositoryMemory) StartTransfer(bookId string, fromBranchId string, toBranchId string, copies int) (*models.Transfer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bookCopies := m.find(func(bookCopy models.BookCopySource) bool {
+		return bookCopy.BookId == bookId && bookCopy.BranchId == fromBranchId && bookCopy.Status == consts.CopyStatusAvailable
+	})
+	if len(bookCopies) < copies {
+		return nil, &models.ConflictError{Message: fmt.Sprintf("branch has only %d available copies of the book", len(bookCopies))}
+	}
+	bookCopies = bookCopies[:copies]
+
+	transferId, err := newTransferId()
+	if err != nil {
+		return nil, err
+	}
+	for _, bookCopy := range bookCopies {
+		source := m.copies[bookCopy.Id]
+		source.Status = consts.CopyStatusInTransit
+		source.DestinationBranchId = toBranchId
+		source.TransferId = transferId
+		m.copies[bookCopy.Id] = source
+	}
+
+	return newTransfer(transferId, bookId, fromBranchId, toBranchId, bookCopies), nil
+}
+
+func (m *CopiesRepositoryMemory) CompleteTransfer(transferId string) (*models.Transfer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bookCopies := m.find(func(bookCopy models.BookCopySource) bool {
+		return bookCopy.TransferId == transferId && bookCopy.Status == consts.CopyStatusInTransit
+	})
+	if len(bookCopies) == 0 {
+		return nil, &models.NotFoundError{Resource: "transfer", Id: transferId}
+	}
+
+	for _, bookCopy := range bookCopies {
+		m.copies[bookCopy.Id] = models.BookCopySource{
+			BookId:   bookCopy.BookId,
+			BranchId: bookCopy.DestinationBranchId,
+			Status:   consts.CopyStatusAvailable,
+		}
+	}
+
+	first := bookCopies[0]
+	return newTransfer(transferId, first.BookId, first.BranchId, first.DestinationBranchId, bookCopies), nil
+}
+
+func (m *CopiesRepositoryMemory) find(matches func(bookCopy models.BookCopySource) bool) []models.BookCopy {
+	bookCopies := make([]models.BookCopy, 0)
+	for _, id := range m.ids {
+		source := m.copies[id]
+		if matches(source) {
+			bookCopies = append(bookCopies, models.BookCopy{
+				Id:                  id,
+				BookId:              source.BookId,
+				BranchId:            source.BranchId,
+				Status:              source.Status,
+				DestinationBranchId: source.DestinationBranchId,
+				TransferId:          source.TransferId,
+			})
+		}
+	}
+	return bookCopies
+}
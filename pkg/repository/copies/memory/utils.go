@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"pkg/service/pkg/models"
+)
+
+func newId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newTransferId() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func newTransfer(transferId string, bookId string, fromBranchId string, toBranchId string, bookCopies []models.BookCopy) *models.Transfer {
+	copyIds := make([]string, 0, len(bookCopies))
+	for _, bookCopy := range bookCopies {
+		copyIds = append(copyIds, bookCopy.Id)
+	}
+
+	return &models.Transfer{
+		Id:           transferId,
+		BookId:       bookId,
+		FromBranchId: fromBranchId,
+		ToBranchId:   toBranchId,
+		CopyIds:      copyIds,
+	}
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+)
+
+var _ interfaces.EventOutboxRepository = &EventOutboxRepositoryMemory{}
+
+type EventOutboxRepositoryMemory struct {
+	mu     sync.Mutex
+	events []models.BookEvent
+}
+
+func NewEventOutboxRepositoryMemory() interfaces.EventOutboxRepository {
+	return &EventOutboxRepositoryMemory{}
+}
+
+func (m *EventOutboxRepositoryMemory) Add(events []models.BookEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *EventOutboxRepositoryMemory) Pending(limit int) ([]models.BookEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit > len(m.events) {
+		limit = len(m.events)
+	}
+	return append([]models.BookEvent{}, m.events[:limit]...), nil
+}
+
+func (m *EventOutboxRepositoryMemory) Ack(eventIds []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acked := make(map[string]bool, len(eventIds))
+	for _, eventId := range eventIds {
+		acked[eventId] = true
+	}
+	pending := m.events[:0]
+	for _, event := range m.events {
+		if !acked[event.Id] {
+			pending = append(pending, event)
+		}
+	}
+	m.events = pending
+	return nil
+}
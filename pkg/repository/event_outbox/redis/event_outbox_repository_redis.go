@@ -0,0 +1,126 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.EventOutboxRepository = &EventOutboxRepositoryRedis{}
+
+// EventOutboxRepositoryRedis keeps the outbox in a list, appending at the
+// tail and acknowledging from the head.
+type EventOutboxRepositoryRedis struct{}
+
+// ackScript removes the events with the given ids from the head of the
+// outbox. Events are only appended at the tail, so the acknowledged ones are
+// among the first as many entries as there are ids, unless they are gone
+// already.
+var ackScript = redis.NewScript(`
+local acked = {}
+for _, id in ipairs(ARGV) do
+	acked[id] = true
+end
+local values = redis.call('LRANGE', KEYS[1], 0, #ARGV - 1)
+local removed = 0
+for _, value in ipairs(values) do
+	if acked[cjson.decode(value).id] then
+		removed = removed + redis.call('LREM', KEYS[1], 1, value)
+	end
+end
+return removed
+`)
+
+func NewEventOutboxRepositoryRedis() interfaces.EventOutboxRepository {
+	return &EventOutboxRepositoryRedis{}
+}
+
+func (r *EventOutboxRepositoryRedis) Add(events []models.BookEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	values := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	if err = client.RPush(ctx, consts.EventOutboxRedisKey, values...).Err(); err != nil {
+		log.Printf("error adding %d events to the outbox: %s", len(events), err)
+		return errors.New("error adding events to the outbox")
+	}
+
+	return nil
+}
+
+func (r *EventOutboxRepositoryRedis) Pending(limit int) ([]models.BookEvent, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	values, err := client.LRange(ctx, consts.EventOutboxRedisKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		log.Printf("error getting pending events: %s", err)
+		return nil, errors.New("error getting pending events")
+	}
+
+	events := make([]models.BookEvent, 0, len(values))
+	for _, value := range values {
+		event := models.BookEvent{}
+		if err = json.Unmarshal([]byte(value), &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (r *EventOutboxRepositoryRedis) Ack(eventIds []string) error {
+	if len(eventIds) == 0 {
+		return nil
+	}
+
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	args := make([]interface{}, 0, len(eventIds))
+	for _, eventId := range eventIds {
+		args = append(args, eventId)
+	}
+	if err = ackScript.Run(ctx, client, []string{consts.EventOutboxRedisKey}, args...).Err(); err != nil {
+		log.Printf("error acknowledging %d events: %s", len(eventIds), err)
+		return errors.New("error acknowledging events")
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"strconv"
+	"sync"
+)
+
+var _ interfaces.EventStream = &EventStreamFile{}
+
+// EventStreamFile appends the events to a JSON lines file, one stream event
+// per line. Offsets are line numbers, and the committed offsets are kept in a
+// JSON file next to it. Reads scan the file from the start, so it suits
+// local setups rather than long lived streams.
+type EventStreamFile struct {
+	mu   sync.Mutex
+	path string
+	// last is the offset of the last line, counted on first use
+	last *int
+}
+
+func NewEventStreamFile(path string) interfaces.EventStream {
+	return &EventStreamFile{path: path}
+}
+
+func (f *EventStreamFile) Append(events []models.BookEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	last, err := f.lastOffset()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("error opening event stream file %s: %s", f.path, err)
+		return errors.New("error appending events to the stream")
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		last++
+		if err = encoder.Encode(models.StreamEvent{Offset: strconv.Itoa(last), Event: event}); err != nil {
+			return err
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		log.Printf("error writing event stream file %s: %s", f.path, err)
+		return errors.New("error appending events to the stream")
+	}
+
+	f.last = &last
+	return nil
+}
+
+func (f *EventStreamFile) Read(after string, limit int) ([]models.StreamEvent, error) {
+	sequence, err := parseOffset(after)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := make([]models.StreamEvent, 0)
+	line := 0
+	err = f.scan(func(data []byte) (bool, error) {
+		line++
+		if line <= sequence {
+			return true, nil
+		}
+		event := models.StreamEvent{}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return false, err
+		}
+		events = append(events, event)
+		return len(events) < limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (f *EventStreamFile) LastOffset() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	last, err := f.lastOffset()
+	if err != nil || last == 0 {
+		return "", err
+	}
+	return strconv.Itoa(last), nil
+}
+
+func (f *EventStreamFile) GetOffset(consumer string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	offsets, err := f.readOffsets()
+	if err != nil {
+		return "", err
+	}
+	return offsets[consumer], nil
+}
+
+// CommitOffset rewrites the offsets file through a temporary file, so a
+// crash leaves either the old or the new offsets.
+func (f *EventStreamFile) CommitOffset(consumer string, offset string) error {
+	if _, err := parseOffset(offset); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	offsets, err := f.readOffsets()
+	if err != nil {
+		return err
+	}
+	offsets[consumer] = offset
+
+	data, err := json.Marshal(offsets)
+	if err != nil {
+		return err
+	}
+	tmpPath := f.offsetsPath() + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0o644); err != nil {
+		log.Printf("error writing offsets file %s: %s", tmpPath, err)
+		return errors.New("error committing consumer offset")
+	}
+	if err = os.Rename(tmpPath, f.offsetsPath()); err != nil {
+		log.Printf("error replacing offsets file %s: %s", f.offsetsPath(), err)
+		return errors.New("error committing consumer offset")
+	}
+	return nil
+}
+
+func (f *EventStreamFile) lastOffset() (int, error) {
+	if f.last != nil {
+		return *f.last, nil
+	}
+
+	last := 0
+	err := f.scan(func([]byte) (bool, error) {
+		last++
+		return true, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	f.last = &last
+	return last, nil
+}
+
+// scan calls fn with every line of the stream file until it returns false.
+// A missing file is an empty stream.
+func (f *EventStreamFile) scan(fn func(data []byte) (bool, error)) error {
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("error opening event stream file %s: %s", f.path, err)
+		return errors.New("error reading the event stream")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		more, err := fn(scanner.Bytes())
+		if err != nil || !more {
+			return err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		log.Printf("error reading event stream file %s: %s", f.path, err)
+		return errors.New("error reading the event stream")
+	}
+	return nil
+}
+
+func (f *EventStreamFile) offsetsPath() string {
+	return f.path + ".offsets"
+}
+
+func (f *EventStreamFile) readOffsets() (map[string]string, error) {
+	offsets := make(map[string]string)
+	data, err := os.ReadFile(f.offsetsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return offsets, nil
+	}
+	if err != nil {
+		log.Printf("error reading offsets file %s: %s", f.offsetsPath(), err)
+		return nil, errors.New("error reading consumer offsets")
+	}
+	if err = json.Unmarshal(data, &offsets); err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+func parseOffset(offset string) (int, error) {
+	if offset == "" {
+		return 0, nil
+	}
+	sequence, err := strconv.Atoi(offset)
+	if err != nil || sequence < 0 {
+		return 0, &models.ValidationError{Message: "invalid offset " + offset}
+	}
+	return sequence, nil
+}
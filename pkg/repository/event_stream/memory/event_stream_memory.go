@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"strconv"
+	"sync"
+)
+
+var _ interfaces.EventStream = &EventStreamMemory{}
+
+// EventStreamMemory keeps the newest consts.EventStreamMaxLength events in
+// memory. Offsets are sequence numbers starting at 1.
+type EventStreamMemory struct {
+	mu      sync.RWMutex
+	events  []models.StreamEvent
+	last    int
+	offsets map[string]string
+}
+
+func NewEventStreamMemory() interfaces.EventStream {
+	return &EventStreamMemory{offsets: make(map[string]string)}
+}
+
+func (m *EventStreamMemory) Append(events []models.BookEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, event := range events {
+		m.last++
+		m.events = append(m.events, models.StreamEvent{Offset: strconv.Itoa(m.last), Event: event})
+	}
+	if len(m.events) > consts.EventStreamMaxLength {
+		m.events = m.events[len(m.events)-consts.EventStreamMaxLength:]
+	}
+	return nil
+}
+
+func (m *EventStreamMemory) Read(after string, limit int) ([]models.StreamEvent, error) {
+	sequence, err := parseOffset(after)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// The first event kept has the offset of the last one minus the kept count
+	start := sequence - (m.last - len(m.events))
+	if start < 0 {
+		start = 0
+	}
+	events := make([]models.StreamEvent, 0)
+	for i := start; i < len(m.events) && len(events) < limit; i++ {
+		events = append(events, m.events[i])
+	}
+	return events, nil
+}
+
+func (m *EventStreamMemory) LastOffset() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.last == 0 {
+		return "", nil
+	}
+	return strconv.Itoa(m.last), nil
+}
+
+func (m *EventStreamMemory) GetOffset(consumer string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.offsets[consumer], nil
+}
+
+func (m *EventStreamMemory) CommitOffset(consumer string, offset string) error {
+	if _, err := parseOffset(offset); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.offsets[consumer] = offset
+	return nil
+}
+
+func parseOffset(offset string) (int, error) {
+	if offset == "" {
+		return 0, nil
+	}
+	sequence, err := strconv.Atoi(offset)
+	if err != nil || sequence < 0 {
+		return 0, &models.ValidationError{Message: "invalid offset " + offset}
+	}
+	return sequence, nil
+}
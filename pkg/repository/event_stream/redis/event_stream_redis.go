@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"regexp"
+	"time"
+)
+
+var _ interfaces.EventStream = &EventStreamRedis{}
+
+var streamIdPattern = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+// EventStreamRedis appends the events to a Redis stream, capped at about
+// consts.EventStreamMaxLength entries. Offsets are the stream entry ids and
+// the committed offsets are kept in a hash by consumer.
+type EventStreamRedis struct{}
+
+func NewEventStreamRedis() interfaces.EventStream {
+	return &EventStreamRedis{}
+}
+
+func (r *EventStreamRedis) Append(events []models.BookEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, event := range events {
+			value, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream:       consts.EventStreamRedisKey,
+				MaxLenApprox: consts.EventStreamMaxLength,
+				Values:       map[string]interface{}{"type": event.Type, "event": value},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error appending %d events to the stream: %s", len(events), err)
+		return errors.New("error appending events to the stream")
+	}
+
+	return nil
+}
+
+func (r *EventStreamRedis) Read(after string, limit int) ([]models.StreamEvent, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	if after == "" {
+		after = "0"
+	}
+	if !streamIdPattern.MatchString(after) {
+		return nil, &models.ValidationError{Message: "invalid offset " + after}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	streams, err := client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{consts.EventStreamRedisKey, after},
+		Count:   int64(limit),
+		Block:   -1,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return make([]models.StreamEvent, 0), nil
+	}
+	if err != nil {
+		log.Printf("error reading the stream after %s: %s", after, err)
+		return nil, errors.New("error reading the event stream")
+	}
+
+	events := make([]models.StreamEvent, 0)
+	for _, stream := range streams {
+		for _, message := range stream.Messages {
+			value, _ := message.Values["event"].(string)
+			event := models.BookEvent{}
+			if err = json.Unmarshal([]byte(value), &event); err != nil {
+				return nil, err
+			}
+			events = append(events, models.StreamEvent{Offset: message.ID, Event: event})
+		}
+	}
+	return events, nil
+}
+
+func (r *EventStreamRedis) LastOffset() (string, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return "", err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	messages, err := client.XRevRangeN(ctx, consts.EventStreamRedisKey, "+", "-", 1).Result()
+	if err != nil {
+		log.Printf("error getting the last offset of the stream: %s", err)
+		return "", errors.New("error getting the last offset of the event stream")
+	}
+	if len(messages) == 0 {
+		return "", nil
+	}
+
+	return messages[0].ID, nil
+}
+
+func (r *EventStreamRedis) GetOffset(consumer string) (string, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return "", err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	offset, err := client.HGet(ctx, consts.EventOffsetsRedisKey, consumer).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		log.Printf("error getting the offset of %s: %s", consumer, err)
+		return "", errors.New("error getting consumer offset")
+	}
+
+	return offset, nil
+}
+
+func (r *EventStreamRedis) CommitOffset(consumer string, offset string) error {
+	if !streamIdPattern.MatchString(offset) {
+		return &models.ValidationError{Message: "invalid offset " + offset}
+	}
+
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	if err = client.HSet(ctx, consts.EventOffsetsRedisKey, consumer, offset).Err(); err != nil {
+		log.Printf("error committing the offset of %s: %s", consumer, err)
+		return errors.New("error committing consumer offset")
+	}
+
+	return nil
+}
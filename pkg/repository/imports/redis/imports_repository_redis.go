@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"time"
+)
+
+var _ interfaces.ImportsRepository = &ImportsRepositoryRedis{}
+
+type ImportsRepositoryRedis struct{}
+
+func NewImportsRepositoryRedis() interfaces.ImportsRepository {
+	return &ImportsRepositoryRedis{}
+}
+
+func (r *ImportsRepositoryRedis) GetCheckpoint(importId string) (int, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return 0, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	row, err := client.Get(ctx, createCheckpointKey(importId)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		log.Printf("error getting checkpoint for import %s: %s", importId, err)
+		return 0, errors.New(fmt.Sprintf("error getting checkpoint for import %s", importId))
+	}
+
+	return row, nil
+}
+
+func (r *ImportsRepositoryRedis) SaveCheckpoint(importId string, row int) error {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	err = client.Set(ctx, createCheckpointKey(importId), row, consts.ImportCheckpointTtlHours*time.Hour).Err()
+	if err != nil {
+		log.Printf("error saving checkpoint for import %s: %s", importId, err)
+		return errors.New(fmt.Sprintf("error saving checkpoint for import %s", importId))
+	}
+
+	return nil
+}
+
+func (r *ImportsRepositoryRedis) DeleteCheckpoint(importId string) error {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	if err = client.Del(ctx, createCheckpointKey(importId)).Err(); err != nil {
+		log.Printf("error deleting checkpoint for import %s: %s", importId, err)
+		return errors.New(fmt.Sprintf("error deleting checkpoint for import %s", importId))
+	}
+
+	return nil
+}
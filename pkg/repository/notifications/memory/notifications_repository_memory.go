@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+)
+
+var _ interfaces.NotificationsRepository = &NotificationsRepositoryMemory{}
+
+type NotificationsRepositoryMemory struct {
+	mu            sync.RWMutex
+	notifications map[string][]models.Notification
+}
+
+func NewNotificationsRepositoryMemory() interfaces.NotificationsRepository {
+	return &NotificationsRepositoryMemory{notifications: make(map[string][]models.Notification)}
+}
+
+func (m *NotificationsRepositoryMemory) Save(notifications []models.Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, notification := range notifications {
+		userNotifications := append(m.notifications[notification.Username], notification)
+		if len(userNotifications) > consts.NotificationsPerUser {
+			userNotifications = userNotifications[len(userNotifications)-consts.NotificationsPerUser:]
+		}
+		m.notifications[notification.Username] = userNotifications
+	}
+	return nil
+}
+
+// Get returns the newest notifications first, like the Redis repository.
+func (m *NotificationsRepositoryMemory) Get(username string, limit int) ([]models.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	userNotifications := m.notifications[username]
+	notifications := make([]models.Notification, 0)
+	for i := len(userNotifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		notifications = append(notifications, userNotifications[i])
+	}
+	return notifications, nil
+}
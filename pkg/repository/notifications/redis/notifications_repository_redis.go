@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"time"
+)
+
+var _ interfaces.NotificationsRepository = &NotificationsRepositoryRedis{}
+
+// NotificationsRepositoryRedis keeps a list of the newest notifications of
+// every user, trimmed to consts.NotificationsPerUser.
+type NotificationsRepositoryRedis struct{}
+
+func NewNotificationsRepositoryRedis() interfaces.NotificationsRepository {
+	return &NotificationsRepositoryRedis{}
+}
+
+func (r *NotificationsRepositoryRedis) Save(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, notification := range notifications {
+			value, err := json.Marshal(notification)
+			if err != nil {
+				return err
+			}
+			key := createNotificationsKey(notification.Username)
+			pipe.LPush(ctx, key, value)
+			pipe.LTrim(ctx, key, 0, consts.NotificationsPerUser-1)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error saving %d notifications: %s", len(notifications), err)
+		return errors.New("error saving notifications")
+	}
+
+	return nil
+}
+
+func (r *NotificationsRepositoryRedis) Get(username string, limit int) ([]models.Notification, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	values, err := client.LRange(ctx, createNotificationsKey(username), 0, int64(limit-1)).Result()
+	if err != nil {
+		log.Printf("error getting notifications of %s: %s", username, err)
+		return nil, errors.New("error getting notifications")
+	}
+
+	notifications := make([]models.Notification, 0, len(values))
+	for _, value := range values {
+		notification := models.Notification{}
+		if err = json.Unmarshal([]byte(value), &notification); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
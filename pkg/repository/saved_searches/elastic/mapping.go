@@ -0,0 +1,57 @@
+package elastic
+
+import (
+	"context"
+	"errors"
+	"log"
+	"pkg/service/pkg/consts"
+	books_elastic "pkg/service/pkg/repository/books/elastic"
+	"time"
+)
+
+// The percolator parses every query against the book fields, so the index
+// maps them next to the saved search itself. Book fields that are not mapped
+// are ignored when a book is percolated.
+func savedSearchesIndexBody() map[string]interface{} {
+	properties := books_elastic.IndexProperties()
+	properties["query"] = map[string]interface{}{"type": "percolator"}
+	properties["username"] = map[string]interface{}{"type": "keyword"}
+	properties["name"] = map[string]interface{}{"type": "keyword"}
+	properties["filters"] = map[string]interface{}{"type": "object", "enabled": false}
+	properties["created_at"] = map[string]interface{}{"type": "date"}
+
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"dynamic":    false,
+			"properties": properties,
+		},
+	}
+}
+
+// EnsureIndex creates the saved searches index if it does not exist yet.
+func EnsureIndex(indexName string) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+
+	exists, err := client.IndexExists(indexName).Do(ctx)
+	if err != nil {
+		log.Printf("error checking saved searches index: %s", err)
+		return errors.New("error checking saved searches index")
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err = client.CreateIndex(indexName).BodyJson(savedSearchesIndexBody()).Do(ctx); err != nil {
+		log.Printf("error creating saved searches index: %s", err)
+		return errors.New("error creating saved searches index")
+	}
+
+	return nil
+}
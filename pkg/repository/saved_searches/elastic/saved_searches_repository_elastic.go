@@ -0,0 +1,168 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	books_elastic "pkg/service/pkg/repository/books/elastic"
+	"time"
+)
+
+var _ interfaces.SavedSearchesRepository = &SavedSearchesRepositoryElastic{}
+
+// SavedSearchesRepositoryElastic stores every saved search with its filters
+// as a percolator query, so a new book is matched against all of them in a
+// single search.
+type SavedSearchesRepositoryElastic struct {
+	index string
+}
+
+type savedSearchDocument struct {
+	models.SavedSearch
+	Query interface{} `json:"query"`
+}
+
+func NewSavedSearchesRepositoryElastic(indexName string) interfaces.SavedSearchesRepository {
+	return &SavedSearchesRepositoryElastic{index: indexName}
+}
+
+func (e *SavedSearchesRepositoryElastic) Create(search models.SavedSearch) (string, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return "", err
+	}
+	defer client.Stop()
+
+	query, err := books_elastic.FetchQuery(search.Filters.BookFilters()).Source()
+	if err != nil {
+		log.Printf("error building saved search query: %s", err)
+		return "", errors.New("error creating saved search")
+	}
+
+	res, err := client.Index().
+		Index(e.index).
+		BodyJson(savedSearchDocument{SavedSearch: search, Query: query}).
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error creating saved search: %s", err)
+		return "", errors.New("error creating saved search")
+	}
+
+	return res.Id, nil
+}
+
+func (e *SavedSearchesRepositoryElastic) Get(username string) ([]models.SavedSearch, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(elastic.NewTermQuery("username", username)).
+		SortBy(elastic.NewFieldSort("created_at")).
+		Size(consts.SavedSearchesPerUser).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error getting saved searches: %s", err)
+		return nil, errors.New("error getting saved searches")
+	}
+
+	return savedSearchesFromHits(searchResult.Hits.Hits)
+}
+
+func (e *SavedSearchesRepositoryElastic) Delete(username string, id string) error {
+	client, err := getElasticClient()
+	if err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.BooksRequestTimeout*time.Second)
+	defer cancel()
+	res, err := client.Get().
+		Index(e.index).
+		Id(id).
+		Do(ctx)
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return &models.NotFoundError{Resource: "saved search", Id: id}
+		}
+		log.Printf("error getting saved search: %s", err)
+		return errors.New("error deleting saved search")
+	}
+
+	search := models.SavedSearch{}
+	if err = json.Unmarshal(res.Source, &search); err != nil {
+		return err
+	}
+	// Searches of other users are reported missing rather than forbidden
+	if search.Username != username {
+		return &models.NotFoundError{Resource: "saved search", Id: id}
+	}
+
+	_, err = client.Delete().
+		Index(e.index).
+		Id(id).
+		Refresh("wait_for").
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return &models.NotFoundError{Resource: "saved search", Id: id}
+		}
+		log.Printf("error deleting saved search: %s", err)
+		return errors.New("error deleting saved search")
+	}
+
+	return nil
+}
+
+func (e *SavedSearchesRepositoryElastic) Match(book models.Book) ([]models.SavedSearch, error) {
+	client, err := getElasticClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Stop()
+
+	searchResult, err := client.Search().
+		Index(e.index).
+		Query(elastic.NewPercolatorQuery().Field("query").Document(book)).
+		Size(consts.SavedSearchMatchSize).
+		Timeout(fmt.Sprintf("%ds", consts.BooksRequestTimeout)).
+		Do(context.Background())
+
+	if err != nil {
+		log.Printf("error matching saved searches: %s", err)
+		return nil, errors.New("error matching saved searches")
+	}
+
+	return savedSearchesFromHits(searchResult.Hits.Hits)
+}
+
+func savedSearchesFromHits(hits []*elastic.SearchHit) ([]models.SavedSearch, error) {
+	searches := make([]models.SavedSearch, 0, len(hits))
+	for _, hit := range hits {
+		search := models.SavedSearch{}
+		if err := json.Unmarshal(hit.Source, &search); err != nil {
+			return nil, err
+		}
+		search.Id = hit.Id
+		searches = append(searches, search)
+	}
+	return searches, nil
+}
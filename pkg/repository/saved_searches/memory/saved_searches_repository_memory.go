@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+)
+
+var _ interfaces.SavedSearchesRepository = &SavedSearchesRepositoryMemory{}
+
+type SavedSearchesRepositoryMemory struct {
+	mu       sync.RWMutex
+	searches []models.SavedSearch
+}
+
+func NewSavedSearchesRepositoryMemory() interfaces.SavedSearchesRepository {
+	return &SavedSearchesRepositoryMemory{}
+}
+
+func (m *SavedSearchesRepositoryMemory) Create(search models.SavedSearch) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	search.Id = newId()
+	m.searches = append(m.searches, search)
+	return search.Id, nil
+}
+
+func (m *SavedSearchesRepositoryMemory) Get(username string) ([]models.SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	searches := make([]models.SavedSearch, 0)
+	for _, search := range m.searches {
+		if search.Username == username {
+			searches = append(searches, search)
+		}
+	}
+	return searches, nil
+}
+
+func (m *SavedSearchesRepositoryMemory) Delete(username string, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, search := range m.searches {
+		if search.Id == id && search.Username == username {
+			m.searches = append(m.searches[:i], m.searches[i+1:]...)
+			return nil
+		}
+	}
+	return &models.NotFoundError{Resource: "saved search", Id: id}
+}
+
+// Match checks the book against every saved search, which is fine for the
+// number of searches a memory backend holds.
+func (m *SavedSearchesRepositoryMemory) Match(book models.Book) ([]models.SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches := make([]models.SavedSearch, 0)
+	for _, search := range m.searches {
+		if search.Filters.BookFilters().Matches(book) {
+			matches = append(matches, search)
+		}
+	}
+	return matches, nil
+}
+
+func newId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
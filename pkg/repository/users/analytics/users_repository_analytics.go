@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"log"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+)
+
+var _ interfaces.UsersRepository = &UsersRepositoryAnalytics{}
+
+// UsersRepositoryAnalytics counts the actions saved to the backing repository
+// in the analytics repository. Counting is best effort and never fails a save.
+type UsersRepositoryAnalytics struct {
+	backing             interfaces.UsersRepository
+	analyticsRepository interfaces.AnalyticsRepository
+}
+
+func NewUsersRepositoryAnalytics(backing interfaces.UsersRepository, analyticsRepository interfaces.AnalyticsRepository) interfaces.UsersRepository {
+	return &UsersRepositoryAnalytics{
+		backing:             backing,
+		analyticsRepository: analyticsRepository,
+	}
+}
+
+func (a *UsersRepositoryAnalytics) SaveAction(ua models.UserAction) error {
+	return a.SaveActions([]models.UserAction{ua})
+}
+
+func (a *UsersRepositoryAnalytics) SaveActions(actions []models.UserAction) error {
+	if err := a.backing.SaveActions(actions); err != nil {
+		return err
+	}
+
+	if err := a.analyticsRepository.RecordActions(actions); err != nil {
+		log.Printf("error recording analytics: %s", err)
+	}
+	return nil
+}
+
+func (a *UsersRepositoryAnalytics) GetActivity(username string, filters models.ActivityFilters) (*models.UserActivity, error) {
+	return a.backing.GetActivity(username, filters)
+}
+
+func (a *UsersRepositoryAnalytics) ClearActivity(username string) error {
+	return a.backing.ClearActivity(username)
+}
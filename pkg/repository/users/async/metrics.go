@@ -0,0 +1,14 @@
+package async
+
+import (
+	"expvar"
+	"pkg/service/pkg/models"
+)
+
+// metrics is served under user_activity_queue on /debug/vars.
+var metrics = expvar.NewMap("user_activity_queue")
+
+func publishQueue(queue chan models.UserAction) {
+	metrics.Set("depth", expvar.Func(func() any { return len(queue) }))
+	metrics.Set("capacity", expvar.Func(func() any { return cap(queue) }))
+}
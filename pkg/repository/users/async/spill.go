@@ -0,0 +1,106 @@
+package async
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"pkg/service/pkg/models"
+	"sync"
+)
+
+// spillFile keeps the actions that did not fit in the queue as JSON lines, to
+// be replayed into the backing repository later. It survives restarts, so
+// actions spilled before a crash are replayed by the next run.
+type spillFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (s *spillFile) write(actions []models.UserAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, ua := range actions {
+		if err = encoder.Encode(ua); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// replay moves the spilled actions aside and saves them in batches, so new
+// actions can keep spilling meanwhile. A file left aside by a failed replay is
+// saved first. When saving fails the file is kept and replayed again from the
+// start next time, which rewrites the actions that were already saved.
+func (s *spillFile) replay(batchSize int, save func([]models.UserAction) error) (int, error) {
+	replayPath := s.path + ".replay"
+	replayed := 0
+	for pass := 0; pass < 2; pass++ {
+		if _, err := os.Stat(replayPath); errors.Is(err, os.ErrNotExist) {
+			s.mu.Lock()
+			err = os.Rename(s.path, replayPath)
+			s.mu.Unlock()
+			if errors.Is(err, os.ErrNotExist) {
+				return replayed, nil
+			}
+			if err != nil {
+				return replayed, err
+			}
+		}
+
+		saved, err := replayFile(replayPath, batchSize, save)
+		replayed += saved
+		if err != nil {
+			return replayed, err
+		}
+	}
+	return replayed, nil
+}
+
+func replayFile(path string, batchSize int, save func([]models.UserAction) error) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	replayed := 0
+	batch := make([]models.UserAction, 0, batchSize)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		ua := models.UserAction{}
+		if err = json.Unmarshal(scanner.Bytes(), &ua); err != nil {
+			log.Printf("error reading spilled user action: %s", err)
+			continue
+		}
+		batch = append(batch, ua)
+		if len(batch) == batchSize {
+			if err = save(batch); err != nil {
+				return replayed, err
+			}
+			replayed += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return replayed, err
+	}
+	if len(batch) > 0 {
+		if err = save(batch); err != nil {
+			return replayed, err
+		}
+		replayed += len(batch)
+	}
+
+	file.Close()
+	return replayed, os.Remove(path)
+}
@@ -0,0 +1,219 @@
+package async
+
+import (
+	"context"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+	"time"
+)
+
+var _ interfaces.UsersRepository = &UsersRepositoryAsync{}
+
+type Options struct {
+	QueueSize      int
+	Workers        int
+	BatchSize      int
+	FlushInterval  time.Duration
+	OverflowPolicy string
+	SpillPath      string
+}
+
+// UsersRepositoryAsync queues the actions it is given and writes them to the
+// backing repository in batches from worker goroutines, so requests do not
+// wait on the backing store. Reads go straight to the backing repository and
+// do not see the actions that are still queued.
+type UsersRepositoryAsync struct {
+	backing interfaces.UsersRepository
+	options Options
+	queue   chan models.UserAction
+	spill   *spillFile
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+}
+
+func NewUsersRepositoryAsync(backing interfaces.UsersRepository, options Options) *UsersRepositoryAsync {
+	a := &UsersRepositoryAsync{
+		backing: backing,
+		options: options,
+		queue:   make(chan models.UserAction, options.QueueSize),
+		stop:    make(chan struct{}),
+	}
+	publishQueue(a.queue)
+
+	for i := 0; i < options.Workers; i++ {
+		a.wg.Add(1)
+		go a.work()
+	}
+	if options.OverflowPolicy == consts.OverflowPolicySpill {
+		a.spill = &spillFile{path: options.SpillPath}
+		a.wg.Add(1)
+		go a.replaySpill()
+	}
+
+	return a
+}
+
+// SaveAction queues the action, and applies the overflow policy when the
+// queue is full. Once the repository is closed actions are written directly.
+func (a *UsersRepositoryAsync) SaveAction(ua models.UserAction) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return a.backing.SaveAction(ua)
+	}
+
+	if a.options.OverflowPolicy == consts.OverflowPolicyBlock {
+		a.queue <- ua
+		metrics.Add("enqueued", 1)
+		return nil
+	}
+
+	select {
+	case a.queue <- ua:
+		metrics.Add("enqueued", 1)
+	default:
+		if a.spill == nil {
+			metrics.Add("dropped", 1)
+			return nil
+		}
+		if err := a.spill.write([]models.UserAction{ua}); err != nil {
+			log.Printf("error spilling user action: %s", err)
+			metrics.Add("dropped", 1)
+			return nil
+		}
+		metrics.Add("spilled", 1)
+	}
+	return nil
+}
+
+func (a *UsersRepositoryAsync) SaveActions(actions []models.UserAction) error {
+	for _, ua := range actions {
+		if err := a.SaveAction(ua); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *UsersRepositoryAsync) GetActivity(username string, filters models.ActivityFilters) (*models.UserActivity, error) {
+	return a.backing.GetActivity(username, filters)
+}
+
+func (a *UsersRepositoryAsync) ClearActivity(username string) error {
+	return a.backing.ClearActivity(username)
+}
+
+// Close stops accepting actions into the queue and waits until the workers
+// have flushed everything queued, or until ctx is done.
+func (a *UsersRepositoryAsync) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	close(a.stop)
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if a.spill != nil {
+		a.replay()
+	}
+	return nil
+}
+
+func (a *UsersRepositoryAsync) work() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.UserAction, 0, a.options.BatchSize)
+	for {
+		select {
+		case ua, ok := <-a.queue:
+			if !ok {
+				a.flush(batch)
+				return
+			}
+			batch = append(batch, ua)
+			if len(batch) >= a.options.BatchSize {
+				a.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			a.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush writes a batch to the backing repository. A batch that fails is
+// spilled when spilling is enabled, and lost otherwise.
+func (a *UsersRepositoryAsync) flush(batch []models.UserAction) {
+	if len(batch) == 0 {
+		return
+	}
+
+	err := a.backing.SaveActions(batch)
+	if err == nil {
+		metrics.Add("flushed", int64(len(batch)))
+		return
+	}
+
+	log.Printf("error flushing %d user actions: %s", len(batch), err)
+	metrics.Add("flush_failures", 1)
+	if a.spill == nil {
+		metrics.Add("dropped", int64(len(batch)))
+		return
+	}
+	if err = a.spill.write(batch); err != nil {
+		log.Printf("error spilling user actions: %s", err)
+		metrics.Add("dropped", int64(len(batch)))
+		return
+	}
+	metrics.Add("spilled", int64(len(batch)))
+}
+
+func (a *UsersRepositoryAsync) replaySpill() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(consts.UserActivitySpillReplaySeconds * time.Second)
+	defer ticker.Stop()
+
+	for {
+		a.replay()
+
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *UsersRepositoryAsync) replay() {
+	replayed, err := a.spill.replay(a.options.BatchSize, a.backing.SaveActions)
+	metrics.Add("replayed", int64(replayed))
+	if err != nil {
+		log.Printf("error replaying spilled user actions: %s", err)
+	}
+}
@@ -0,0 +1,46 @@
+package live
+
+import (
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+)
+
+var _ interfaces.UsersRepository = &UsersRepositoryLive{}
+
+// UsersRepositoryLive publishes the actions saved to the backing repository
+// to the live feed.
+type UsersRepositoryLive struct {
+	backing interfaces.UsersRepository
+	feed    interfaces.LiveFeed
+}
+
+func NewUsersRepositoryLive(backing interfaces.UsersRepository, feed interfaces.LiveFeed) interfaces.UsersRepository {
+	return &UsersRepositoryLive{
+		backing: backing,
+		feed:    feed,
+	}
+}
+
+func (l *UsersRepositoryLive) SaveAction(ua models.UserAction) error {
+	return l.SaveActions([]models.UserAction{ua})
+}
+
+func (l *UsersRepositoryLive) SaveActions(actions []models.UserAction) error {
+	if err := l.backing.SaveActions(actions); err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		l.feed.Publish(consts.LiveTopicActivity, consts.LiveEventActivity, action.Username, action)
+	}
+	return nil
+}
+
+func (l *UsersRepositoryLive) GetActivity(username string, filters models.ActivityFilters) (*models.UserActivity, error) {
+	return l.backing.GetActivity(username, filters)
+}
+
+func (l *UsersRepositoryLive) ClearActivity(username string) error {
+	return l.backing.ClearActivity(username)
+}
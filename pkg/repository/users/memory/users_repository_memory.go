@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ interfaces.UsersRepository = &UsersRepositoryMemory{}
+
+type UsersRepositoryMemory struct {
+	activityActions int
+	retention       time.Duration
+	mu              sync.Mutex
+	activity        map[string][]models.UserAction
+}
+
+func NewUsersRepositoryMemory(activityActions int, retention time.Duration) interfaces.UsersRepository {
+	return &UsersRepositoryMemory{
+		activityActions: activityActions,
+		retention:       retention,
+		activity:        make(map[string][]models.UserAction),
+	}
+}
+
+func (m *UsersRepositoryMemory) SaveAction(ua models.UserAction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.saveAction(ua)
+	return nil
+}
+
+func (m *UsersRepositoryMemory) SaveActions(actions []models.UserAction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, ua := range actions {
+		m.saveAction(ua)
+	}
+	return nil
+}
+
+func (m *UsersRepositoryMemory) saveAction(ua models.UserAction) {
+	// Newest first, trimmed the same way as the sorted set in Redis
+	actions := append(m.activity[ua.Username], ua)
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Timestamp.After(actions[j].Timestamp) })
+	if m.retention > 0 {
+		cutoff := time.Now().Add(-m.retention)
+		for len(actions) > 0 && actions[len(actions)-1].Timestamp.Before(cutoff) {
+			actions = actions[:len(actions)-1]
+		}
+	}
+	if len(actions) > m.activityActions {
+		actions = actions[:m.activityActions]
+	}
+	m.activity[ua.Username] = actions
+}
+
+func (m *UsersRepositoryMemory) GetActivity(username string, filters models.ActivityFilters) (*models.UserActivity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	activity := &models.UserActivity{Events: make([]models.UserAction, 0)}
+	for _, action := range m.activity[username] {
+		if !filters.Matches(action) {
+			continue
+		}
+		if activity.Total >= filters.Offset && len(activity.Events) < filters.Limit {
+			activity.Events = append(activity.Events, action)
+		}
+		activity.Total++
+	}
+	return activity, nil
+}
+
+func (m *UsersRepositoryMemory) ClearActivity(username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.activity, username)
+	return nil
+}
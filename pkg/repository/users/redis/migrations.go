@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"log"
+	"pkg/service/pkg/consts"
+	"time"
+)
+
+// DeleteLegacyActivity deletes the activity lists kept before activity moved
+// to sorted sets of events. They only held the last few action names without
+// a time, so there is nothing to carry over into the events.
+func DeleteLegacyActivity() (int, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return 0, err
+	}
+	defer client.Close()
+
+	deleted := 0
+	var cursor uint64
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+		var keys []string
+		keys, cursor, err = client.Scan(ctx, cursor, consts.LegacyUserActivityRedisKeys, consts.LegacyUserActivityDeleteBatchSize).Result()
+		if err == nil && len(keys) > 0 {
+			err = client.Del(ctx, keys...).Err()
+		}
+		cancel()
+		if err != nil {
+			log.Printf("error deleting legacy user activity: %s", err)
+			return deleted, errors.New("error deleting legacy user activity")
+		}
+
+		deleted += len(keys)
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
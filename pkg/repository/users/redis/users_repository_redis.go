@@ -1,26 +1,44 @@
 package redis
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/go-redis/redis/v8"
 	"log"
 	"pkg/service/pkg/interfaces"
 	"pkg/service/pkg/models"
+	"time"
 )
 
 var _ interfaces.UsersRepository = &UsersRepositoryRedis{}
 
+// UsersRepositoryRedis keeps the activity of each user in a sorted set scored
+// by event time, trimmed to the newest activityActions events and to the
+// retention period.
 type UsersRepositoryRedis struct {
 	activityActions int64
+	retention       time.Duration
 }
 
-func NewUsersRepositoryRedis(activityActions int) interfaces.UsersRepository {
+func NewUsersRepositoryRedis(activityActions int, retention time.Duration) interfaces.UsersRepository {
 	return &UsersRepositoryRedis{
 		activityActions: int64(activityActions),
+		retention:       retention,
 	}
 }
 
 func (r *UsersRepositoryRedis) SaveAction(ua models.UserAction) error {
+	return r.SaveActions([]models.UserAction{ua})
+}
+
+// SaveActions writes the actions of every user in one pipeline, and then trims
+// the keys it touched in a second one.
+func (r *UsersRepositoryRedis) SaveActions(actions []models.UserAction) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
 	client, err := newRedisClient()
 	if err != nil {
 		log.Printf("error creating redis client: %s", err)
@@ -28,23 +46,35 @@ func (r *UsersRepositoryRedis) SaveAction(ua models.UserAction) error {
 	}
 	defer client.Close()
 
-	key := createUsernameKey(ua.Username)
+	events := make(map[string][]*redis.Z)
+	for _, ua := range actions {
+		event, err := json.Marshal(ua)
+		if err != nil {
+			return err
+		}
+		key := createUsernameKey(ua.Username)
+		events[key] = append(events[key], &redis.Z{Score: float64(ua.Timestamp.UnixMilli()), Member: string(event)})
+	}
 
-	err = pushValueToKey(client, key, ua.Action)
+	err = addEventsToKeys(client, events)
 	if err != nil {
-		log.Printf("error saving action for user %s: %s", ua.Username, err)
+		log.Printf("error saving %d user actions: %s", len(actions), err)
 		return err
 	}
 
-	err = r.trimKeyValues(client, key)
+	keys := make([]string, 0, len(events))
+	for key := range events {
+		keys = append(keys, key)
+	}
+	err = r.trimKeyEvents(client, keys...)
 	if err != nil {
-		log.Printf("error trimming action for user %s: %s", ua.Username, err)
+		log.Printf("error trimming user actions: %s", err)
 	}
 
 	return nil
 }
 
-func (r *UsersRepositoryRedis) GetActivity(username string) (*models.UserActivity, error) {
+func (r *UsersRepositoryRedis) GetActivity(username string, filters models.ActivityFilters) (*models.UserActivity, error) {
 	client, err := newRedisClient()
 	if err != nil {
 		log.Printf("error creating redis client: %s", err)
@@ -53,11 +83,38 @@ func (r *UsersRepositoryRedis) GetActivity(username string) (*models.UserActivit
 	defer client.Close()
 
 	key := createUsernameKey(username)
-	actions, err := r.getRangeForKey(client, key)
+	total, events, err := getEventsForKey(client, key, filters)
 	if err != nil {
 		log.Printf("error getting activity for user %s: %s", username, err)
 		return nil, errors.New(fmt.Sprintf("error getting activity for user %s", username))
 	}
 
-	return &models.UserActivity{Actions: actions}, nil
+	activity := &models.UserActivity{Events: make([]models.UserAction, 0, len(events)), Total: int(total)}
+	for _, event := range events {
+		action := models.UserAction{}
+		if err = json.Unmarshal([]byte(event), &action); err != nil {
+			log.Printf("error reading activity event for user %s: %s", username, err)
+			continue
+		}
+		activity.Events = append(activity.Events, action)
+	}
+
+	return activity, nil
+}
+
+func (r *UsersRepositoryRedis) ClearActivity(username string) error {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	err = deleteKey(client, createUsernameKey(username))
+	if err != nil {
+		log.Printf("error clearing activity for user %s: %s", username, err)
+		return errors.New(fmt.Sprintf("error clearing activity for user %s", username))
+	}
+
+	return nil
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/go-redis/redis/v8"
 	"os"
 	"pkg/service/pkg/consts"
+	"pkg/service/pkg/models"
+	"strconv"
 	"time"
 )
 
@@ -32,20 +34,79 @@ func createUsernameKey(username string) string {
 	return fmt.Sprintf(consts.UserActivityRedisKey, username)
 }
 
-func pushValueToKey(client *redis.Client, key string, value any) error {
+func eventScore(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func addEventsToKeys(client *redis.Client, events map[string][]*redis.Z) error {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, members := range events {
+			pipe.ZAdd(ctx, key, members...)
+		}
+		return nil
+	})
+	return err
+}
+
+// trimKeyEvents drops the events older than the retention period and all but
+// the newest activityActions, and lets the keys expire once the user is idle
+// for a whole retention period.
+func (r *UsersRepositoryRedis) trimKeyEvents(client *redis.Client, keys ...string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
 	defer cancel()
-	return client.LPush(ctx, key, value).Err()
+
+	cutoff := "(" + eventScore(time.Now().Add(-r.retention))
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			if r.retention > 0 {
+				pipe.ZRemRangeByScore(ctx, key, "-inf", cutoff)
+				pipe.Expire(ctx, key, r.retention)
+			}
+			pipe.ZRemRangeByRank(ctx, key, 0, -r.activityActions-1)
+		}
+		return nil
+	})
+	return err
 }
 
-func (r *UsersRepositoryRedis) trimKeyValues(client *redis.Client, key string) error {
+// getEventsForKey returns how many events fall in the filtered time range,
+// and the requested page of them, newest first.
+func getEventsForKey(client *redis.Client, key string, filters models.ActivityFilters) (int64, []string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
 	defer cancel()
-	return client.LTrim(ctx, key, 0, r.activityActions-1).Err()
+
+	min, max := "-inf", "+inf"
+	if !filters.From.IsZero() {
+		min = eventScore(filters.From)
+	}
+	if !filters.To.IsZero() {
+		max = eventScore(filters.To)
+	}
+
+	var total *redis.IntCmd
+	var events *redis.StringSliceCmd
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		total = pipe.ZCount(ctx, key, min, max)
+		events = pipe.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+			Min:    min,
+			Max:    max,
+			Offset: int64(filters.Offset),
+			Count:  int64(filters.Limit),
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return total.Val(), events.Val(), nil
 }
 
-func (r *UsersRepositoryRedis) getRangeForKey(client *redis.Client, key string) ([]string, error) {
+func deleteKey(client *redis.Client, key string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
 	defer cancel()
-	return client.LRange(ctx, key, 0, r.activityActions-1).Result()
+	return client.Del(ctx, key).Err()
 }
@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ interfaces.WebhooksRepository = &WebhooksRepositoryMemory{}
+
+type WebhooksRepositoryMemory struct {
+	mu            sync.RWMutex
+	subscriptions map[string]models.WebhookSubscription
+	deliveries    map[string]models.WebhookDelivery
+	// history holds the delivery ids of every subscription, oldest first
+	history map[string][]string
+	claims  map[string]claim
+}
+
+type claim struct {
+	id          string
+	leasedUntil time.Time
+}
+
+func NewWebhooksRepositoryMemory() interfaces.WebhooksRepository {
+	return &WebhooksRepositoryMemory{
+		subscriptions: make(map[string]models.WebhookSubscription),
+		deliveries:    make(map[string]models.WebhookDelivery),
+		history:       make(map[string][]string),
+		claims:        make(map[string]claim),
+	}
+}
+
+func (m *WebhooksRepositoryMemory) CreateSubscription(subscription models.WebhookSubscription) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subscription.Id = newId()
+	m.subscriptions[subscription.Id] = subscription
+	return subscription.Id, nil
+}
+
+func (m *WebhooksRepositoryMemory) GetSubscriptions() ([]models.WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subscriptions := make([]models.WebhookSubscription, 0, len(m.subscriptions))
+	for _, subscription := range m.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+func (m *WebhooksRepositoryMemory) GetSubscription(id string) (*models.WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subscription, found := m.subscriptions[id]
+	if !found {
+		return nil, &models.NotFoundError{Resource: "webhook", Id: id}
+	}
+	return &subscription, nil
+}
+
+func (m *WebhooksRepositoryMemory) DeleteSubscription(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.subscriptions[id]; !found {
+		return &models.NotFoundError{Resource: "webhook", Id: id}
+	}
+	delete(m.subscriptions, id)
+	for _, deliveryId := range m.history[id] {
+		delete(m.deliveries, deliveryId)
+		delete(m.claims, deliveryId)
+	}
+	delete(m.history, id)
+	return nil
+}
+
+func (m *WebhooksRepositoryMemory) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		m.deliveries[delivery.Id] = delivery
+		history := append(m.history[delivery.SubscriptionId], delivery.Id)
+		if len(history) > consts.WebhookDeliveryHistory {
+			for _, deliveryId := range history[:len(history)-consts.WebhookDeliveryHistory] {
+				delete(m.deliveries, deliveryId)
+				delete(m.claims, deliveryId)
+			}
+			history = history[len(history)-consts.WebhookDeliveryHistory:]
+		}
+		m.history[delivery.SubscriptionId] = history
+	}
+	return nil
+}
+
+// UpdateDelivery releases the claim on the delivery. It leaves out deliveries
+// that were trimmed from the history or removed with their subscription in
+// the meantime.
+func (m *WebhooksRepositoryMemory) UpdateDelivery(delivery models.WebhookDelivery, claimId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.deliveries[delivery.Id]; !found {
+		return nil
+	}
+	if m.claims[delivery.Id].id != claimId {
+		return &models.ConflictError{Message: "the claim on the webhook delivery was lost"}
+	}
+	m.deliveries[delivery.Id] = delivery
+	delete(m.claims, delivery.Id)
+	return nil
+}
+
+func (m *WebhooksRepositoryMemory) GetDelivery(id string) (*models.WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	delivery, found := m.deliveries[id]
+	if !found {
+		return nil, &models.NotFoundError{Resource: "webhook delivery", Id: id}
+	}
+	return &delivery, nil
+}
+
+// GetDeliveries returns the newest deliveries first, like the Redis repository.
+func (m *WebhooksRepositoryMemory) GetDeliveries(subscriptionId string, limit int) ([]models.WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	history := m.history[subscriptionId]
+	deliveries := make([]models.WebhookDelivery, 0)
+	for i := len(history) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		deliveries = append(deliveries, m.deliveries[history[i]])
+	}
+	return deliveries, nil
+}
+
+func (m *WebhooksRepositoryMemory) DueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	due := make([]models.WebhookDelivery, 0)
+	for _, delivery := range m.deliveries {
+		if m.due(delivery, now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// ClaimDelivery returns a nil delivery when it is no longer due, such as when
+// someone else claimed it first.
+func (m *WebhooksRepositoryMemory) ClaimDelivery(id string, now time.Time, lease time.Duration) (*models.WebhookDelivery, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery, found := m.deliveries[id]
+	if !found || !m.due(delivery, now) {
+		return nil, "", nil
+	}
+	c := claim{id: newId(), leasedUntil: now.Add(lease)}
+	m.claims[id] = c
+	return &delivery, c.id, nil
+}
+
+func (m *WebhooksRepositoryMemory) due(delivery models.WebhookDelivery, now time.Time) bool {
+	if delivery.Status != consts.WebhookDeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+		return false
+	}
+	c, claimed := m.claims[delivery.Id]
+	return !claimed || !c.leasedUntil.After(now)
+}
+
+func newId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
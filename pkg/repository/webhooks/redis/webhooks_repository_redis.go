@@ -0,0 +1,419 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var _ interfaces.WebhooksRepository = &WebhooksRepositoryRedis{}
+
+// WebhooksRepositoryRedis keeps subscriptions and deliveries in hashes keyed
+// by id. Every subscription has a sorted set of its delivery ids by creation
+// time, and pending deliveries are in a sorted set by next attempt time. A
+// claimed delivery is moved to the end of its lease in that set, so it is not
+// due for anyone else in the meantime, and the id of the claim is kept in a
+// hash by delivery id.
+type WebhooksRepositoryRedis struct{}
+
+// claimScript pushes a due delivery back by the lease and records the claim
+// in one step, so concurrent dispatchers never both claim it.
+var claimScript = redis.NewScript(`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score or tonumber(score) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[4])
+return 1
+`)
+
+// updateScript saves a delivery only for the holder of the latest claim on
+// it, and only while it was not trimmed or removed, releasing the claim.
+var updateScript = redis.NewScript(`
+if (redis.call('HGET', KEYS[3], ARGV[1]) or '') ~= ARGV[2] then
+	return -1
+end
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+if ARGV[4] == '' then
+	redis.call('ZREM', KEYS[2], ARGV[1])
+else
+	redis.call('ZADD', KEYS[2], ARGV[4], ARGV[1])
+end
+redis.call('HDEL', KEYS[3], ARGV[1])
+return 1
+`)
+
+func NewWebhooksRepositoryRedis() interfaces.WebhooksRepository {
+	return &WebhooksRepositoryRedis{}
+}
+
+func (r *WebhooksRepositoryRedis) CreateSubscription(subscription models.WebhookSubscription) (string, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return "", err
+	}
+	defer client.Close()
+
+	subscription.Id = newId()
+	value, err := json.Marshal(subscription)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	if err = client.HSet(ctx, consts.WebhookSubscriptionsRedisKey, subscription.Id, value).Err(); err != nil {
+		log.Printf("error creating webhook subscription: %s", err)
+		return "", errors.New("error creating webhook subscription")
+	}
+
+	return subscription.Id, nil
+}
+
+func (r *WebhooksRepositoryRedis) GetSubscriptions() ([]models.WebhookSubscription, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	values, err := client.HVals(ctx, consts.WebhookSubscriptionsRedisKey).Result()
+	if err != nil {
+		log.Printf("error getting webhook subscriptions: %s", err)
+		return nil, errors.New("error getting webhook subscriptions")
+	}
+
+	subscriptions := make([]models.WebhookSubscription, 0, len(values))
+	for _, value := range values {
+		subscription := models.WebhookSubscription{}
+		if err = json.Unmarshal([]byte(value), &subscription); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+func (r *WebhooksRepositoryRedis) GetSubscription(id string) (*models.WebhookSubscription, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	value, err := client.HGet(ctx, consts.WebhookSubscriptionsRedisKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, &models.NotFoundError{Resource: "webhook", Id: id}
+	}
+	if err != nil {
+		log.Printf("error getting webhook subscription %s: %s", id, err)
+		return nil, errors.New("error getting webhook subscription")
+	}
+
+	subscription := models.WebhookSubscription{}
+	if err = json.Unmarshal([]byte(value), &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhooksRepositoryRedis) DeleteSubscription(id string) error {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	historyKey := createSubscriptionDeliveriesKey(id)
+	deliveryIds, err := client.ZRange(ctx, historyKey, 0, -1).Result()
+	if err != nil {
+		log.Printf("error getting deliveries of webhook subscription %s: %s", id, err)
+		return errors.New("error deleting webhook subscription")
+	}
+
+	var deleted *redis.IntCmd
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.HDel(ctx, consts.WebhookSubscriptionsRedisKey, id)
+		removeDeliveries(ctx, pipe, deliveryIds)
+		pipe.Del(ctx, historyKey)
+		return nil
+	})
+	if err != nil {
+		log.Printf("error deleting webhook subscription %s: %s", id, err)
+		return errors.New("error deleting webhook subscription")
+	}
+	if deleted.Val() == 0 {
+		return &models.NotFoundError{Resource: "webhook", Id: id}
+	}
+
+	return nil
+}
+
+// CreateDeliveries trims the history of every subscription the deliveries
+// belong to, dropping the oldest deliveries past consts.WebhookDeliveryHistory.
+func (r *WebhooksRepositoryRedis) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	historyKeys := make(map[string]bool)
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, delivery := range deliveries {
+			if err := saveDelivery(ctx, pipe, delivery); err != nil {
+				return err
+			}
+			historyKey := createSubscriptionDeliveriesKey(delivery.SubscriptionId)
+			pipe.ZAdd(ctx, historyKey, &redis.Z{Score: float64(delivery.CreatedAt.UnixNano()), Member: delivery.Id})
+			historyKeys[historyKey] = true
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error creating %d webhook deliveries: %s", len(deliveries), err)
+		return errors.New("error creating webhook deliveries")
+	}
+
+	for historyKey := range historyKeys {
+		if err = trimHistory(ctx, client, historyKey); err != nil {
+			log.Printf("error trimming webhook deliveries of %s: %s", historyKey, err)
+			return errors.New("error creating webhook deliveries")
+		}
+	}
+
+	return nil
+}
+
+// UpdateDelivery leaves out deliveries that were trimmed from the history or
+// removed with their subscription in the meantime.
+func (r *WebhooksRepositoryRedis) UpdateDelivery(delivery models.WebhookDelivery, claim string) error {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return err
+	}
+	defer client.Close()
+
+	value, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	dueAt := ""
+	if delivery.Status == consts.WebhookDeliveryPending && delivery.NextAttemptAt != nil {
+		dueAt = strconv.FormatInt(delivery.NextAttemptAt.UnixMilli(), 10)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	keys := []string{consts.WebhookDeliveriesRedisKey, consts.WebhookDueDeliveriesRedisKey, consts.WebhookDeliveryClaimsRedisKey}
+	updated, err := updateScript.Run(ctx, client, keys, delivery.Id, claim, value, dueAt).Int()
+	if err != nil {
+		log.Printf("error updating webhook delivery %s: %s", delivery.Id, err)
+		return errors.New("error updating webhook delivery")
+	}
+	if updated < 0 {
+		return &models.ConflictError{Message: "the claim on the webhook delivery was lost"}
+	}
+
+	return nil
+}
+
+func (r *WebhooksRepositoryRedis) GetDelivery(id string) (*models.WebhookDelivery, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	value, err := client.HGet(ctx, consts.WebhookDeliveriesRedisKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, &models.NotFoundError{Resource: "webhook delivery", Id: id}
+	}
+	if err != nil {
+		log.Printf("error getting webhook delivery %s: %s", id, err)
+		return nil, errors.New("error getting webhook delivery")
+	}
+
+	delivery := models.WebhookDelivery{}
+	if err = json.Unmarshal([]byte(value), &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhooksRepositoryRedis) GetDeliveries(subscriptionId string, limit int) ([]models.WebhookDelivery, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	deliveryIds, err := client.ZRevRange(ctx, createSubscriptionDeliveriesKey(subscriptionId), 0, int64(limit-1)).Result()
+	if err != nil {
+		log.Printf("error getting deliveries of webhook subscription %s: %s", subscriptionId, err)
+		return nil, errors.New("error getting webhook deliveries")
+	}
+
+	return getDeliveries(ctx, client, deliveryIds)
+}
+
+func (r *WebhooksRepositoryRedis) DueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	deliveryIds, err := client.ZRangeByScore(ctx, consts.WebhookDueDeliveriesRedisKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		log.Printf("error getting due webhook deliveries: %s", err)
+		return nil, errors.New("error getting due webhook deliveries")
+	}
+
+	return getDeliveries(ctx, client, deliveryIds)
+}
+
+// ClaimDelivery returns a nil delivery when it is no longer due, such as when
+// someone else claimed it first.
+func (r *WebhooksRepositoryRedis) ClaimDelivery(id string, now time.Time, lease time.Duration) (*models.WebhookDelivery, string, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		log.Printf("error creating redis client: %s", err)
+		return nil, "", err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.UsersRequestTimeout*time.Second)
+	defer cancel()
+	claim := newId()
+	keys := []string{consts.WebhookDueDeliveriesRedisKey, consts.WebhookDeliveryClaimsRedisKey}
+	claimed, err := claimScript.Run(ctx, client, keys, id, now.UnixMilli(), now.Add(lease).UnixMilli(), claim).Int()
+	if err != nil {
+		log.Printf("error claiming webhook delivery %s: %s", id, err)
+		return nil, "", errors.New("error claiming webhook delivery")
+	}
+	if claimed == 0 {
+		return nil, "", nil
+	}
+
+	deliveries, err := getDeliveries(ctx, client, []string{id})
+	if err != nil || len(deliveries) == 0 {
+		return nil, "", err
+	}
+	return &deliveries[0], claim, nil
+}
+
+// saveDelivery writes the delivery and keeps it in the due set only while it
+// is pending.
+func saveDelivery(ctx context.Context, pipe redis.Pipeliner, delivery models.WebhookDelivery) error {
+	value, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	pipe.HSet(ctx, consts.WebhookDeliveriesRedisKey, delivery.Id, value)
+	if delivery.Status == consts.WebhookDeliveryPending && delivery.NextAttemptAt != nil {
+		pipe.ZAdd(ctx, consts.WebhookDueDeliveriesRedisKey, &redis.Z{Score: float64(delivery.NextAttemptAt.UnixMilli()), Member: delivery.Id})
+	} else {
+		pipe.ZRem(ctx, consts.WebhookDueDeliveriesRedisKey, delivery.Id)
+	}
+	return nil
+}
+
+func removeDeliveries(ctx context.Context, pipe redis.Pipeliner, deliveryIds []string) {
+	if len(deliveryIds) == 0 {
+		return
+	}
+	members := make([]interface{}, 0, len(deliveryIds))
+	for _, deliveryId := range deliveryIds {
+		members = append(members, deliveryId)
+	}
+	pipe.HDel(ctx, consts.WebhookDeliveriesRedisKey, deliveryIds...)
+	pipe.HDel(ctx, consts.WebhookDeliveryClaimsRedisKey, deliveryIds...)
+	pipe.ZRem(ctx, consts.WebhookDueDeliveriesRedisKey, members...)
+}
+
+func trimHistory(ctx context.Context, client *redis.Client, historyKey string) error {
+	trimmed, err := client.ZRange(ctx, historyKey, 0, -consts.WebhookDeliveryHistory-1).Result()
+	if err != nil || len(trimmed) == 0 {
+		return err
+	}
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removeDeliveries(ctx, pipe, trimmed)
+		pipe.ZRemRangeByRank(ctx, historyKey, 0, int64(len(trimmed)-1))
+		return nil
+	})
+	return err
+}
+
+// getDeliveries keeps the order of the ids and skips deliveries that are
+// gone, such as the ones trimmed concurrently.
+func getDeliveries(ctx context.Context, client *redis.Client, deliveryIds []string) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0, len(deliveryIds))
+	if len(deliveryIds) == 0 {
+		return deliveries, nil
+	}
+
+	values, err := client.HMGet(ctx, consts.WebhookDeliveriesRedisKey, deliveryIds...).Result()
+	if err != nil {
+		log.Printf("error getting webhook deliveries: %s", err)
+		return nil, errors.New("error getting webhook deliveries")
+	}
+	for _, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		delivery := models.WebhookDelivery{}
+		if err = json.Unmarshal([]byte(raw), &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
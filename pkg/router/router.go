@@ -1,6 +1,7 @@
 package router
 
 import (
+	"expvar"
 	"github.com/gin-gonic/gin"
 	"pkg/service/pkg/consts"
 	"pkg/service/pkg/controller"
@@ -10,15 +11,55 @@ import (
 
 func NewRouter(controller *controller.LibraryController, usersHandler *interfaces.UsersHandler) *gin.Engine {
 	router := gin.Default()
+	router.Use(user_activity_middleware.RequestId())
 	router.Use(user_activity_middleware.Middleware(*usersHandler))
 
 	router.POST(consts.CreateBookUrlPath, controller.CreateBook)
 	router.GET(consts.GetBooksUrlPath, controller.GetBooks)
+	router.POST(consts.BulkBooksUrlPath, controller.BulkBooks)
+	router.POST(consts.BulkBooksByQueryUrlPath, controller.BulkBooksByQuery)
+	router.POST(consts.ImportBooksUrlPath, controller.ImportBooks)
+	router.GET(consts.ExportBooksUrlPath, controller.ExportBooks)
+	router.GET(consts.SuggestBooksUrlPath, controller.SuggestBooks)
 	router.GET(consts.GetBookUrlPath, controller.GetBookById)
 	router.PUT(consts.UpdateBookUrlPath, controller.UpdateBookTitle)
+	router.PATCH(consts.PatchBookUrlPath, controller.UpdateBookTitle)
 	router.DELETE(consts.DeleteBookUrlPath, controller.DeleteBook)
+	router.GET(consts.GetTrashUrlPath, controller.GetTrash)
+	router.POST(consts.RestoreBookUrlPath, controller.RestoreBook)
+	router.DELETE(consts.PurgeBookUrlPath, controller.PurgeBook)
+	router.GET(consts.GetBookHistoryUrlPath, controller.GetBookHistory)
+	router.GET(consts.GetSimilarBooksUrlPath, controller.GetSimilarBooks)
+	router.GET(consts.GetAlsoViewedBooksUrlPath, controller.GetAlsoViewedBooks)
+	router.GET(consts.GetAuditUrlPath, controller.GetAudit)
 	router.GET(consts.GetStoreInventoryUrlPath, controller.GetStoreInventory)
 	router.GET(consts.GetUserActivityUrlPath, controller.GetUserActivity)
+	router.POST(consts.CreateSavedSearchUrlPath, controller.CreateSavedSearch)
+	router.GET(consts.GetSavedSearchesUrlPath, controller.GetSavedSearches)
+	router.DELETE(consts.DeleteSavedSearchUrlPath, controller.DeleteSavedSearch)
+	router.GET(consts.GetNotificationsUrlPath, controller.GetNotifications)
+	router.POST(consts.CreateBranchUrlPath, controller.CreateBranch)
+	router.GET(consts.GetBranchesUrlPath, controller.GetBranches)
+	router.GET(consts.GetBranchInventoryUrlPath, controller.GetBranchInventory)
+	router.POST(consts.AddBranchCopiesUrlPath, controller.AddBranchCopies)
+	router.POST(consts.CreateTransferUrlPath, controller.CreateTransfer)
+	router.POST(consts.CompleteTransferUrlPath, controller.CompleteTransfer)
+	router.GET(consts.GetAuthorsUrlPath, controller.GetAuthors)
+	router.POST(consts.CreateAuthorUrlPath, controller.CreateAuthor)
+	router.GET(consts.GetAuthorUrlPath, controller.GetAuthorById)
+	router.POST(consts.MergeAuthorsUrlPath, controller.MergeAuthors)
+	router.GET(consts.GetTopBooksUrlPath, controller.GetTopBooks)
+	router.GET(consts.GetTopUsersUrlPath, controller.GetTopUsers)
+	router.GET(consts.GetTrafficUrlPath, controller.GetTraffic)
+	router.POST(consts.CreateWebhookUrlPath, controller.CreateWebhook)
+	router.GET(consts.GetWebhooksUrlPath, controller.GetWebhooks)
+	router.DELETE(consts.DeleteWebhookUrlPath, controller.DeleteWebhook)
+	router.GET(consts.GetWebhookDeliveriesUrlPath, controller.GetWebhookDeliveries)
+	router.POST(consts.ReplayWebhookDeliveryUrlPath, controller.ReplayWebhookDelivery)
+	router.GET(consts.GetConsumerEventsUrlPath, controller.GetConsumerEvents)
+	router.PUT(consts.CommitConsumerOffsetUrlPath, controller.CommitConsumerOffset)
+	router.GET(consts.StreamEventsUrlPath, controller.StreamEvents)
+	router.GET(consts.DebugVarsUrlPath, gin.WrapH(expvar.Handler()))
 
 	return router
 }
@@ -0,0 +1,336 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ interfaces.WebhookDispatcher = &Dispatcher{}
+
+type Options struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	PollInterval   time.Duration
+	Lease          time.Duration
+	Workers        int
+	Client         *http.Client
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts:    consts.WebhookMaxAttempts,
+		InitialBackoff: consts.WebhookInitialBackoffSeconds * time.Second,
+		MaxBackoff:     consts.WebhookMaxBackoffSeconds * time.Second,
+		PollInterval:   consts.WebhookPollIntervalSeconds * time.Second,
+		Lease:          consts.WebhookClaimLeaseSeconds * time.Second,
+		Workers:        consts.WebhookWorkers,
+		Client:         &http.Client{Timeout: consts.WebhookRequestTimeout * time.Second},
+	}
+}
+
+// Dispatcher stores a pending delivery per event and subscriber, and sends
+// the deliveries that are due from Run. Failed attempts are retried with
+// exponential backoff until MaxAttempts, after which the delivery is failed
+// and can only be replayed by hand. Since pending deliveries are stored, the
+// ones left when the service stops are sent once it runs again. Each
+// delivery is claimed for Lease just before it is sent, which has to outlast
+// an attempt, and its outcome is only saved while the claim is still held, so
+// several instances can run a dispatcher without sending a delivery twice.
+// Up to Workers subscriptions are sent to at once, each of them one delivery
+// at a time so a slow subscriber only holds up its own deliveries.
+type Dispatcher struct {
+	webhooksRepository interfaces.WebhooksRepository
+	options            Options
+	wake               chan struct{}
+}
+
+func NewDispatcher(webhooksRepository interfaces.WebhooksRepository, options Options) *Dispatcher {
+	return &Dispatcher{
+		webhooksRepository: webhooksRepository,
+		options:            options,
+		wake:               make(chan struct{}, 1),
+	}
+}
+
+func (d *Dispatcher) Dispatch(events []models.BookEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	subscriptions, err := d.webhooksRepository.GetSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		for _, subscription := range subscriptions {
+			if !subscription.Subscribes(event.Type) {
+				continue
+			}
+			deliveries = append(deliveries, models.WebhookDelivery{
+				Id:             newId(),
+				SubscriptionId: subscription.Id,
+				EventId:        event.Id,
+				EventType:      event.Type,
+				Payload:        payload,
+				Status:         consts.WebhookDeliveryPending,
+				NextAttemptAt:  &now,
+				CreatedAt:      now,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err = d.webhooksRepository.CreateDeliveries(deliveries); err != nil {
+		return err
+	}
+	d.notify()
+	return nil
+}
+
+// Replay sends the payload of a delivery again as a new delivery, whatever
+// the outcome of the original was.
+func (d *Dispatcher) Replay(deliveryId string) (*models.WebhookDelivery, error) {
+	original, err := d.webhooksRepository.GetDelivery(deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = d.webhooksRepository.GetSubscription(original.SubscriptionId); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	replay := models.WebhookDelivery{
+		Id:             newId(),
+		SubscriptionId: original.SubscriptionId,
+		EventId:        original.EventId,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         consts.WebhookDeliveryPending,
+		NextAttemptAt:  &now,
+		ReplayOf:       original.Id,
+		CreatedAt:      now,
+	}
+	if err = d.webhooksRepository.CreateDeliveries([]models.WebhookDelivery{replay}); err != nil {
+		return nil, err
+	}
+	d.notify()
+	return &replay, nil
+}
+
+// Run sends the due deliveries until ctx is done, looking for them every poll
+// interval and as soon as new deliveries are stored.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := d.webhooksRepository.DueDeliveries(time.Now().UTC(), consts.WebhookDueBatchSize)
+		if err != nil {
+			log.Printf("failed to get due webhook deliveries: %s", err.Error())
+			return
+		}
+		// Deliveries that could not be claimed are left due, so a full batch
+		// is only fetched again while some of it was sent
+		if d.deliverAll(ctx, due) == 0 || len(due) < consts.WebhookDueBatchSize {
+			return
+		}
+	}
+}
+
+// deliverAll sends the deliveries of every subscription in order, handing the
+// subscriptions out to the workers, and returns how many of them it claimed.
+func (d *Dispatcher) deliverAll(ctx context.Context, due []models.WebhookDelivery) int {
+	bySubscription := make(map[string][]models.WebhookDelivery)
+	subscriptionIds := make([]string, 0)
+	for _, delivery := range due {
+		if _, found := bySubscription[delivery.SubscriptionId]; !found {
+			subscriptionIds = append(subscriptionIds, delivery.SubscriptionId)
+		}
+		bySubscription[delivery.SubscriptionId] = append(bySubscription[delivery.SubscriptionId], delivery)
+	}
+
+	workers := d.options.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(subscriptionIds) {
+		workers = len(subscriptionIds)
+	}
+	queue := make(chan []models.WebhookDelivery)
+	var claimed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for deliveries := range queue {
+				for _, delivery := range deliveries {
+					if d.deliver(ctx, delivery.Id) {
+						claimed.Add(1)
+					}
+				}
+			}
+		}()
+	}
+	for _, subscriptionId := range subscriptionIds {
+		queue <- bySubscription[subscriptionId]
+	}
+	close(queue)
+	wg.Wait()
+	return int(claimed.Load())
+}
+
+// deliver claims the delivery and makes an attempt at sending it, and returns
+// whether it was claimed. It is skipped when it is no longer due, such as when
+// another dispatcher claimed it since it was listed.
+func (d *Dispatcher) deliver(ctx context.Context, deliveryId string) bool {
+	delivery, claim, err := d.webhooksRepository.ClaimDelivery(deliveryId, time.Now().UTC(), d.options.Lease)
+	if err != nil {
+		log.Printf("failed to claim webhook delivery %s: %s", deliveryId, err.Error())
+		return false
+	}
+	if delivery == nil {
+		return false
+	}
+	d.attempt(ctx, *delivery, claim)
+	return true
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery models.WebhookDelivery, claim string) {
+	subscription, err := d.webhooksRepository.GetSubscription(delivery.SubscriptionId)
+	var notFound *models.NotFoundError
+	if errors.As(err, &notFound) {
+		d.finish(delivery, claim, consts.WebhookDeliveryFailed, 0, "webhook was deleted")
+		return
+	}
+	if err != nil {
+		log.Printf("failed to get webhook subscription %s: %s", delivery.SubscriptionId, err.Error())
+		return
+	}
+
+	statusCode, err := d.send(ctx, *subscription, delivery)
+	if ctx.Err() != nil {
+		// Shutting down, the claim is released so the delivery is due again
+		// for the next run
+		d.update(delivery, claim)
+		return
+	}
+
+	delivery.Attempts++
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+	}
+	switch {
+	case err == nil && statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices:
+		d.finish(delivery, claim, consts.WebhookDeliverySucceeded, statusCode, "")
+	case delivery.Attempts >= d.options.MaxAttempts:
+		d.finish(delivery, claim, consts.WebhookDeliveryFailed, statusCode, lastError)
+	default:
+		now := time.Now().UTC()
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.LastAttemptAt = &now
+		delivery.NextAttemptAt = &next
+		delivery.LastStatusCode = statusCode
+		delivery.LastError = lastError
+		d.update(delivery, claim)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, subscription models.WebhookSubscription, delivery models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(consts.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(consts.WebhookDeliveryHeader, delivery.Id)
+	req.Header.Set(consts.WebhookSignatureHeader, Sign(subscription.Secret, time.Now(), delivery.Payload))
+
+	res, err := d.options.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	return res.StatusCode, nil
+}
+
+func (d *Dispatcher) finish(delivery models.WebhookDelivery, claim string, status string, statusCode int, lastError string) {
+	now := time.Now().UTC()
+	delivery.Status = status
+	delivery.LastAttemptAt = &now
+	delivery.NextAttemptAt = nil
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = lastError
+	d.update(delivery, claim)
+}
+
+// update saves the outcome of an attempt unless the claim ran out and someone
+// else claimed the delivery since, in which case their outcome is kept.
+func (d *Dispatcher) update(delivery models.WebhookDelivery, claim string) {
+	if err := d.webhooksRepository.UpdateDelivery(delivery, claim); err != nil {
+		log.Printf("failed to update webhook delivery %s: %s", delivery.Id, err.Error())
+	}
+}
+
+// backoff doubles the wait after every failed attempt, up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.options.InitialBackoff
+	for i := 1; i < attempts && wait < d.options.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.options.MaxBackoff {
+		wait = d.options.MaxBackoff
+	}
+	return wait
+}
+
+func newId() string {
+	b := make([]byte, 15)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,329 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"pkg/service/pkg/consts"
+	"pkg/service/pkg/interfaces"
+	"pkg/service/pkg/models"
+	webhooks_repository "pkg/service/pkg/repository/webhooks/memory"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+// receiver is a webhook endpoint answering with the queued status codes, and
+// with the last one once they run out.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	status := r.statuses[0]
+	if len(r.statuses) > 1 {
+		r.statuses = r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func (r *receiver) answer(statuses ...int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = statuses
+}
+
+type dispatcherFixture struct {
+	repository     interfaces.WebhooksRepository
+	dispatcher     *Dispatcher
+	receiver       *receiver
+	subscriptionId string
+}
+
+func newDispatcherFixture(t *testing.T, maxAttempts int, statuses ...int) dispatcherFixture {
+	t.Helper()
+
+	r := &receiver{statuses: statuses}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	repository := webhooks_repository.NewWebhooksRepositoryMemory()
+	subscriptionId, err := repository.CreateSubscription(models.WebhookSubscription{
+		Url:        server.URL,
+		EventTypes: []string{consts.EventBookCreated},
+		Secret:     testSecret,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("create subscription: %s", err)
+	}
+
+	dispatcher := NewDispatcher(repository, Options{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     4 * time.Millisecond,
+		PollInterval:   time.Hour,
+		Lease:          time.Minute,
+		Workers:        4,
+		Client:         server.Client(),
+	})
+	return dispatcherFixture{repository: repository, dispatcher: dispatcher, receiver: r, subscriptionId: subscriptionId}
+}
+
+func (f dispatcherFixture) dispatch(t *testing.T, eventIds ...string) []models.WebhookDelivery {
+	t.Helper()
+
+	events := make([]models.BookEvent, 0, len(eventIds))
+	for _, eventId := range eventIds {
+		events = append(events, models.BookEvent{Id: eventId, Type: consts.EventBookCreated, BookId: "book-" + eventId, Timestamp: time.Now()})
+	}
+	if err := f.dispatcher.Dispatch(events); err != nil {
+		t.Fatalf("dispatch: %s", err)
+	}
+	deliveries, err := f.repository.GetDeliveries(f.subscriptionId, consts.WebhookDeliveryHistory)
+	if err != nil {
+		t.Fatalf("get deliveries: %s", err)
+	}
+	return deliveries
+}
+
+func (f dispatcherFixture) delivery(t *testing.T, id string) models.WebhookDelivery {
+	t.Helper()
+
+	delivery, err := f.repository.GetDelivery(id)
+	if err != nil {
+		t.Fatalf("get delivery: %s", err)
+	}
+	return *delivery
+}
+
+// deliverUntilDone keeps sending the due deliveries until the delivery is no
+// longer pending, waiting out the backoff in between.
+func (f dispatcherFixture) deliverUntilDone(t *testing.T, id string) models.WebhookDelivery {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f.dispatcher.deliverDue(context.Background())
+		delivery := f.delivery(t, id)
+		if delivery.Status != consts.WebhookDeliveryPending {
+			return delivery
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery still pending after %d attempts", delivery.Attempts)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDispatcherSignsDeliveries(t *testing.T) {
+	f := newDispatcherFixture(t, 3, http.StatusOK)
+	delivery := f.dispatch(t, "event-1")[0]
+
+	f.dispatcher.deliverDue(context.Background())
+
+	requests := f.receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	header, body := requests[0].header, requests[0].body
+	if string(body) != string(delivery.Payload) {
+		t.Errorf("got body %s, want %s", body, delivery.Payload)
+	}
+	if header.Get(consts.WebhookDeliveryHeader) != delivery.Id || header.Get(consts.WebhookEventHeader) != consts.EventBookCreated {
+		t.Errorf("got delivery %q and event %q headers", header.Get(consts.WebhookDeliveryHeader), header.Get(consts.WebhookEventHeader))
+	}
+
+	signature := header.Get(consts.WebhookSignatureHeader)
+	if err := VerifySignature(testSecret, signature, body, time.Minute, time.Now()); err != nil {
+		t.Errorf("signature does not verify: %s", err)
+	}
+	if err := VerifySignature("other-secret", signature, body, time.Minute, time.Now()); err == nil {
+		t.Error("signature verifies with another secret")
+	}
+	if err := VerifySignature(testSecret, signature, append(body, ' '), time.Minute, time.Now()); err == nil {
+		t.Error("signature verifies a changed body")
+	}
+	if err := VerifySignature(testSecret, signature, body, time.Minute, time.Now().Add(time.Hour)); err == nil {
+		t.Error("signature verifies outside the tolerance")
+	}
+
+	if sent := f.delivery(t, delivery.Id); sent.Status != consts.WebhookDeliverySucceeded || sent.Attempts != 1 || sent.LastStatusCode != http.StatusOK {
+		t.Errorf("got delivery %+v, want succeeded on the first attempt", sent)
+	}
+}
+
+func TestDispatcherRetriesServerErrors(t *testing.T) {
+	f := newDispatcherFixture(t, 5, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	delivery := f.dispatch(t, "event-1")[0]
+
+	before := time.Now()
+	f.dispatcher.deliverDue(context.Background())
+	retried := f.delivery(t, delivery.Id)
+	if retried.Status != consts.WebhookDeliveryPending || retried.Attempts != 1 || retried.LastStatusCode != http.StatusInternalServerError {
+		t.Fatalf("got delivery %+v, want pending after a failed attempt", retried)
+	}
+	if retried.NextAttemptAt == nil || retried.NextAttemptAt.Before(before.Add(time.Millisecond)) {
+		t.Errorf("got next attempt at %v, want the initial backoff after the attempt", retried.NextAttemptAt)
+	}
+
+	sent := f.deliverUntilDone(t, delivery.Id)
+	if sent.Status != consts.WebhookDeliverySucceeded || sent.Attempts != 3 {
+		t.Errorf("got delivery %+v, want succeeded on the third attempt", sent)
+	}
+	if requests := f.receiver.received(); len(requests) != 3 {
+		t.Errorf("got %d requests, want 3", len(requests))
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	f := newDispatcherFixture(t, 10, http.StatusOK)
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}
+	for i, wait := range want {
+		if got := f.dispatcher.backoff(i + 1); got != wait {
+			t.Errorf("backoff after %d attempts is %s, want %s", i+1, got, wait)
+		}
+	}
+}
+
+func TestDispatcherFailsAfterMaxAttempts(t *testing.T) {
+	f := newDispatcherFixture(t, 3, http.StatusServiceUnavailable)
+	delivery := f.dispatch(t, "event-1")[0]
+
+	failed := f.deliverUntilDone(t, delivery.Id)
+	if failed.Status != consts.WebhookDeliveryFailed || failed.Attempts != 3 || failed.LastStatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got delivery %+v, want failed after 3 attempts", failed)
+	}
+	if failed.NextAttemptAt != nil {
+		t.Errorf("failed delivery has a next attempt at %v", failed.NextAttemptAt)
+	}
+
+	f.dispatcher.deliverDue(context.Background())
+	if requests := f.receiver.received(); len(requests) != 3 {
+		t.Errorf("got %d requests, want 3", len(requests))
+	}
+}
+
+func TestDispatcherReplaysFailedDeliveries(t *testing.T) {
+	f := newDispatcherFixture(t, 1, http.StatusInternalServerError)
+	original := f.dispatch(t, "event-1")[0]
+	if failed := f.deliverUntilDone(t, original.Id); failed.Status != consts.WebhookDeliveryFailed {
+		t.Fatalf("got delivery %+v, want failed", failed)
+	}
+
+	f.receiver.answer(http.StatusOK)
+	replay, err := f.dispatcher.Replay(original.Id)
+	if err != nil {
+		t.Fatalf("replay: %s", err)
+	}
+	if replay.Id == original.Id || replay.ReplayOf != original.Id || replay.Status != consts.WebhookDeliveryPending {
+		t.Fatalf("got replay %+v of %s", replay, original.Id)
+	}
+
+	if sent := f.deliverUntilDone(t, replay.Id); sent.Status != consts.WebhookDeliverySucceeded {
+		t.Errorf("got replay %+v, want succeeded", sent)
+	}
+	if unchanged := f.delivery(t, original.Id); unchanged.Status != consts.WebhookDeliveryFailed {
+		t.Errorf("got original %+v, want it left failed", unchanged)
+	}
+
+	requests := f.receiver.received()
+	last := requests[len(requests)-1]
+	if last.header.Get(consts.WebhookDeliveryHeader) != replay.Id || string(last.body) != string(original.Payload) {
+		t.Errorf("replay sent delivery %q with %s", last.header.Get(consts.WebhookDeliveryHeader), last.body)
+	}
+
+	if _, err = f.dispatcher.Replay("missing"); err == nil {
+		t.Error("replayed a missing delivery")
+	}
+}
+
+func TestDispatchersSendEachDeliveryOnce(t *testing.T) {
+	f := newDispatcherFixture(t, 3, http.StatusOK)
+	eventIds := make([]string, 0)
+	for i := 0; i < 50; i++ {
+		eventIds = append(eventIds, fmt.Sprintf("event-%d", i))
+	}
+	f.dispatch(t, eventIds...)
+
+	other := NewDispatcher(f.repository, f.dispatcher.options)
+	var wg sync.WaitGroup
+	for _, dispatcher := range []*Dispatcher{f.dispatcher, other} {
+		wg.Add(1)
+		go func(dispatcher *Dispatcher) {
+			defer wg.Done()
+			dispatcher.deliverDue(context.Background())
+		}(dispatcher)
+	}
+	wg.Wait()
+
+	sent := make(map[string]int)
+	for _, request := range f.receiver.received() {
+		sent[request.header.Get(consts.WebhookDeliveryHeader)]++
+	}
+	if len(sent) != len(eventIds) {
+		t.Errorf("got %d deliveries sent, want %d", len(sent), len(eventIds))
+	}
+	for deliveryId, count := range sent {
+		if count != 1 {
+			t.Errorf("delivery %s was sent %d times", deliveryId, count)
+		}
+	}
+}
+
+func TestDispatcherKeepsOutcomeOfLatestClaim(t *testing.T) {
+	f := newDispatcherFixture(t, 3, http.StatusOK)
+	delivery := f.dispatch(t, "event-1")[0]
+
+	now := time.Now().UTC()
+	stale, staleClaim, err := f.repository.ClaimDelivery(delivery.Id, now, time.Millisecond)
+	if err != nil || stale == nil {
+		t.Fatalf("claim: %+v %s", stale, err)
+	}
+	if again, _, _ := f.repository.ClaimDelivery(delivery.Id, now, time.Minute); again != nil {
+		t.Fatal("claimed a delivery under lease")
+	}
+
+	// The lease runs out before the first attempt is saved, so the delivery
+	// is claimed and sent again
+	time.Sleep(2 * time.Millisecond)
+	f.dispatcher.deliverDue(context.Background())
+	sent := f.delivery(t, delivery.Id)
+	if sent.Status != consts.WebhookDeliverySucceeded {
+		t.Fatalf("got delivery %+v, want succeeded", sent)
+	}
+
+	stale.Attempts++
+	stale.Status = consts.WebhookDeliveryFailed
+	stale.NextAttemptAt = nil
+	var conflict *models.ConflictError
+	if err = f.repository.UpdateDelivery(*stale, staleClaim); !errors.As(err, &conflict) {
+		t.Errorf("got %v updating with a lost claim, want a conflict", err)
+	}
+	if kept := f.delivery(t, delivery.Id); kept.Status != consts.WebhookDeliverySucceeded || kept.Attempts != 1 {
+		t.Errorf("got delivery %+v, want the outcome of the latest claim", kept)
+	}
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sign returns the signature header of a delivery: the time it was sent and
+// the hex HMAC-SHA256 of "<unix time>.<body>" with the subscription secret.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, signature(secret, unix, body))
+}
+
+// VerifySignature checks a signature header made by Sign, for receivers of
+// the deliveries. Signatures older than tolerance are rejected so a captured
+// delivery cannot be sent again later.
+func VerifySignature(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var unix, expected string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			expected = value
+		}
+	}
+	if unix == "" || expected == "" {
+		return errors.New("malformed signature header")
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return errors.New("malformed signature timestamp")
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp is outside the tolerance")
+	}
+	if !hmac.Equal([]byte(expected), []byte(signature(secret, unix, body))) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+func signature(secret string, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}